@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/smtp"
+	"github.com/mjl-/mox/smtpclient"
+)
+
+// Probing addresses is opt-in for the API, it can be used to find valid
+// addresses at a domain.
+var addressProbe bool
+
+// AddressCheckResult is the result of checking the syntax and deliverability of
+// an email address.
+type AddressCheckResult struct {
+	DurationMS        int
+	Address           string // As given.
+	Valid             bool   // Whether the syntax is valid.
+	SyntaxError       string
+	Localpart         string     // Decoded, without quotes and escapes.
+	Domain            dns.Domain // With ASCII and Unicode (IDNA) form.
+	Normalized        string     // For use in SMTP, with quoted localpart if needed, and ASCII domain unless the localpart is non-ASCII.
+	SMTPUTF8          bool       // Whether the address can only be used with mail servers that support SMTPUTF8.
+	Findings          []Finding
+	HaveMX            bool
+	NullMX            bool       // The domain explicitly does not accept email (RFC 7505).
+	ExpandedDomain    dns.Domain // After following CNAMEs.
+	Hosts             []dns.IPDomain
+	DestinationsError string
+	Deliverable       bool // Whether the domain has hosts to deliver to.
+	Probe             *AddressProbe
+}
+
+// AddressProbe is the result of a RCPT TO for the address at a destination host,
+// without delivering a message.
+type AddressProbe struct {
+	Host           dns.IPDomain // Last one attempted.
+	IP             net.IP
+	Result         string // "accept", "reject", "catchall" or "unknown", e.g. for a temporary error or greylisting.
+	Response       string // To the RCPT TO of the address.
+	Explanation    string // Of the response.
+	RandomAddress  string // Non-existent address at the domain, to detect catch-all.
+	RandomResponse string
+	Error          string
+	Trace          []Proto
+}
+
+func (API) AddressCheck(ctx context.Context, address string, probe bool) AddressCheckResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	if probe {
+		if !addressProbe {
+			xcheckuser(errors.New("not enabled on this instance"), "address probe")
+		}
+		xlimit(ctx, &apiDomainLimiter)
+	}
+
+	log.Debug("addresscheck call", slog.String("address", address), slog.Bool("probe", probe))
+
+	return addressCheck(ctx, log, address, probe)
+}
+
+func addressCheck(ctx context.Context, log mlog.Log, address string, probe bool) (r AddressCheckResult) {
+	start := time.Now()
+	defer func() {
+		r.DurationMS = timeSince(start)
+	}()
+
+	r = AddressCheckResult{Address: address, Findings: []Finding{}, Hosts: []dns.IPDomain{}}
+	add := func(check, severity, title, explanation, remediation string) {
+		r.Findings = append(r.Findings, Finding{check, severity, title, explanation, remediation, 0})
+	}
+
+	addr, err := smtp.ParseAddress(address)
+	if err != nil {
+		r.SyntaxError = err.Error()
+		explanation, remediation := addressSyntaxExplain(address)
+		add("address.syntax", "error", "Invalid address syntax", explanation, remediation)
+		return
+	}
+	r.Valid = true
+	r.Localpart = string(addr.Localpart)
+	r.Domain = addr.Domain
+	r.SMTPUTF8 = addr.Localpart.IsInternational()
+	r.Normalized = addr.Pack(r.SMTPUTF8)
+
+	lp := addr.Localpart.String()
+	if strings.HasPrefix(lp, `"`) {
+		add("address.quoted", "warning", "Quoted localpart", "The localpart must be quoted, e.g. because it has spaces, special characters or dots at the start or end, or consecutive dots. This is valid, but many websites and some mail servers reject such addresses.", "Use an address without quoting if possible.")
+	}
+	if len(lp) > 64 {
+		add("address.localpart-length", "warning", "Long localpart", fmt.Sprintf("The localpart is %d bytes, more than the 64 bytes that mail servers must support (RFC 5321). Some mail servers reject it.", len(lp)), "Use a shorter address.")
+	}
+	if n := len(lp) + 1 + len(addr.Domain.ASCII); n > 254 {
+		add("address.length", "warning", "Long address", fmt.Sprintf("The address is %d bytes, more than the 254 bytes that fit in a forward path (RFC 5321). Mail servers will likely reject it.", n), "Use a shorter address.")
+	}
+	if r.SMTPUTF8 {
+		add("address.smtputf8", "warning", "Non-ASCII localpart", "The localpart has non-ASCII characters. The address can only be used if the mail servers of both sender and recipient support SMTPUTF8 (RFC 6531). Many systems do not yet.", "Provide an ASCII alternative address for systems without SMTPUTF8 support.")
+	}
+	if addr.Domain.Unicode != "" {
+		add("address.idna", "info", "Internationalized domain", "The domain has non-ASCII characters. Its ASCII (IDNA) form is "+addr.Domain.ASCII+". With an ASCII localpart, the address can be used without SMTPUTF8 by using the ASCII form of the domain.", "")
+	}
+	if !strings.Contains(addr.Domain.ASCII, ".") {
+		add("address.domain-dots", "warning", "Domain without dot", "The domain is a single label, e.g. a top-level domain or a local hostname. Such domains practically never accept email from the internet.", "Check the domain for typos.")
+	}
+
+	opctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var hosts []dns.IPDomain
+	var permanent bool
+	r.HaveMX, _, _, r.ExpandedDomain, hosts, permanent, err = smtpclient.GatherDestinations(opctx, log.Logger, resolver, dns.IPDomain{Domain: addr.Domain})
+	r.DestinationsError = errmsg(err)
+	if hosts != nil {
+		r.Hosts = hosts
+	}
+	r.Deliverable = len(hosts) > 0
+	if r.Deliverable && !r.HaveMX {
+		// Implicit MX only works if the domain has an IP address.
+		if _, _, _, _, _, err := smtpclient.GatherIPs(opctx, log.Logger, resolver, "ip", hosts[0], map[string][]net.IP{}); err != nil {
+			r.Deliverable = false
+			r.DestinationsError = fmt.Sprintf("no mx record, and looking up ips for implicit mx: %v", err)
+		}
+	}
+	// The null MX error of smtpclient is not exported.
+	r.NullMX = r.HaveMX && permanent && err != nil && strings.Contains(err.Error(), "does not accept email")
+	if r.NullMX {
+		add("address.nullmx", "error", "Domain does not accept email", "The domain has a null MX record (RFC 7505), explicitly indicating it does not accept email.", "Use an address at another domain.")
+	} else if !r.Deliverable {
+		add("address.destinations", "error", "No destinations", "The mail servers for the domain could not be looked up: "+r.DestinationsError, "Check the domain for typos. For temporary DNS errors, try again later.")
+	} else if !r.HaveMX {
+		add("address.implicitmx", "warning", "No MX record", "The domain has no MX record. Email is delivered directly to the domain (implicit MX), but only if it has an IP address and runs a mail server. Domains that accept email normally have MX records.", "Check the domain for typos.")
+	}
+
+	if !probe || !r.Deliverable {
+		return
+	}
+
+	p := &AddressProbe{Result: "unknown", Trace: []Proto{}}
+	r.Probe = p
+	// Like a queue, try the hosts in order until one of them replies to RCPT TO,
+	// continuing with the next host on connection failures.
+	for _, h := range hosts {
+		*p = AddressProbe{Host: h, Result: "unknown", Trace: []Proto{}}
+		smtpErr, err := addressProbeHost(opctx, log, p, h, addr, r.SMTPUTF8)
+		if err != nil {
+			p.Error = err.Error()
+		}
+		if err == nil || smtpErr {
+			break
+		}
+	}
+	if p.Response != "" {
+		if sr, err := smtpReplyExplain(p.Response); err == nil {
+			p.Explanation = sr.Detail
+			if p.Explanation == "" {
+				p.Explanation = sr.CodeExplanation
+			}
+		}
+	}
+	return
+}
+
+// addressProbeHost connects to host, and sends MAIL FROM and RCPT TO for the
+// address and a random address at the same domain. The transaction is reset
+// before DATA, no message is delivered.
+func addressProbeHost(ctx context.Context, log mlog.Log, p *AddressProbe, host dns.IPDomain, addr smtp.Address, smtputf8 bool) (smtpErr bool, rerr error) {
+	dialedIPs := map[string][]net.IP{}
+	_, _, _, ips, _, err := smtpclient.GatherIPs(ctx, log.Logger, resolver, "ip", host, dialedIPs)
+	if err != nil {
+		return false, fmt.Errorf("looking up ips for %s: %v", host, err)
+	}
+
+	dialer := &limitDialer{Control: publicDialControl}
+	conn, ip, err := smtpclient.Dial(ctx, log.Logger, dialer, host, ips, 25, dialedIPs, nil)
+	p.IP = ip
+	if err != nil {
+		return false, fmt.Errorf("dial %s: %v", host, err)
+	}
+	defer conn.Close()
+
+	th := traceHandler{Trace: []Proto{}}
+	tracelog := slog.New(&th)
+	defer func() {
+		p.Trace = append(th.Trace, p.Trace...)
+	}()
+	opts := smtpclient.Opts{IgnoreTLSVerifyErrors: true} // note: not generally safe
+	client, err := smtpclient.New(ctx, tracelog, conn, smtpclient.TLSOpportunistic, false, dnsHostname, host.Domain, opts)
+	if err != nil {
+		p.Response = testDeliveryResponse(err, th.Trace)
+		return p.Response != "", fmt.Errorf("smtp connection with %s: %v", host, err)
+	}
+	if smtputf8 && !client.SupportsSMTPUTF8() {
+		p.Result = "reject"
+		client.Close()
+		return true, errors.New("server does not support smtputf8, required for the non-ascii localpart")
+	}
+
+	// The client has no function for only a RCPT TO, so we continue on the
+	// connection ourselves.
+	sconn, err := client.Conn()
+	if err != nil {
+		return false, fmt.Errorf("smtp connection with %s: %v", host, err)
+	}
+	defer sconn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		sconn.SetDeadline(deadline)
+	}
+	br := bufio.NewReader(sconn)
+	command := func(line string) (int, string, error) {
+		p.Trace = append(p.Trace, Proto{true, line + "\r\n"})
+		if _, err := fmt.Fprintf(sconn, "%s\r\n", line); err != nil {
+			return 0, "", fmt.Errorf("write: %v", err)
+		}
+		code, resp, err := addressProbeReply(br)
+		if resp != "" {
+			p.Trace = append(p.Trace, Proto{false, strings.ReplaceAll(resp, "\n", "\r\n") + "\r\n"})
+		}
+		return code, resp, err
+	}
+
+	var mailFromArg string
+	if smtputf8 {
+		mailFromArg = " SMTPUTF8"
+	}
+	code, resp, err := command("MAIL FROM:<" + testDeliveryFromAddr.Pack(smtputf8) + ">" + mailFromArg)
+	if err != nil {
+		return false, fmt.Errorf("mail from: %v", err)
+	} else if code/100 != 2 {
+		p.Response = resp
+		return true, fmt.Errorf("mail from rejected: %s", resp)
+	}
+
+	code, p.Response, err = command("RCPT TO:<" + addr.Pack(smtputf8) + ">")
+	if err != nil {
+		return false, fmt.Errorf("rcpt to: %v", err)
+	}
+	switch code / 100 {
+	case 2:
+		p.Result = "accept"
+	case 5:
+		p.Result = "reject"
+	}
+
+	if p.Result == "accept" {
+		buf := make([]byte, 8)
+		rand.Read(buf)
+		p.RandomAddress = smtp.NewAddress(smtp.Localpart(fmt.Sprintf("moxtools-probe-%x", buf)), addr.Domain).Pack(smtputf8)
+		code, p.RandomResponse, err = command("RCPT TO:<" + p.RandomAddress + ">")
+		if err != nil {
+			return true, fmt.Errorf("rcpt to for random address: %v", err)
+		} else if code/100 == 2 {
+			p.Result = "catchall"
+		}
+	}
+
+	command("RSET")
+	command("QUIT")
+	return true, nil
+}
+
+// addressProbeReply reads a possibly multi-line SMTP reply, returning the lines
+// joined with newlines.
+func addressProbeReply(br *bufio.Reader) (int, string, error) {
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return 0, strings.Join(lines, "\n"), fmt.Errorf("read: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		if len(line) < 3 {
+			return 0, strings.Join(lines, "\n"), fmt.Errorf("malformed reply %q", line)
+		}
+		if len(line) == 3 || line[3] != '-' {
+			code, err := strconv.Atoi(line[:3])
+			if err != nil {
+				return 0, strings.Join(lines, "\n"), fmt.Errorf("malformed reply code in %q", line)
+			}
+			return code, strings.Join(lines, "\n"), nil
+		}
+	}
+}
+
+// addressSyntaxExplain looks for common mistakes in an address that failed to
+// parse, and returns an explanation and remediation.
+func addressSyntaxExplain(s string) (explanation, remediation string) {
+	if s == "" {
+		return "The address is empty.", "Enter an address, e.g. user@example.org."
+	} else if strings.TrimSpace(s) != s {
+		return "The address has leading or trailing whitespace.", "Remove the whitespace."
+	} else if strings.ContainsAny(s, "<>") {
+		return "The address has angle brackets, possibly with a display name, as in a message header. Only the address itself is expected.", "Use only the part between the angle brackets, e.g. user@example.org."
+	}
+	i := strings.LastIndex(s, "@")
+	if i < 0 {
+		return "The address has no @, it needs a localpart and a domain.", "Add the @ and domain, e.g. user@example.org."
+	}
+	lp, dom := s[:i], s[i+1:]
+	quoted := strings.HasPrefix(lp, `"`) && strings.HasSuffix(lp, `"`) && len(lp) >= 2
+	switch {
+	case lp == "":
+		return "The localpart, before the @, is empty.", "Add the localpart, e.g. user@example.org."
+	case dom == "":
+		return "The domain, after the @, is empty.", "Add the domain, e.g. user@example.org."
+	case strings.HasPrefix(lp, `"`) && !quoted:
+		return "The localpart starts with a double quote but is not a properly quoted string.", "Close the quoted string right before the @, or remove the quote."
+	case !quoted && strings.Contains(lp, "@"):
+		return "The address has multiple @'s. An @ in the localpart is only allowed in a quoted localpart.", "Check for a typo, or quote the localpart, e.g. \"a@b\"@example.org."
+	case !quoted && (strings.HasPrefix(lp, ".") || strings.HasSuffix(lp, ".") || strings.Contains(lp, "..")):
+		return "The localpart has a dot at the start or end, or consecutive dots. This is only allowed in a quoted localpart.", "Check for a typo, or quote the localpart, e.g. \"john..doe\"@example.org."
+	case !quoted && strings.ContainsAny(lp, " ()<>[]:;,\\\""):
+		return "The localpart has spaces or special characters that are only allowed in a quoted localpart.", "Check for a typo, or quote the localpart, e.g. \"john doe\"@example.org."
+	case strings.HasPrefix(dom, "["):
+		return "The domain is an IP address literal. Addresses with IP addresses are rarely accepted and not supported here.", "Use a domain name."
+	case strings.HasPrefix(dom, ".") || strings.HasSuffix(dom, ".") || strings.Contains(dom, ".."):
+		return "The domain has a dot at the start or end, or consecutive dots.", "Remove the superfluous dots."
+	case strings.Contains(dom, "_"):
+		return "The domain has an underscore, which is not allowed in hostnames.", "Check the domain for typos."
+	case strings.ContainsAny(dom, " ,;:()\"\\"):
+		return "The domain has spaces or characters that are not allowed in domain names.", "Check the domain for typos."
+	}
+	for _, label := range strings.Split(dom, ".") {
+		if len(label) > 63 {
+			return "A label of the domain is longer than 63 bytes.", "Check the domain for typos."
+		}
+	}
+	return "The address could not be parsed. Non-ASCII domains must be valid internationalized domain names (IDNA), non-ASCII localparts must be valid UTF-8.", "Check the address for typos."
+}
+
+func cmdAddresscheck(c *cmd) {
+	var probe bool
+	c.flag.BoolVar(&probe, "probe", false, "connect to the mx hosts and probe the address with rcpt to, without delivering a message")
+	args := c.Parse()
+	if len(args) != 1 {
+		c.Usage()
+	}
+
+	r := addressCheck(context.Background(), pkglog, args[0], probe)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err := enc.Encode(r)
+	xcmdcheck(err, "write result")
+	if !r.Valid || !r.Deliverable {
+		os.Exit(1)
+	}
+}
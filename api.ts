@@ -2,9 +2,25 @@
 
 namespace api {
 
-export interface SPFReceived {
-	Status: string
-	Mechanism: string
+// AddressCheckResult is the result of checking the syntax and deliverability of
+// an email address.
+export interface AddressCheckResult {
+	DurationMS: number
+	Address: string  // As given.
+	Valid: boolean  // Whether the syntax is valid.
+	SyntaxError: string
+	Localpart: string  // Decoded, without quotes and escapes.
+	Domain: Domain  // With ASCII and Unicode (IDNA) form.
+	Normalized: string  // For use in SMTP, with quoted localpart if needed, and ASCII domain unless the localpart is non-ASCII.
+	SMTPUTF8: boolean  // Whether the address can only be used with mail servers that support SMTPUTF8.
+	Findings?: Finding[] | null
+	HaveMX: boolean
+	NullMX: boolean  // The domain explicitly does not accept email (RFC 7505).
+	ExpandedDomain: Domain  // After following CNAMEs.
+	Hosts?: IPDomain[] | null
+	DestinationsError: string
+	Deliverable: boolean  // Whether the domain has hosts to deliver to.
+	Probe?: AddressProbe | null
 }
 
 // Domain is a domain name, with one or more labels, with at least an ASCII
@@ -16,70 +32,75 @@ export interface Domain {
 	Unicode: string  // Name as U-labels, in Unicode NFC. Empty if this is an ASCII-only domain. No trailing dot.
 }
 
-// Record is a DKIM DNS record, served on <selector>._domainkey.<domain> for a
-// given selector and domain (s= and d= in the DKIM-Signature).
-// 
-// The record is a semicolon-separated list of "="-separated field value pairs.
-// Strings should be compared case-insensitively, e.g. k=ed25519 is equivalent to k=ED25519.
-// 
-// Example:
-// 
-// 	v=DKIM1;h=sha256;k=ed25519;p=ln5zd/JEX4Jy60WAhUOv33IYm2YZMyTQAdr9stML504=
-export interface Record {
-	Version: string  // Version, fixed "DKIM1" (case sensitive). Field "v".
-	Hashes?: string[] | null  // Acceptable hash algorithms, e.g. "sha1", "sha256". Optional, defaults to all algorithms. Field "h".
-	Key: string  // Key type, "rsa" or "ed25519". Optional, default "rsa". Field "k".
-	Notes: string  // Debug notes. Field "n".
-	Pubkey?: string | null  // Public key, as base64 in record. If empty, the key has been revoked. Field "p".
-	Services?: string[] | null  // Service types. Optional, default "*" for all services. Other values: "email". Field "s".
-	Flags?: string[] | null  // Flags, colon-separated. Optional, default is no flags. Other values: "y" for testing DKIM, "s" for "i=" must have same domain as "d" in signatures. Field "t".
+export interface Finding {
+	Check: string  // ID of the check, e.g. "spf.all" or "mx[0].starttls".
+	Severity: string  // "error", "warning" or "info".
+	Title: string
+	Explanation: string
+	Remediation: string
+	Points: number  // Deducted from the score.
 }
 
-export interface DKIMResult {
-	Status: DKIMStatus
-	Sig?: Sig | null  // Parsed form of DKIM-Signature header. Can be nil for invalid DKIM-Signature header.
-	Record?: Record | null  // Parsed form of DKIM DNS record for selector and domain in Sig. Optional.
-	RecordAuthentic: boolean  // Whether DKIM DNS record was DNSSEC-protected. Only valid if Sig is non-nil.
-	Error: string  // If Status is not StatusPass, this error holds the details and can be checked using errors.Is.
+// IPDomain is an ip address, a domain, or empty.
+export interface IPDomain {
+	IP: IP
+	Domain: Domain
 }
 
-// Sig is a DKIM-Signature header.
-// 
-// String values must be compared case insensitively.
-export interface Sig {
-	Version: number  // Required fields.; Version, 1. Field "v". Always the first field.
-	AlgorithmSign: string  // "rsa" or "ed25519". Field "a".
-	AlgorithmHash: string  // "sha256" or the deprecated "sha1" (deprecated). Field "a".
-	Signature?: string | null  // Field "b".
-	BodyHash?: string | null  // Field "bh".
-	Domain: Domain  // Field "d".
-	SignedHeaders?: string[] | null  // Duplicates are meaningful. Field "h".
-	Selector: Domain  // Selector, for looking DNS TXT record at <s>._domainkey.<domain>. Field "s".
-	Canonicalization: string  // Optional fields. Canonicalization is the transformation of header and/or body before hashing. The value is in original case, but must be compared case-insensitively. Normally two slash-separated values: header canonicalization and body canonicalization. But the "simple" means "simple/simple" and "relaxed" means "relaxed/simple". Field "c".
-	Length: number  // Body length to verify, default -1 for whole body. Field "l".
-	Identity?: Identity | null  // AUID (agent/user id). If nil and an identity is needed, should be treated as an Identity without localpart and Domain from d= field. Field "i".
-	QueryMethods?: string[] | null  // For public key, currently known value is "dns/txt" (should be compared case-insensitively). If empty, dns/txt must be assumed. Field "q".
-	SignTime: number  // Unix epoch. -1 if unset. Field "t".
-	ExpireTime: number  // Unix epoch. -1 if unset. Field "x".
-	CopiedHeaders?: string[] | null  // Copied header fields. Field "z".
+// AddressProbe is the result of a RCPT TO for the address at a destination host,
+// without delivering a message.
+export interface AddressProbe {
+	Host: IPDomain  // Last one attempted.
+	IP: IP
+	Result: string  // "accept", "reject", "catchall" or "unknown", e.g. for a temporary error or greylisting.
+	Response: string  // To the RCPT TO of the address.
+	Explanation: string  // Of the response.
+	RandomAddress: string  // Non-existent address at the domain, to detect catch-all.
+	RandomResponse: string
+	Error: string
+	Trace?: Proto[] | null
 }
 
-// Identity is used for the optional i= field in a DKIM-Signature header. It uses
-// the syntax of an email address, but does not necessarily represent one.
-export interface Identity {
-	Localpart?: Localpart | null  // Optional.
-	Domain: Domain
+export interface Proto {
+	ClientWrite: boolean
+	Text: string
+}
+
+export interface DomainBatchResult {
+	DurationMS: number
+	Summaries?: DomainSummary[] | null
+	Results?: (DomainResult | null)[] | null  // Full results for drill-down, nil if the domain was not checked.
+}
+
+// DomainSummary has the key statuses of a domain check, for a table with one row
+// per domain.
+export interface DomainSummary {
+	Domain: string
+	Error: string  // E.g. invalid domain. Other fields are empty.
+	Grade: string
+	Score: number
+	SPF: string  // "none" if no record, "softfail" for ~all, "permissive" for +all, etc.
+	DMARC: string  // Policy, or "none" if no record.
+	MTASTS: string  // Mode, or "none" if no record.
+	TLSRPT: boolean
+	DNSSEC: boolean  // For the MX records.
+	DANE: boolean  // All MX hosts have DANE.
+	STARTTLS: boolean  // All dialed MX hosts support STARTTLS, false if none could be dialed.
+	MXHosts: number
+	Findings: number  // Number of errors and warnings.
 }
 
 export interface DomainResult {
 	DurationMS: number
 	Domain: Domain
 	SPF: DomainSPF
+	DKIM: DKIMDiscoverResult  // Records for common selectors.
 	DMARC: DomainDMARC
 	TLSRPT: DomainTLSRPT
 	MTASTS: DomainMTASTS
 	MX: DomainMX
 	MXHosts?: DomainMXHost[] | null
+	Grade: DomainGrade
 }
 
 export interface DomainSPF {
@@ -89,6 +110,9 @@ export interface DomainSPF {
 	Record?: SPFRecord | null
 	Authentic: boolean
 	Error: string
+	DNSBL?: DNSBLIP[] | null  // For IPs in ip4 and ip6 mechanisms.
+	Lookups: number  // DNS lookups needed for evaluation, following include and redirect. At most 10 are allowed.
+	LookupsErr: string  // E.g. for an include of a domain without SPF record.
 }
 
 export interface SPFRecord {
@@ -118,6 +142,60 @@ export interface Modifier {
 	Value: string
 }
 
+export interface DNSBLIP {
+	IP: IP
+	Results?: DNSBLResult[] | null
+}
+
+export interface DNSBLResult {
+	Zone: Domain
+	Status: string  // "pass" for not listed, "fail" for listed, or "temperror".
+	Codes?: string[] | null  // IPs from A records for listed IP, e.g. 127.0.0.2, typically indicating the reason for the listing.
+	Reason: string  // From TXT records for listed IP.
+	Error: string
+}
+
+export interface DKIMDiscoverResult {
+	DurationMS: number
+	Probed: number  // Number of selectors tried.
+	Wildcard: boolean  // A random selector has a record too, found records may come from a wildcard.
+	Selectors?: DKIMDiscovered[] | null  // Only selectors with a record.
+}
+
+// DKIMDiscovered is a DKIM record found for a selector.
+export interface DKIMDiscovered {
+	Selector: string
+	Status: string  // Of the lookup, "neutral" if a record was found.
+	TXT: string
+	Record?: Record | null
+	KeyType: string  // "rsa" or "ed25519".
+	KeyBits: number  // Size of public key, 0 if revoked.
+	Flags?: string[] | null  // From t=, "y" for testing mode, "s" for strict identity.
+	Revoked: boolean  // Empty p=.
+	Authentic: boolean
+	Audit?: Finding[] | null  // Of key strength and fields.
+	Error: string  // E.g. syntax error in record.
+}
+
+// Record is a DKIM DNS record, served on <selector>._domainkey.<domain> for a
+// given selector and domain (s= and d= in the DKIM-Signature).
+// 
+// The record is a semicolon-separated list of "="-separated field value pairs.
+// Strings should be compared case-insensitively, e.g. k=ed25519 is equivalent to k=ED25519.
+// 
+// Example:
+// 
+// 	v=DKIM1;h=sha256;k=ed25519;p=ln5zd/JEX4Jy60WAhUOv33IYm2YZMyTQAdr9stML504=
+export interface Record {
+	Version: string  // Version, fixed "DKIM1" (case sensitive). Field "v".
+	Hashes?: string[] | null  // Acceptable hash algorithms, e.g. "sha1", "sha256". Optional, defaults to all algorithms. Field "h".
+	Key: string  // Key type, "rsa" or "ed25519". Optional, default "rsa". Field "k".
+	Notes: string  // Debug notes. Field "n".
+	Pubkey?: string | null  // Public key, as base64 in record. If empty, the key has been revoked. Field "p".
+	Services?: string[] | null  // Service types. Optional, default "*" for all services. Other values: "email". Field "s".
+	Flags?: string[] | null  // Flags, colon-separated. Optional, default is no flags. Other values: "y" for testing DKIM, "s" for "i=" must have same domain as "d" in signatures. Field "t".
+}
+
 export interface DomainDMARC {
 	DurationMS: number
 	Status: string
@@ -219,15 +297,13 @@ export interface DomainMXHost {
 	Host: IPDomain
 	MTASTSError: string
 	IP: DomainIP
+	DNSBL?: DNSBLIP[] | null
+	IPRev?: IPRevResult[] | null  // EHLO is compared against the hostname from the SMTP trace.
 	DANE: DomainDANE
 	Dial: DomainDial
 	SMTP: DomainSMTP
-}
-
-// IPDomain is an ip address, a domain, or empty.
-export interface IPDomain {
-	IP: IP
-	Domain: Domain
+	IPResults?: DomainMXIP[] | null  // When checking all IPs, instead of Dial and SMTP.
+	Parity?: DomainParity | null  // For MX hosts with both IPv4 and IPv6 addresses.
 }
 
 export interface DomainIP {
@@ -240,6 +316,18 @@ export interface DomainIP {
 	Error: string
 }
 
+export interface IPRevResult {
+	DurationMS: number
+	IP: IP
+	Status: string  // "pass", "fail" (PTR names don't resolve back to IP), "temperror" or "permerror" (e.g. no PTR record).
+	Name: string  // First name from PTR records that resolves back to the IP.
+	Names?: string[] | null  // All names from PTR records.
+	Authentic: boolean
+	EHLO: string  // Hostname from EHLO, if known.
+	EHLOMatch: boolean  // Whether EHLO is the same as the forward-confirmed name.
+	Error: string
+}
+
 export interface DomainDANE {
 	DurationMS: number
 	Required: boolean
@@ -247,6 +335,7 @@ export interface DomainDANE {
 	TLSABaseDomain: Domain
 	Error: string
 	VerifiedRecord: TLSARecord
+	WithoutDNSSEC: boolean  // TLSA records exist, but the MX records or MX host IPs aren't DNSSEC-protected, so senders ignore them.
 }
 
 export interface TLSARecord {
@@ -272,6 +361,10 @@ export interface DomainSMTP {
 	TLSConnectionState?: TLSConnectionState | null
 	RecipientDomainResult?: TLSRPTResult | null
 	HostResult?: TLSRPTResult | null
+	GreetingHostname: string  // From 220 greeting.
+	EHLO?: SMTPEHLO | null  // Before STARTTLS.
+	EHLOTLS?: SMTPEHLO | null  // After STARTTLS.
+	Warnings?: string[] | null  // About extensions.
 	Trace?: Proto[] | null
 }
 
@@ -280,6 +373,7 @@ export interface TLSConnectionState {
 	CipherSuite: string
 	NegotiatedProtocol: string
 	ServerName: string
+	CertFingerprint: string  // Hex SHA-256 of the leaf certificate.
 }
 
 export interface TLSRPTResult {
@@ -311,9 +405,446 @@ export interface TLSRPTFailureDetails {
 	FailureReasonCode: string
 }
 
-export interface Proto {
-	ClientWrite: boolean
+// SMTPEHLO is a parsed EHLO response.
+export interface SMTPEHLO {
+	Hostname: string  // From first line of the response.
+	Extensions?: SMTPExtension[] | null
+	Size: number  // Maximum message size from SIZE, 0 if absent or without limit.
+	Pipelining: boolean
+	Chunking: boolean
+	DSN: boolean
+	EnhancedStatusCodes: boolean
+	StartTLS: boolean
+	AuthMechanisms?: string[] | null  // Upper case.
+	LimitRcptMax: number  // From LIMITS, RFC 9422, 0 if absent.
+	LimitMailMax: number
+	LimitRcptDomainMax: number
+}
+
+export interface SMTPExtension {
+	Keyword: string  // Upper case.
+	Params: string
+	Explanation: string  // Empty for unknown extensions.
+}
+
+// DomainMXIP is the result of connecting to a single IP of an MX host.
+export interface DomainMXIP {
+	IP: IP
+	Dial: DomainDial
+	SMTP: DomainSMTP
+	DANEVerifiedRecord: TLSARecord
+}
+
+// DomainParity compares connections to an MX host over IPv4 and IPv6. Servers
+// for the two address families are often configured separately, e.g. behind
+// different load balancers, and get out of sync.
+export interface DomainParity {
+	IPv4: DomainMXIP
+	IPv6: DomainMXIP
+	Differences?: string[] | null
+}
+
+// DomainGrade is a summary of the domain check, with a score and the findings
+// that lowered it.
+export interface DomainGrade {
+	Score: number  // 0-100.
+	Grade: string  // "A" (90 and higher) to "F" (below 60).
+	Findings?: Finding[] | null
+}
+
+export interface ClientConfigResult {
+	DurationMS: number
+	Domain: Domain
+	SRV?: ClientConfigSRV[] | null
+	Autoconfig: ClientConfigAutoconfig
+	Autodiscover: ClientConfigAutodiscover
+	Endpoints?: ClientConfigEndpoint[] | null
+	Mismatches?: string[] | null
+}
+
+export interface ClientConfigSRV {
+	DurationMS: number
+	Service: string  // E.g. "_submissions._tcp".
+	Protocol: string  // "imap", "pop3" or "submission".
+	Security: string  // "tls" for immediate TLS, "starttls" otherwise.
+	Records?: SRVRecord[] | null
+	Authentic: boolean
+	Error: string
+}
+
+export interface SRVRecord {
+	Target: string
+	Port: number
+	Priority: number
+	Weight: number
+}
+
+export interface ClientConfigAutoconfig {
+	DurationMS: number
+	URL: string
+	Servers?: ClientConfigServer[] | null
+	XML: string
+	Error: string
+}
+
+export interface ClientConfigServer {
+	Protocol: string  // "imap", "pop3" or "submission".
+	Host: string
+	Port: number
+	Security: string  // "tls", "starttls" or "plain".
+	Username: string
+	Authentication: string
+}
+
+export interface ClientConfigAutodiscover {
+	DurationMS: number
+	URL: string
+	Servers?: ClientConfigServer[] | null
+	XML: string
+	Error: string
+}
+
+export interface ClientConfigEndpoint {
+	DurationMS: number
+	Protocol: string
+	Host: string
+	Port: number
+	Security: string
+	Sources?: string[] | null  // "srv", "autoconfig" and/or "autodiscover".
+	IP: IP
+	Greeting: string
+	TLSConnectionState?: TLSConnectionState | null
+	Error: string
+}
+
+export interface TestDeliveryResult {
+	DurationMS: number
+	MailFrom: string
+	RcptTo: string
+	MessageID: string
+	DKIMSigned: boolean
+	Host: IPDomain  // MX target the message was delivered to, or the last one attempted.
+	IP: IP
+	TLSConnectionState?: TLSConnectionState | null
+	Supports8bitMIME: boolean  // Extensions announced by the server.
+	SupportsRequireTLS: boolean
+	SupportsSMTPUTF8: boolean
+	Need8bitMIME: boolean  // Extensions required for this delivery, with delivery failing if the server doesn't support them.
+	NeedSMTPUTF8: boolean
+	NeedRequireTLS: boolean
+	Response: string  // Last line of the SMTP response to the message data, or of the failed command.
+	QueueID: string  // Heuristically parsed from Response.
+	Success: boolean
+	Error: string
+	Trace?: Proto[] | null  // Of the last delivery attempt, message data replaced with "...".
+}
+
+// DKIMBreakage is the analysis of a DKIM signature, for explaining failures.
+export interface DKIMBreakage {
+	Result: DKIMResult
+	HeadersOK: boolean  // Signature over the headers verifies.
+	BodyOK: boolean  // Body hash matches.
+	Hypotheses?: DKIMHypothesis[] | null
+	Error: string
+}
+
+export interface DKIMResult {
+	Status: DKIMStatus
+	Sig?: Sig | null  // Parsed form of DKIM-Signature header. Can be nil for invalid DKIM-Signature header.
+	Record?: Record | null  // Parsed form of DKIM DNS record for selector and domain in Sig. Optional.
+	RecordAuthentic: boolean  // Whether DKIM DNS record was DNSSEC-protected. Only valid if Sig is non-nil.
+	Error: string  // If Status is not StatusPass, this error holds the details and can be checked using errors.Is.
+}
+
+// Sig is a DKIM-Signature header.
+// 
+// String values must be compared case insensitively.
+export interface Sig {
+	Version: number  // Required fields.; Version, 1. Field "v". Always the first field.
+	AlgorithmSign: string  // "rsa" or "ed25519". Field "a".
+	AlgorithmHash: string  // "sha256" or the deprecated "sha1" (deprecated). Field "a".
+	Signature?: string | null  // Field "b".
+	BodyHash?: string | null  // Field "bh".
+	Domain: Domain  // Field "d".
+	SignedHeaders?: string[] | null  // Duplicates are meaningful. Field "h".
+	Selector: Domain  // Selector, for looking DNS TXT record at <s>._domainkey.<domain>. Field "s".
+	Canonicalization: string  // Optional fields. Canonicalization is the transformation of header and/or body before hashing. The value is in original case, but must be compared case-insensitively. Normally two slash-separated values: header canonicalization and body canonicalization. But the "simple" means "simple/simple" and "relaxed" means "relaxed/simple". Field "c".
+	Length: number  // Body length to verify, default -1 for whole body. Field "l".
+	Identity?: Identity | null  // AUID (agent/user id). If nil and an identity is needed, should be treated as an Identity without localpart and Domain from d= field. Field "i".
+	QueryMethods?: string[] | null  // For public key, currently known value is "dns/txt" (should be compared case-insensitively). If empty, dns/txt must be assumed. Field "q".
+	SignTime: number  // Unix epoch. -1 if unset. Field "t".
+	ExpireTime: number  // Unix epoch. -1 if unset. Field "x".
+	CopiedHeaders?: string[] | null  // Copied header fields. Field "z".
+}
+
+// Identity is used for the optional i= field in a DKIM-Signature header. It uses
+// the syntax of an email address, but does not necessarily represent one.
+export interface Identity {
+	Localpart?: Localpart | null  // Optional.
+	Domain: Domain
+}
+
+// DKIMHypothesis is a possible explanation of a failing DKIM signature.
+export interface DKIMHypothesis {
 	Text: string
+	Confirmed: boolean  // Reversing the change makes the signature, or the failing half of it, verify.
+}
+
+// DKIMDebug has the intermediate steps of verifying a DKIM signature.
+export interface DKIMDebug {
+	Result: DKIMResult
+	HeaderCanon: string  // "simple" or "relaxed".
+	BodyCanon: string  // "simple" or "relaxed".
+	Headers?: DKIMDebugHeader[] | null  // In hashed order, ending with the DKIM-Signature header without b= value.
+	Body: string  // Canonicalized body, truncated to l= if present.
+	BodyLength: number  // Of the canonicalized body, before truncation.
+	Length: number  // From l=, -1 if absent.
+	BodyHash: string  // As claimed in bh=, base64.
+	BodyHashComputed: string  // Over Body.
+	BodyHashOther: string  // Computed with the other body canonicalization, to detect a signer using the wrong canonicalization.
+	BodyLines?: DKIMDebugLine[] | null  // Lines that differ with the other body canonicalization, at most 100.
+	Error: string  // If debugging could not be done, e.g. for an unknown hash algorithm.
+}
+
+// DKIMDebugHeader is a header as included in the data hash of a signature.
+export interface DKIMDebugHeader {
+	Name: string  // As listed in h= of the signature.
+	Missing: boolean  // Not present (anymore), e.g. oversigned to prevent adding the header. Nothing is hashed.
+	Raw: string
+	Canonical: string  // As hashed, with the header canonicalization of the signature.
+	Other: string  // With the other header canonicalization, for comparison.
+}
+
+// DKIMDebugLine is a line of the body that differs between canonicalizations.
+export interface DKIMDebugLine {
+	Line: number  // Starting at 1.
+	Canonical: string
+	Other: string
+}
+
+// DSN is a parsed delivery status notification, or bounce message.
+export interface DSN {
+	Format: string  // "rfc3464" for a multipart/report with delivery-status part, "heuristic" if recipients were parsed from the text.
+	ReportingMTA: string
+	ArrivalDate: string
+	Recipients?: DSNRecipient[] | null
+	Text: string  // Human-readable text of the bounce, truncated.
+	OriginalHeaders?: MIMEHeader[] | null  // Of the bounced message, if included.
+}
+
+// DSNRecipient is the delivery status of a recipient in a bounce message.
+export interface DSNRecipient {
+	FinalRecipient: string
+	OriginalRecipient: string
+	Action: string  // "failed", "delayed", "delivered", "relayed" or "expanded".
+	Status: string  // Enhanced status code, e.g. "5.1.1".
+	StatusExplanation: string
+	DiagnosticCode: string  // Typically the SMTP reply of the remote server.
+	RemoteMTA: string
+	LastAttemptDate: string
+	WillRetryUntil: string
+}
+
+// MIMEHeader is a header of a message or part.
+export interface MIMEHeader {
+	Name: string
+	Value: string  // Unfolded.
+	Decoded: string  // With RFC 2047 encoded-words decoded, empty if the same as Value.
+}
+
+// Expectations is the expected state of a domain, e.g. for compliance checks.
+// Empty fields are not checked. In files, it is stored as JSON or YAML, with the
+// same field names.
+export interface Expectations {
+	Domain: string
+	DMARCPolicy: string  // E.g. "reject".
+	SPFAll: string  // The all mechanism in the SPF record, e.g. "-all" or "~all".
+	MX?: string[] | null  // Exactly these MX hosts, in any order.
+	DANERequired: boolean  // All MX hosts must have TLSA records.
+	MTASTSMode: string  // E.g. "enforce".
+	MTASTSMinMaxAge: number  // Minimum max_age of the MTA-STS policy in seconds, e.g. 604800 for a week.
+	TLSRPT: boolean  // A TLSRPT record must be present.
+	MinScore: number  // Minimum score of the domain grade.
+	DKIM?: ExpectedDKIM[] | null
+}
+
+export interface ExpectedDKIM {
+	Selector: string
+	KeyType: string  // "rsa" or "ed25519". Optional.
+}
+
+export interface ExpectationsResult {
+	DurationMS: number
+	Domain: Domain
+	Checks?: ExpectationCheck[] | null
+	Violations: number
+	Result: DomainResult
+}
+
+// ExpectationCheck is the evaluation of a single expectation.
+export interface ExpectationCheck {
+	Name: string
+	Expected: string
+	Actual: string
+	OK: boolean
+}
+
+export interface SPFReceived {
+	Status: string
+	Mechanism: string
+}
+
+// MIMEInspection is the MIME structure of a message, with problems found.
+export interface MIMEInspection {
+	Size: number
+	Part?: MIMEPart | null  // Nil if the message header could not be parsed.
+	Findings?: Finding[] | null
+}
+
+// MIMEPart is a part in the MIME tree of a message.
+export interface MIMEPart {
+	Path: string  // Part number as in IMAP, e.g. "1.2", empty for the message.
+	ContentType: string  // Lower case, e.g. "text/plain". Empty if absent, which means text/plain.
+	Params?: { [key: string]: string }
+	Charset: string
+	ContentTransferEncoding: string  // Lower case, empty if absent, which means 7bit.
+	Disposition: string  // E.g. "inline" or "attachment".
+	Filename: string  // From Content-Disposition, or the "name" Content-Type parameter.
+	ContentID: string
+	Headers?: MIMEHeader[] | null
+	Envelope?: Envelope | null  // For the message and embedded messages.
+	Size: number  // Of the raw body, -1 if the part could not be parsed completely.
+	DecodedSize: number
+	Lines: number
+	Parts?: MIMEPart[] | null
+	Message?: MIMEPart | null  // For message/rfc822 and message/global parts.
+}
+
+// Envelope holds the basic/common message headers as used in IMAP4.
+export interface Envelope {
+	Date: Date
+	Subject: string  // Q/B-word-decoded.
+	From?: Address[] | null
+	Sender?: Address[] | null
+	ReplyTo?: Address[] | null
+	To?: Address[] | null
+	CC?: Address[] | null
+	BCC?: Address[] | null
+	InReplyTo: string  // From In-Reply-To header, includes <>.
+	MessageID: string  // From Message-Id header, includes <>.
+}
+
+// Address as used in From and To headers.
+export interface Address {
+	Name: string  // Free-form name for display in mail applications.
+	User: string  // Localpart, encoded as string. Must be parsed before using as Localpart.
+	Host: string  // Domain in ASCII.
+}
+
+export interface ReflectorResult {
+	Address: string
+	Expires: Date
+	Messages?: ReflectorMessage[] | null
+}
+
+export interface ReflectorMessage {
+	Received: Date
+	DurationMS: number  // Of analysis.
+	RemoteIP: IP
+	Hello: string  // As sent with EHLO or HELO.
+	EHLO: boolean
+	TLSConnectionState?: TLSConnectionState | null
+	MailFrom: string  // Empty for null reverse path.
+	Size: number
+	IPRev: IPRevResult  // Compared against Hello.
+	From: string  // Address in message From header.
+	Subject: string
+	SPF: ReflectorSPF
+	DKIM?: DKIMResult[] | null
+	DMARC: ReflectorDMARC
+	Error: string
+}
+
+export interface ReflectorSPF {
+	Status: string
+	Mechanism: string
+	Identity: string  // "mailfrom" or "helo".
+	Domain: Domain
+	Explanation: string
+	Authentic: boolean
+	Error: string
+}
+
+export interface ReflectorDMARC {
+	Status: string
+	Domain: Domain
+	Record?: DMARCRecord | null
+	RecordAuthentic: boolean
+	AlignedSPFPass: boolean
+	AlignedDKIMPass: boolean
+	Reject: boolean
+	Error: string
+}
+
+export interface SMTPAuthResult {
+	DurationMS: number
+	Host: Domain
+	IP: IP
+	Port: number
+	Security: string  // "tls" or "starttls".
+	TLSConnectionState?: TLSConnectionState | null
+	Mechanisms?: string[] | null  // Offered by server, in upper case.
+	Mechanism: string  // Used for authentication.
+	ChannelBinding: boolean  // Whether a PLUS variant of SCRAM was used, binding authentication to the TLS connection.
+	Success: boolean
+	Error: string
+	Trace?: Proto[] | null  // Lines with credentials are replaced with "***".
+}
+
+// SMTPReply is an SMTP reply, broken into its parts and explained.
+export interface SMTPReply {
+	Code: number  // Basic reply code, e.g. 550.
+	CodeExplanation: string
+	EnhancedCode: string  // E.g. "5.7.26", empty if absent.
+	Class: string  // Explanation of the first number of the enhanced code.
+	Subject: string
+	Detail: string  // Empty if the detail is not known.
+	Text: string  // Of all lines, without codes.
+	ClientError: string  // Explanation of the error from an SMTP client, like the mox smtpclient, before the reply.
+	Tags?: SMTPReplyTag[] | null
+	Advice: string
+}
+
+// SMTPReplyTag is a provider-specific code in an SMTP reply.
+export interface SMTPReplyTag {
+	Provider: string  // E.g. "Google" or "DNSBL".
+	Tag: string
+	Explanation: string  // Empty if the tag is not known.
+}
+
+export interface TLSScanResult {
+	DurationMS: number
+	Host: IPDomain
+	IP: IP
+	Versions?: TLSScanVersion[] | null
+	CipherSuites?: TLSScanCipherSuite[] | null  // For TLS 1.2. Go cannot restrict the TLS 1.3 cipher suites.
+	CertKeyTypes?: string[] | null  // Of certificates seen with ECDSA and RSA cipher suites, e.g. "ecdsa" and "rsa".
+	DualCert: boolean  // Whether different certificates are served for ECDSA and RSA cipher suites.
+	Warnings?: string[] | null  // About legacy protocols and insecure cipher suites.
+	Error: string
+}
+
+export interface TLSScanVersion {
+	Version: string
+	Accepted: boolean
+	CipherSuite: string  // Negotiated.
+	Error: string
+}
+
+export interface TLSScanCipherSuite {
+	Name: string
+	Insecure: boolean  // As marked by Go, e.g. RC4, 3DES or CBC with SHA-256.
+	Accepted: boolean
 }
 
 // TLSAUsage indicates which certificate/public key verification must be done.
@@ -346,13 +877,16 @@ export enum TLSAMatchType {
 	TLSAMatchTypeSHA512 = 2,  // SHA2-512-hashed data.
 }
 
-export type DKIMStatus = string
-
-// Localpart is a decoded local part of an email address, before the "@".
-// For quoted strings, values do not hold the double quote or escaping backslashes.
-// An empty string can be a valid localpart.
-// Localparts are in Unicode NFC.
-export type Localpart = string
+// An IP is a single IP address, a slice of bytes.
+// Functions in this package accept either 4-byte (IPv4)
+// or 16-byte (IPv6) slices as input.
+// 
+// Note that in this documentation, referring to an
+// IP address as an IPv4 address or an IPv6 address
+// is a semantic property of the address, not just the
+// length of the byte slice: a 16-byte slice can still
+// be an IPv4 address.
+export type IP = string
 
 // Policy as used in DMARC DNS record for "p=" or "sp=".
 export enum DMARCPolicy {
@@ -379,32 +913,36 @@ export enum Mode {
 	ModeNone = "none",  // In case MTA-STS is not or no longer implemented.
 }
 
-// An IP is a single IP address, a slice of bytes.
-// Functions in this package accept either 4-byte (IPv4)
-// or 16-byte (IPv6) slices as input.
-// 
-// Note that in this documentation, referring to an
-// IP address as an IPv4 address or an IPv6 address
-// is a semantic property of the address, not just the
-// length of the byte slice: a 16-byte slice can still
-// be an IPv4 address.
-export type IP = string
+export type DKIMStatus = string
 
-export const structTypes: {[typename: string]: boolean} = {"DKIMResult":true,"DMARCRecord":true,"Directive":true,"Domain":true,"DomainDANE":true,"DomainDMARC":true,"DomainDial":true,"DomainIP":true,"DomainMTASTS":true,"DomainMX":true,"DomainMXHost":true,"DomainResult":true,"DomainSMTP":true,"DomainSPF":true,"DomainTLSRPT":true,"Extension":true,"IPDomain":true,"Identity":true,"MTASTSRecord":true,"MX":true,"Modifier":true,"Pair":true,"Policy":true,"Proto":true,"Record":true,"SPFReceived":true,"SPFRecord":true,"Sig":true,"TLSARecord":true,"TLSConnectionState":true,"TLSRPTFailureDetails":true,"TLSRPTRecord":true,"TLSRPTResult":true,"TLSRPTResultPolicy":true,"TLSRPTSummary":true,"URI":true}
+// Localpart is a decoded local part of an email address, before the "@".
+// For quoted strings, values do not hold the double quote or escaping backslashes.
+// An empty string can be a valid localpart.
+// Localparts are in Unicode NFC.
+export type Localpart = string
+
+export const structTypes: {[typename: string]: boolean} = {"Address":true,"AddressCheckResult":true,"AddressProbe":true,"ClientConfigAutoconfig":true,"ClientConfigAutodiscover":true,"ClientConfigEndpoint":true,"ClientConfigResult":true,"ClientConfigSRV":true,"ClientConfigServer":true,"DKIMBreakage":true,"DKIMDebug":true,"DKIMDebugHeader":true,"DKIMDebugLine":true,"DKIMDiscoverResult":true,"DKIMDiscovered":true,"DKIMHypothesis":true,"DKIMResult":true,"DMARCRecord":true,"DNSBLIP":true,"DNSBLResult":true,"DSN":true,"DSNRecipient":true,"Directive":true,"Domain":true,"DomainBatchResult":true,"DomainDANE":true,"DomainDMARC":true,"DomainDial":true,"DomainGrade":true,"DomainIP":true,"DomainMTASTS":true,"DomainMX":true,"DomainMXHost":true,"DomainMXIP":true,"DomainParity":true,"DomainResult":true,"DomainSMTP":true,"DomainSPF":true,"DomainSummary":true,"DomainTLSRPT":true,"Envelope":true,"ExpectationCheck":true,"Expectations":true,"ExpectationsResult":true,"ExpectedDKIM":true,"Extension":true,"Finding":true,"IPDomain":true,"IPRevResult":true,"Identity":true,"MIMEHeader":true,"MIMEInspection":true,"MIMEPart":true,"MTASTSRecord":true,"MX":true,"Modifier":true,"Pair":true,"Policy":true,"Proto":true,"Record":true,"ReflectorDMARC":true,"ReflectorMessage":true,"ReflectorResult":true,"ReflectorSPF":true,"SMTPAuthResult":true,"SMTPEHLO":true,"SMTPExtension":true,"SMTPReply":true,"SMTPReplyTag":true,"SPFReceived":true,"SPFRecord":true,"SRVRecord":true,"Sig":true,"TLSARecord":true,"TLSConnectionState":true,"TLSRPTFailureDetails":true,"TLSRPTRecord":true,"TLSRPTResult":true,"TLSRPTResultPolicy":true,"TLSRPTSummary":true,"TLSScanCipherSuite":true,"TLSScanResult":true,"TLSScanVersion":true,"TestDeliveryResult":true,"URI":true}
 export const stringsTypes: {[typename: string]: boolean} = {"Align":true,"DKIMStatus":true,"DMARCPolicy":true,"IP":true,"Localpart":true,"Mode":true,"RUA":true}
 export const intsTypes: {[typename: string]: boolean} = {"TLSAMatchType":true,"TLSASelector":true,"TLSAUsage":true}
 export const types: TypenameMap = {
-	"SPFReceived": {"Name":"SPFReceived","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Mechanism","Docs":"","Typewords":["string"]}]},
+	"AddressCheckResult": {"Name":"AddressCheckResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Address","Docs":"","Typewords":["string"]},{"Name":"Valid","Docs":"","Typewords":["bool"]},{"Name":"SyntaxError","Docs":"","Typewords":["string"]},{"Name":"Localpart","Docs":"","Typewords":["string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"Normalized","Docs":"","Typewords":["string"]},{"Name":"SMTPUTF8","Docs":"","Typewords":["bool"]},{"Name":"Findings","Docs":"","Typewords":["[]","Finding"]},{"Name":"HaveMX","Docs":"","Typewords":["bool"]},{"Name":"NullMX","Docs":"","Typewords":["bool"]},{"Name":"ExpandedDomain","Docs":"","Typewords":["Domain"]},{"Name":"Hosts","Docs":"","Typewords":["[]","IPDomain"]},{"Name":"DestinationsError","Docs":"","Typewords":["string"]},{"Name":"Deliverable","Docs":"","Typewords":["bool"]},{"Name":"Probe","Docs":"","Typewords":["nullable","AddressProbe"]}]},
 	"Domain": {"Name":"Domain","Docs":"","Fields":[{"Name":"ASCII","Docs":"","Typewords":["string"]},{"Name":"Unicode","Docs":"","Typewords":["string"]}]},
-	"Record": {"Name":"Record","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Hashes","Docs":"","Typewords":["[]","string"]},{"Name":"Key","Docs":"","Typewords":["string"]},{"Name":"Notes","Docs":"","Typewords":["string"]},{"Name":"Pubkey","Docs":"","Typewords":["nullable","string"]},{"Name":"Services","Docs":"","Typewords":["[]","string"]},{"Name":"Flags","Docs":"","Typewords":["[]","string"]}]},
-	"DKIMResult": {"Name":"DKIMResult","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["DKIMStatus"]},{"Name":"Sig","Docs":"","Typewords":["nullable","Sig"]},{"Name":"Record","Docs":"","Typewords":["nullable","Record"]},{"Name":"RecordAuthentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
-	"Sig": {"Name":"Sig","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["int32"]},{"Name":"AlgorithmSign","Docs":"","Typewords":["string"]},{"Name":"AlgorithmHash","Docs":"","Typewords":["string"]},{"Name":"Signature","Docs":"","Typewords":["nullable","string"]},{"Name":"BodyHash","Docs":"","Typewords":["nullable","string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"SignedHeaders","Docs":"","Typewords":["[]","string"]},{"Name":"Selector","Docs":"","Typewords":["Domain"]},{"Name":"Canonicalization","Docs":"","Typewords":["string"]},{"Name":"Length","Docs":"","Typewords":["int64"]},{"Name":"Identity","Docs":"","Typewords":["nullable","Identity"]},{"Name":"QueryMethods","Docs":"","Typewords":["[]","string"]},{"Name":"SignTime","Docs":"","Typewords":["int64"]},{"Name":"ExpireTime","Docs":"","Typewords":["int64"]},{"Name":"CopiedHeaders","Docs":"","Typewords":["[]","string"]}]},
-	"Identity": {"Name":"Identity","Docs":"","Fields":[{"Name":"Localpart","Docs":"","Typewords":["nullable","Localpart"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]}]},
-	"DomainResult": {"Name":"DomainResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"SPF","Docs":"","Typewords":["DomainSPF"]},{"Name":"DMARC","Docs":"","Typewords":["DomainDMARC"]},{"Name":"TLSRPT","Docs":"","Typewords":["DomainTLSRPT"]},{"Name":"MTASTS","Docs":"","Typewords":["DomainMTASTS"]},{"Name":"MX","Docs":"","Typewords":["DomainMX"]},{"Name":"MXHosts","Docs":"","Typewords":["[]","DomainMXHost"]}]},
-	"DomainSPF": {"Name":"DomainSPF","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"TXT","Docs":"","Typewords":["string"]},{"Name":"Record","Docs":"","Typewords":["nullable","SPFRecord"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"Finding": {"Name":"Finding","Docs":"","Fields":[{"Name":"Check","Docs":"","Typewords":["string"]},{"Name":"Severity","Docs":"","Typewords":["string"]},{"Name":"Title","Docs":"","Typewords":["string"]},{"Name":"Explanation","Docs":"","Typewords":["string"]},{"Name":"Remediation","Docs":"","Typewords":["string"]},{"Name":"Points","Docs":"","Typewords":["int32"]}]},
+	"IPDomain": {"Name":"IPDomain","Docs":"","Fields":[{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]}]},
+	"AddressProbe": {"Name":"AddressProbe","Docs":"","Fields":[{"Name":"Host","Docs":"","Typewords":["IPDomain"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Result","Docs":"","Typewords":["string"]},{"Name":"Response","Docs":"","Typewords":["string"]},{"Name":"Explanation","Docs":"","Typewords":["string"]},{"Name":"RandomAddress","Docs":"","Typewords":["string"]},{"Name":"RandomResponse","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Trace","Docs":"","Typewords":["[]","Proto"]}]},
+	"Proto": {"Name":"Proto","Docs":"","Fields":[{"Name":"ClientWrite","Docs":"","Typewords":["bool"]},{"Name":"Text","Docs":"","Typewords":["string"]}]},
+	"DomainBatchResult": {"Name":"DomainBatchResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Summaries","Docs":"","Typewords":["[]","DomainSummary"]},{"Name":"Results","Docs":"","Typewords":["[]","nullable","DomainResult"]}]},
+	"DomainSummary": {"Name":"DomainSummary","Docs":"","Fields":[{"Name":"Domain","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Grade","Docs":"","Typewords":["string"]},{"Name":"Score","Docs":"","Typewords":["int32"]},{"Name":"SPF","Docs":"","Typewords":["string"]},{"Name":"DMARC","Docs":"","Typewords":["string"]},{"Name":"MTASTS","Docs":"","Typewords":["string"]},{"Name":"TLSRPT","Docs":"","Typewords":["bool"]},{"Name":"DNSSEC","Docs":"","Typewords":["bool"]},{"Name":"DANE","Docs":"","Typewords":["bool"]},{"Name":"STARTTLS","Docs":"","Typewords":["bool"]},{"Name":"MXHosts","Docs":"","Typewords":["int32"]},{"Name":"Findings","Docs":"","Typewords":["int32"]}]},
+	"DomainResult": {"Name":"DomainResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"SPF","Docs":"","Typewords":["DomainSPF"]},{"Name":"DKIM","Docs":"","Typewords":["DKIMDiscoverResult"]},{"Name":"DMARC","Docs":"","Typewords":["DomainDMARC"]},{"Name":"TLSRPT","Docs":"","Typewords":["DomainTLSRPT"]},{"Name":"MTASTS","Docs":"","Typewords":["DomainMTASTS"]},{"Name":"MX","Docs":"","Typewords":["DomainMX"]},{"Name":"MXHosts","Docs":"","Typewords":["[]","DomainMXHost"]},{"Name":"Grade","Docs":"","Typewords":["DomainGrade"]}]},
+	"DomainSPF": {"Name":"DomainSPF","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"TXT","Docs":"","Typewords":["string"]},{"Name":"Record","Docs":"","Typewords":["nullable","SPFRecord"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"DNSBL","Docs":"","Typewords":["[]","DNSBLIP"]},{"Name":"Lookups","Docs":"","Typewords":["int32"]},{"Name":"LookupsErr","Docs":"","Typewords":["string"]}]},
 	"SPFRecord": {"Name":"SPFRecord","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Directives","Docs":"","Typewords":["[]","Directive"]},{"Name":"Redirect","Docs":"","Typewords":["string"]},{"Name":"Explanation","Docs":"","Typewords":["string"]},{"Name":"Other","Docs":"","Typewords":["[]","Modifier"]}]},
 	"Directive": {"Name":"Directive","Docs":"","Fields":[{"Name":"Qualifier","Docs":"","Typewords":["string"]},{"Name":"Mechanism","Docs":"","Typewords":["string"]},{"Name":"DomainSpec","Docs":"","Typewords":["string"]},{"Name":"IPstr","Docs":"","Typewords":["string"]},{"Name":"IP4CIDRLen","Docs":"","Typewords":["nullable","int32"]},{"Name":"IP6CIDRLen","Docs":"","Typewords":["nullable","int32"]}]},
 	"Modifier": {"Name":"Modifier","Docs":"","Fields":[{"Name":"Key","Docs":"","Typewords":["string"]},{"Name":"Value","Docs":"","Typewords":["string"]}]},
+	"DNSBLIP": {"Name":"DNSBLIP","Docs":"","Fields":[{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Results","Docs":"","Typewords":["[]","DNSBLResult"]}]},
+	"DNSBLResult": {"Name":"DNSBLResult","Docs":"","Fields":[{"Name":"Zone","Docs":"","Typewords":["Domain"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Codes","Docs":"","Typewords":["[]","string"]},{"Name":"Reason","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"DKIMDiscoverResult": {"Name":"DKIMDiscoverResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Probed","Docs":"","Typewords":["int32"]},{"Name":"Wildcard","Docs":"","Typewords":["bool"]},{"Name":"Selectors","Docs":"","Typewords":["[]","DKIMDiscovered"]}]},
+	"DKIMDiscovered": {"Name":"DKIMDiscovered","Docs":"","Fields":[{"Name":"Selector","Docs":"","Typewords":["string"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"TXT","Docs":"","Typewords":["string"]},{"Name":"Record","Docs":"","Typewords":["nullable","Record"]},{"Name":"KeyType","Docs":"","Typewords":["string"]},{"Name":"KeyBits","Docs":"","Typewords":["int32"]},{"Name":"Flags","Docs":"","Typewords":["[]","string"]},{"Name":"Revoked","Docs":"","Typewords":["bool"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Audit","Docs":"","Typewords":["[]","Finding"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"Record": {"Name":"Record","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Hashes","Docs":"","Typewords":["[]","string"]},{"Name":"Key","Docs":"","Typewords":["string"]},{"Name":"Notes","Docs":"","Typewords":["string"]},{"Name":"Pubkey","Docs":"","Typewords":["nullable","string"]},{"Name":"Services","Docs":"","Typewords":["[]","string"]},{"Name":"Flags","Docs":"","Typewords":["[]","string"]}]},
 	"DomainDMARC": {"Name":"DomainDMARC","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"Record","Docs":"","Typewords":["nullable","DMARCRecord"]},{"Name":"TXT","Docs":"","Typewords":["string"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
 	"DMARCRecord": {"Name":"DMARCRecord","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Policy","Docs":"","Typewords":["DMARCPolicy"]},{"Name":"SubdomainPolicy","Docs":"","Typewords":["DMARCPolicy"]},{"Name":"AggregateReportAddresses","Docs":"","Typewords":["[]","URI"]},{"Name":"FailureReportAddresses","Docs":"","Typewords":["[]","URI"]},{"Name":"ADKIM","Docs":"","Typewords":["Align"]},{"Name":"ASPF","Docs":"","Typewords":["Align"]},{"Name":"AggregateReportingInterval","Docs":"","Typewords":["int32"]},{"Name":"FailureReportingOptions","Docs":"","Typewords":["[]","string"]},{"Name":"ReportingFormat","Docs":"","Typewords":["[]","string"]},{"Name":"Percentage","Docs":"","Typewords":["int32"]}]},
 	"URI": {"Name":"URI","Docs":"","Fields":[{"Name":"Address","Docs":"","Typewords":["string"]},{"Name":"MaxSize","Docs":"","Typewords":["uint64"]},{"Name":"Unit","Docs":"","Typewords":["string"]}]},
@@ -417,43 +955,92 @@ export const types: TypenameMap = {
 	"Policy": {"Name":"Policy","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Mode","Docs":"","Typewords":["Mode"]},{"Name":"MX","Docs":"","Typewords":["[]","MX"]},{"Name":"MaxAgeSeconds","Docs":"","Typewords":["int32"]},{"Name":"Extensions","Docs":"","Typewords":["[]","Pair"]}]},
 	"MX": {"Name":"MX","Docs":"","Fields":[{"Name":"Wildcard","Docs":"","Typewords":["bool"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]}]},
 	"DomainMX": {"Name":"DomainMX","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Have","Docs":"","Typewords":["bool"]},{"Name":"OrigNextHopAuthentic","Docs":"","Typewords":["bool"]},{"Name":"ExpandedNextHopAuthentic","Docs":"","Typewords":["bool"]},{"Name":"ExpandedNextHop","Docs":"","Typewords":["Domain"]},{"Name":"Permanent","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
-	"DomainMXHost": {"Name":"DomainMXHost","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Host","Docs":"","Typewords":["IPDomain"]},{"Name":"MTASTSError","Docs":"","Typewords":["string"]},{"Name":"IP","Docs":"","Typewords":["DomainIP"]},{"Name":"DANE","Docs":"","Typewords":["DomainDANE"]},{"Name":"Dial","Docs":"","Typewords":["DomainDial"]},{"Name":"SMTP","Docs":"","Typewords":["DomainSMTP"]}]},
-	"IPDomain": {"Name":"IPDomain","Docs":"","Fields":[{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]}]},
+	"DomainMXHost": {"Name":"DomainMXHost","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Host","Docs":"","Typewords":["IPDomain"]},{"Name":"MTASTSError","Docs":"","Typewords":["string"]},{"Name":"IP","Docs":"","Typewords":["DomainIP"]},{"Name":"DNSBL","Docs":"","Typewords":["[]","DNSBLIP"]},{"Name":"IPRev","Docs":"","Typewords":["[]","IPRevResult"]},{"Name":"DANE","Docs":"","Typewords":["DomainDANE"]},{"Name":"Dial","Docs":"","Typewords":["DomainDial"]},{"Name":"SMTP","Docs":"","Typewords":["DomainSMTP"]},{"Name":"IPResults","Docs":"","Typewords":["[]","DomainMXIP"]},{"Name":"Parity","Docs":"","Typewords":["nullable","DomainParity"]}]},
 	"DomainIP": {"Name":"DomainIP","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"ExpandedAuthentic","Docs":"","Typewords":["bool"]},{"Name":"ExpandedHost","Docs":"","Typewords":["Domain"]},{"Name":"IPs","Docs":"","Typewords":["[]","IP"]},{"Name":"DualStack","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
-	"DomainDANE": {"Name":"DomainDANE","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Required","Docs":"","Typewords":["bool"]},{"Name":"Records","Docs":"","Typewords":["[]","TLSARecord"]},{"Name":"TLSABaseDomain","Docs":"","Typewords":["Domain"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"VerifiedRecord","Docs":"","Typewords":["TLSARecord"]}]},
+	"IPRevResult": {"Name":"IPRevResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"Names","Docs":"","Typewords":["[]","string"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"EHLO","Docs":"","Typewords":["string"]},{"Name":"EHLOMatch","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"DomainDANE": {"Name":"DomainDANE","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Required","Docs":"","Typewords":["bool"]},{"Name":"Records","Docs":"","Typewords":["[]","TLSARecord"]},{"Name":"TLSABaseDomain","Docs":"","Typewords":["Domain"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"VerifiedRecord","Docs":"","Typewords":["TLSARecord"]},{"Name":"WithoutDNSSEC","Docs":"","Typewords":["bool"]}]},
 	"TLSARecord": {"Name":"TLSARecord","Docs":"","Fields":[{"Name":"Usage","Docs":"","Typewords":["TLSAUsage"]},{"Name":"Selector","Docs":"","Typewords":["TLSASelector"]},{"Name":"MatchType","Docs":"","Typewords":["TLSAMatchType"]},{"Name":"CertAssoc","Docs":"","Typewords":["nullable","string"]}]},
 	"DomainDial": {"Name":"DomainDial","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
-	"DomainSMTP": {"Name":"DomainSMTP","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Supports8bitMIME","Docs":"","Typewords":["bool"]},{"Name":"SupportsRequireTLS","Docs":"","Typewords":["bool"]},{"Name":"SupportsSMTPUTF8","Docs":"","Typewords":["bool"]},{"Name":"SupportsSTARTTLS","Docs":"","Typewords":["bool"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"RecipientDomainResult","Docs":"","Typewords":["nullable","TLSRPTResult"]},{"Name":"HostResult","Docs":"","Typewords":["nullable","TLSRPTResult"]},{"Name":"Trace","Docs":"","Typewords":["[]","Proto"]}]},
-	"TLSConnectionState": {"Name":"TLSConnectionState","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"CipherSuite","Docs":"","Typewords":["string"]},{"Name":"NegotiatedProtocol","Docs":"","Typewords":["string"]},{"Name":"ServerName","Docs":"","Typewords":["string"]}]},
+	"DomainSMTP": {"Name":"DomainSMTP","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Supports8bitMIME","Docs":"","Typewords":["bool"]},{"Name":"SupportsRequireTLS","Docs":"","Typewords":["bool"]},{"Name":"SupportsSMTPUTF8","Docs":"","Typewords":["bool"]},{"Name":"SupportsSTARTTLS","Docs":"","Typewords":["bool"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"RecipientDomainResult","Docs":"","Typewords":["nullable","TLSRPTResult"]},{"Name":"HostResult","Docs":"","Typewords":["nullable","TLSRPTResult"]},{"Name":"GreetingHostname","Docs":"","Typewords":["string"]},{"Name":"EHLO","Docs":"","Typewords":["nullable","SMTPEHLO"]},{"Name":"EHLOTLS","Docs":"","Typewords":["nullable","SMTPEHLO"]},{"Name":"Warnings","Docs":"","Typewords":["[]","string"]},{"Name":"Trace","Docs":"","Typewords":["[]","Proto"]}]},
+	"TLSConnectionState": {"Name":"TLSConnectionState","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"CipherSuite","Docs":"","Typewords":["string"]},{"Name":"NegotiatedProtocol","Docs":"","Typewords":["string"]},{"Name":"ServerName","Docs":"","Typewords":["string"]},{"Name":"CertFingerprint","Docs":"","Typewords":["string"]}]},
 	"TLSRPTResult": {"Name":"TLSRPTResult","Docs":"","Fields":[{"Name":"Policy","Docs":"","Typewords":["TLSRPTResultPolicy"]},{"Name":"Summary","Docs":"","Typewords":["TLSRPTSummary"]},{"Name":"FailureDetails","Docs":"","Typewords":["[]","TLSRPTFailureDetails"]}]},
 	"TLSRPTResultPolicy": {"Name":"TLSRPTResultPolicy","Docs":"","Fields":[{"Name":"Type","Docs":"","Typewords":["string"]},{"Name":"String","Docs":"","Typewords":["[]","string"]},{"Name":"Domain","Docs":"","Typewords":["string"]},{"Name":"MXHost","Docs":"","Typewords":["[]","string"]}]},
 	"TLSRPTSummary": {"Name":"TLSRPTSummary","Docs":"","Fields":[{"Name":"TotalSuccessfulSessionCount","Docs":"","Typewords":["int64"]},{"Name":"TotalFailureSessionCount","Docs":"","Typewords":["int64"]}]},
 	"TLSRPTFailureDetails": {"Name":"TLSRPTFailureDetails","Docs":"","Fields":[{"Name":"ResultType","Docs":"","Typewords":["string"]},{"Name":"SendingMTAIP","Docs":"","Typewords":["string"]},{"Name":"ReceivingMXHostname","Docs":"","Typewords":["string"]},{"Name":"ReceivingMXHelo","Docs":"","Typewords":["string"]},{"Name":"ReceivingIP","Docs":"","Typewords":["string"]},{"Name":"FailedSessionCount","Docs":"","Typewords":["int64"]},{"Name":"AdditionalInformation","Docs":"","Typewords":["string"]},{"Name":"FailureReasonCode","Docs":"","Typewords":["string"]}]},
-	"Proto": {"Name":"Proto","Docs":"","Fields":[{"Name":"ClientWrite","Docs":"","Typewords":["bool"]},{"Name":"Text","Docs":"","Typewords":["string"]}]},
+	"SMTPEHLO": {"Name":"SMTPEHLO","Docs":"","Fields":[{"Name":"Hostname","Docs":"","Typewords":["string"]},{"Name":"Extensions","Docs":"","Typewords":["[]","SMTPExtension"]},{"Name":"Size","Docs":"","Typewords":["int64"]},{"Name":"Pipelining","Docs":"","Typewords":["bool"]},{"Name":"Chunking","Docs":"","Typewords":["bool"]},{"Name":"DSN","Docs":"","Typewords":["bool"]},{"Name":"EnhancedStatusCodes","Docs":"","Typewords":["bool"]},{"Name":"StartTLS","Docs":"","Typewords":["bool"]},{"Name":"AuthMechanisms","Docs":"","Typewords":["[]","string"]},{"Name":"LimitRcptMax","Docs":"","Typewords":["int32"]},{"Name":"LimitMailMax","Docs":"","Typewords":["int32"]},{"Name":"LimitRcptDomainMax","Docs":"","Typewords":["int32"]}]},
+	"SMTPExtension": {"Name":"SMTPExtension","Docs":"","Fields":[{"Name":"Keyword","Docs":"","Typewords":["string"]},{"Name":"Params","Docs":"","Typewords":["string"]},{"Name":"Explanation","Docs":"","Typewords":["string"]}]},
+	"DomainMXIP": {"Name":"DomainMXIP","Docs":"","Fields":[{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Dial","Docs":"","Typewords":["DomainDial"]},{"Name":"SMTP","Docs":"","Typewords":["DomainSMTP"]},{"Name":"DANEVerifiedRecord","Docs":"","Typewords":["TLSARecord"]}]},
+	"DomainParity": {"Name":"DomainParity","Docs":"","Fields":[{"Name":"IPv4","Docs":"","Typewords":["DomainMXIP"]},{"Name":"IPv6","Docs":"","Typewords":["DomainMXIP"]},{"Name":"Differences","Docs":"","Typewords":["[]","string"]}]},
+	"DomainGrade": {"Name":"DomainGrade","Docs":"","Fields":[{"Name":"Score","Docs":"","Typewords":["int32"]},{"Name":"Grade","Docs":"","Typewords":["string"]},{"Name":"Findings","Docs":"","Typewords":["[]","Finding"]}]},
+	"ClientConfigResult": {"Name":"ClientConfigResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"SRV","Docs":"","Typewords":["[]","ClientConfigSRV"]},{"Name":"Autoconfig","Docs":"","Typewords":["ClientConfigAutoconfig"]},{"Name":"Autodiscover","Docs":"","Typewords":["ClientConfigAutodiscover"]},{"Name":"Endpoints","Docs":"","Typewords":["[]","ClientConfigEndpoint"]},{"Name":"Mismatches","Docs":"","Typewords":["[]","string"]}]},
+	"ClientConfigSRV": {"Name":"ClientConfigSRV","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Service","Docs":"","Typewords":["string"]},{"Name":"Protocol","Docs":"","Typewords":["string"]},{"Name":"Security","Docs":"","Typewords":["string"]},{"Name":"Records","Docs":"","Typewords":["[]","SRVRecord"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"SRVRecord": {"Name":"SRVRecord","Docs":"","Fields":[{"Name":"Target","Docs":"","Typewords":["string"]},{"Name":"Port","Docs":"","Typewords":["int32"]},{"Name":"Priority","Docs":"","Typewords":["int32"]},{"Name":"Weight","Docs":"","Typewords":["int32"]}]},
+	"ClientConfigAutoconfig": {"Name":"ClientConfigAutoconfig","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"URL","Docs":"","Typewords":["string"]},{"Name":"Servers","Docs":"","Typewords":["[]","ClientConfigServer"]},{"Name":"XML","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"ClientConfigServer": {"Name":"ClientConfigServer","Docs":"","Fields":[{"Name":"Protocol","Docs":"","Typewords":["string"]},{"Name":"Host","Docs":"","Typewords":["string"]},{"Name":"Port","Docs":"","Typewords":["int32"]},{"Name":"Security","Docs":"","Typewords":["string"]},{"Name":"Username","Docs":"","Typewords":["string"]},{"Name":"Authentication","Docs":"","Typewords":["string"]}]},
+	"ClientConfigAutodiscover": {"Name":"ClientConfigAutodiscover","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"URL","Docs":"","Typewords":["string"]},{"Name":"Servers","Docs":"","Typewords":["[]","ClientConfigServer"]},{"Name":"XML","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"ClientConfigEndpoint": {"Name":"ClientConfigEndpoint","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Protocol","Docs":"","Typewords":["string"]},{"Name":"Host","Docs":"","Typewords":["string"]},{"Name":"Port","Docs":"","Typewords":["int32"]},{"Name":"Security","Docs":"","Typewords":["string"]},{"Name":"Sources","Docs":"","Typewords":["[]","string"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Greeting","Docs":"","Typewords":["string"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"TestDeliveryResult": {"Name":"TestDeliveryResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"MailFrom","Docs":"","Typewords":["string"]},{"Name":"RcptTo","Docs":"","Typewords":["string"]},{"Name":"MessageID","Docs":"","Typewords":["string"]},{"Name":"DKIMSigned","Docs":"","Typewords":["bool"]},{"Name":"Host","Docs":"","Typewords":["IPDomain"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"Supports8bitMIME","Docs":"","Typewords":["bool"]},{"Name":"SupportsRequireTLS","Docs":"","Typewords":["bool"]},{"Name":"SupportsSMTPUTF8","Docs":"","Typewords":["bool"]},{"Name":"Need8bitMIME","Docs":"","Typewords":["bool"]},{"Name":"NeedSMTPUTF8","Docs":"","Typewords":["bool"]},{"Name":"NeedRequireTLS","Docs":"","Typewords":["bool"]},{"Name":"Response","Docs":"","Typewords":["string"]},{"Name":"QueueID","Docs":"","Typewords":["string"]},{"Name":"Success","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Trace","Docs":"","Typewords":["[]","Proto"]}]},
+	"DKIMBreakage": {"Name":"DKIMBreakage","Docs":"","Fields":[{"Name":"Result","Docs":"","Typewords":["DKIMResult"]},{"Name":"HeadersOK","Docs":"","Typewords":["bool"]},{"Name":"BodyOK","Docs":"","Typewords":["bool"]},{"Name":"Hypotheses","Docs":"","Typewords":["[]","DKIMHypothesis"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"DKIMResult": {"Name":"DKIMResult","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["DKIMStatus"]},{"Name":"Sig","Docs":"","Typewords":["nullable","Sig"]},{"Name":"Record","Docs":"","Typewords":["nullable","Record"]},{"Name":"RecordAuthentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"Sig": {"Name":"Sig","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["int32"]},{"Name":"AlgorithmSign","Docs":"","Typewords":["string"]},{"Name":"AlgorithmHash","Docs":"","Typewords":["string"]},{"Name":"Signature","Docs":"","Typewords":["nullable","string"]},{"Name":"BodyHash","Docs":"","Typewords":["nullable","string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"SignedHeaders","Docs":"","Typewords":["[]","string"]},{"Name":"Selector","Docs":"","Typewords":["Domain"]},{"Name":"Canonicalization","Docs":"","Typewords":["string"]},{"Name":"Length","Docs":"","Typewords":["int64"]},{"Name":"Identity","Docs":"","Typewords":["nullable","Identity"]},{"Name":"QueryMethods","Docs":"","Typewords":["[]","string"]},{"Name":"SignTime","Docs":"","Typewords":["int64"]},{"Name":"ExpireTime","Docs":"","Typewords":["int64"]},{"Name":"CopiedHeaders","Docs":"","Typewords":["[]","string"]}]},
+	"Identity": {"Name":"Identity","Docs":"","Fields":[{"Name":"Localpart","Docs":"","Typewords":["nullable","Localpart"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]}]},
+	"DKIMHypothesis": {"Name":"DKIMHypothesis","Docs":"","Fields":[{"Name":"Text","Docs":"","Typewords":["string"]},{"Name":"Confirmed","Docs":"","Typewords":["bool"]}]},
+	"DKIMDebug": {"Name":"DKIMDebug","Docs":"","Fields":[{"Name":"Result","Docs":"","Typewords":["DKIMResult"]},{"Name":"HeaderCanon","Docs":"","Typewords":["string"]},{"Name":"BodyCanon","Docs":"","Typewords":["string"]},{"Name":"Headers","Docs":"","Typewords":["[]","DKIMDebugHeader"]},{"Name":"Body","Docs":"","Typewords":["string"]},{"Name":"BodyLength","Docs":"","Typewords":["int32"]},{"Name":"Length","Docs":"","Typewords":["int64"]},{"Name":"BodyHash","Docs":"","Typewords":["string"]},{"Name":"BodyHashComputed","Docs":"","Typewords":["string"]},{"Name":"BodyHashOther","Docs":"","Typewords":["string"]},{"Name":"BodyLines","Docs":"","Typewords":["[]","DKIMDebugLine"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"DKIMDebugHeader": {"Name":"DKIMDebugHeader","Docs":"","Fields":[{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"Missing","Docs":"","Typewords":["bool"]},{"Name":"Raw","Docs":"","Typewords":["string"]},{"Name":"Canonical","Docs":"","Typewords":["string"]},{"Name":"Other","Docs":"","Typewords":["string"]}]},
+	"DKIMDebugLine": {"Name":"DKIMDebugLine","Docs":"","Fields":[{"Name":"Line","Docs":"","Typewords":["int32"]},{"Name":"Canonical","Docs":"","Typewords":["string"]},{"Name":"Other","Docs":"","Typewords":["string"]}]},
+	"DSN": {"Name":"DSN","Docs":"","Fields":[{"Name":"Format","Docs":"","Typewords":["string"]},{"Name":"ReportingMTA","Docs":"","Typewords":["string"]},{"Name":"ArrivalDate","Docs":"","Typewords":["string"]},{"Name":"Recipients","Docs":"","Typewords":["[]","DSNRecipient"]},{"Name":"Text","Docs":"","Typewords":["string"]},{"Name":"OriginalHeaders","Docs":"","Typewords":["[]","MIMEHeader"]}]},
+	"DSNRecipient": {"Name":"DSNRecipient","Docs":"","Fields":[{"Name":"FinalRecipient","Docs":"","Typewords":["string"]},{"Name":"OriginalRecipient","Docs":"","Typewords":["string"]},{"Name":"Action","Docs":"","Typewords":["string"]},{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"StatusExplanation","Docs":"","Typewords":["string"]},{"Name":"DiagnosticCode","Docs":"","Typewords":["string"]},{"Name":"RemoteMTA","Docs":"","Typewords":["string"]},{"Name":"LastAttemptDate","Docs":"","Typewords":["string"]},{"Name":"WillRetryUntil","Docs":"","Typewords":["string"]}]},
+	"MIMEHeader": {"Name":"MIMEHeader","Docs":"","Fields":[{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"Value","Docs":"","Typewords":["string"]},{"Name":"Decoded","Docs":"","Typewords":["string"]}]},
+	"Expectations": {"Name":"Expectations","Docs":"","Fields":[{"Name":"Domain","Docs":"","Typewords":["string"]},{"Name":"DMARCPolicy","Docs":"","Typewords":["string"]},{"Name":"SPFAll","Docs":"","Typewords":["string"]},{"Name":"MX","Docs":"","Typewords":["[]","string"]},{"Name":"DANERequired","Docs":"","Typewords":["bool"]},{"Name":"MTASTSMode","Docs":"","Typewords":["string"]},{"Name":"MTASTSMinMaxAge","Docs":"","Typewords":["int32"]},{"Name":"TLSRPT","Docs":"","Typewords":["bool"]},{"Name":"MinScore","Docs":"","Typewords":["int32"]},{"Name":"DKIM","Docs":"","Typewords":["[]","ExpectedDKIM"]}]},
+	"ExpectedDKIM": {"Name":"ExpectedDKIM","Docs":"","Fields":[{"Name":"Selector","Docs":"","Typewords":["string"]},{"Name":"KeyType","Docs":"","Typewords":["string"]}]},
+	"ExpectationsResult": {"Name":"ExpectationsResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"Checks","Docs":"","Typewords":["[]","ExpectationCheck"]},{"Name":"Violations","Docs":"","Typewords":["int32"]},{"Name":"Result","Docs":"","Typewords":["DomainResult"]}]},
+	"ExpectationCheck": {"Name":"ExpectationCheck","Docs":"","Fields":[{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"Expected","Docs":"","Typewords":["string"]},{"Name":"Actual","Docs":"","Typewords":["string"]},{"Name":"OK","Docs":"","Typewords":["bool"]}]},
+	"SPFReceived": {"Name":"SPFReceived","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Mechanism","Docs":"","Typewords":["string"]}]},
+	"MIMEInspection": {"Name":"MIMEInspection","Docs":"","Fields":[{"Name":"Size","Docs":"","Typewords":["int32"]},{"Name":"Part","Docs":"","Typewords":["nullable","MIMEPart"]},{"Name":"Findings","Docs":"","Typewords":["[]","Finding"]}]},
+	"MIMEPart": {"Name":"MIMEPart","Docs":"","Fields":[{"Name":"Path","Docs":"","Typewords":["string"]},{"Name":"ContentType","Docs":"","Typewords":["string"]},{"Name":"Params","Docs":"","Typewords":["{}","string"]},{"Name":"Charset","Docs":"","Typewords":["string"]},{"Name":"ContentTransferEncoding","Docs":"","Typewords":["string"]},{"Name":"Disposition","Docs":"","Typewords":["string"]},{"Name":"Filename","Docs":"","Typewords":["string"]},{"Name":"ContentID","Docs":"","Typewords":["string"]},{"Name":"Headers","Docs":"","Typewords":["[]","MIMEHeader"]},{"Name":"Envelope","Docs":"","Typewords":["nullable","Envelope"]},{"Name":"Size","Docs":"","Typewords":["int64"]},{"Name":"DecodedSize","Docs":"","Typewords":["int64"]},{"Name":"Lines","Docs":"","Typewords":["int64"]},{"Name":"Parts","Docs":"","Typewords":["[]","MIMEPart"]},{"Name":"Message","Docs":"","Typewords":["nullable","MIMEPart"]}]},
+	"Envelope": {"Name":"Envelope","Docs":"","Fields":[{"Name":"Date","Docs":"","Typewords":["timestamp"]},{"Name":"Subject","Docs":"","Typewords":["string"]},{"Name":"From","Docs":"","Typewords":["[]","Address"]},{"Name":"Sender","Docs":"","Typewords":["[]","Address"]},{"Name":"ReplyTo","Docs":"","Typewords":["[]","Address"]},{"Name":"To","Docs":"","Typewords":["[]","Address"]},{"Name":"CC","Docs":"","Typewords":["[]","Address"]},{"Name":"BCC","Docs":"","Typewords":["[]","Address"]},{"Name":"InReplyTo","Docs":"","Typewords":["string"]},{"Name":"MessageID","Docs":"","Typewords":["string"]}]},
+	"Address": {"Name":"Address","Docs":"","Fields":[{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"User","Docs":"","Typewords":["string"]},{"Name":"Host","Docs":"","Typewords":["string"]}]},
+	"ReflectorResult": {"Name":"ReflectorResult","Docs":"","Fields":[{"Name":"Address","Docs":"","Typewords":["string"]},{"Name":"Expires","Docs":"","Typewords":["timestamp"]},{"Name":"Messages","Docs":"","Typewords":["[]","ReflectorMessage"]}]},
+	"ReflectorMessage": {"Name":"ReflectorMessage","Docs":"","Fields":[{"Name":"Received","Docs":"","Typewords":["timestamp"]},{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"RemoteIP","Docs":"","Typewords":["IP"]},{"Name":"Hello","Docs":"","Typewords":["string"]},{"Name":"EHLO","Docs":"","Typewords":["bool"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"MailFrom","Docs":"","Typewords":["string"]},{"Name":"Size","Docs":"","Typewords":["int32"]},{"Name":"IPRev","Docs":"","Typewords":["IPRevResult"]},{"Name":"From","Docs":"","Typewords":["string"]},{"Name":"Subject","Docs":"","Typewords":["string"]},{"Name":"SPF","Docs":"","Typewords":["ReflectorSPF"]},{"Name":"DKIM","Docs":"","Typewords":["[]","DKIMResult"]},{"Name":"DMARC","Docs":"","Typewords":["ReflectorDMARC"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"ReflectorSPF": {"Name":"ReflectorSPF","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Mechanism","Docs":"","Typewords":["string"]},{"Name":"Identity","Docs":"","Typewords":["string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"Explanation","Docs":"","Typewords":["string"]},{"Name":"Authentic","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"ReflectorDMARC": {"Name":"ReflectorDMARC","Docs":"","Fields":[{"Name":"Status","Docs":"","Typewords":["string"]},{"Name":"Domain","Docs":"","Typewords":["Domain"]},{"Name":"Record","Docs":"","Typewords":["nullable","DMARCRecord"]},{"Name":"RecordAuthentic","Docs":"","Typewords":["bool"]},{"Name":"AlignedSPFPass","Docs":"","Typewords":["bool"]},{"Name":"AlignedDKIMPass","Docs":"","Typewords":["bool"]},{"Name":"Reject","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"SMTPAuthResult": {"Name":"SMTPAuthResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Host","Docs":"","Typewords":["Domain"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Port","Docs":"","Typewords":["int32"]},{"Name":"Security","Docs":"","Typewords":["string"]},{"Name":"TLSConnectionState","Docs":"","Typewords":["nullable","TLSConnectionState"]},{"Name":"Mechanisms","Docs":"","Typewords":["[]","string"]},{"Name":"Mechanism","Docs":"","Typewords":["string"]},{"Name":"ChannelBinding","Docs":"","Typewords":["bool"]},{"Name":"Success","Docs":"","Typewords":["bool"]},{"Name":"Error","Docs":"","Typewords":["string"]},{"Name":"Trace","Docs":"","Typewords":["[]","Proto"]}]},
+	"SMTPReply": {"Name":"SMTPReply","Docs":"","Fields":[{"Name":"Code","Docs":"","Typewords":["int32"]},{"Name":"CodeExplanation","Docs":"","Typewords":["string"]},{"Name":"EnhancedCode","Docs":"","Typewords":["string"]},{"Name":"Class","Docs":"","Typewords":["string"]},{"Name":"Subject","Docs":"","Typewords":["string"]},{"Name":"Detail","Docs":"","Typewords":["string"]},{"Name":"Text","Docs":"","Typewords":["string"]},{"Name":"ClientError","Docs":"","Typewords":["string"]},{"Name":"Tags","Docs":"","Typewords":["[]","SMTPReplyTag"]},{"Name":"Advice","Docs":"","Typewords":["string"]}]},
+	"SMTPReplyTag": {"Name":"SMTPReplyTag","Docs":"","Fields":[{"Name":"Provider","Docs":"","Typewords":["string"]},{"Name":"Tag","Docs":"","Typewords":["string"]},{"Name":"Explanation","Docs":"","Typewords":["string"]}]},
+	"TLSScanResult": {"Name":"TLSScanResult","Docs":"","Fields":[{"Name":"DurationMS","Docs":"","Typewords":["int32"]},{"Name":"Host","Docs":"","Typewords":["IPDomain"]},{"Name":"IP","Docs":"","Typewords":["IP"]},{"Name":"Versions","Docs":"","Typewords":["[]","TLSScanVersion"]},{"Name":"CipherSuites","Docs":"","Typewords":["[]","TLSScanCipherSuite"]},{"Name":"CertKeyTypes","Docs":"","Typewords":["[]","string"]},{"Name":"DualCert","Docs":"","Typewords":["bool"]},{"Name":"Warnings","Docs":"","Typewords":["[]","string"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"TLSScanVersion": {"Name":"TLSScanVersion","Docs":"","Fields":[{"Name":"Version","Docs":"","Typewords":["string"]},{"Name":"Accepted","Docs":"","Typewords":["bool"]},{"Name":"CipherSuite","Docs":"","Typewords":["string"]},{"Name":"Error","Docs":"","Typewords":["string"]}]},
+	"TLSScanCipherSuite": {"Name":"TLSScanCipherSuite","Docs":"","Fields":[{"Name":"Name","Docs":"","Typewords":["string"]},{"Name":"Insecure","Docs":"","Typewords":["bool"]},{"Name":"Accepted","Docs":"","Typewords":["bool"]}]},
 	"TLSAUsage": {"Name":"TLSAUsage","Docs":"","Values":[{"Name":"TLSAUsagePKIXTA","Value":0,"Docs":""},{"Name":"TLSAUsagePKIXEE","Value":1,"Docs":""},{"Name":"TLSAUsageDANETA","Value":2,"Docs":""},{"Name":"TLSAUsageDANEEE","Value":3,"Docs":""}]},
 	"TLSASelector": {"Name":"TLSASelector","Docs":"","Values":[{"Name":"TLSASelectorCert","Value":0,"Docs":""},{"Name":"TLSASelectorSPKI","Value":1,"Docs":""}]},
 	"TLSAMatchType": {"Name":"TLSAMatchType","Docs":"","Values":[{"Name":"TLSAMatchTypeFull","Value":0,"Docs":""},{"Name":"TLSAMatchTypeSHA256","Value":1,"Docs":""},{"Name":"TLSAMatchTypeSHA512","Value":2,"Docs":""}]},
-	"DKIMStatus": {"Name":"DKIMStatus","Docs":"","Values":null},
-	"Localpart": {"Name":"Localpart","Docs":"","Values":null},
+	"IP": {"Name":"IP","Docs":"","Values":[]},
 	"DMARCPolicy": {"Name":"DMARCPolicy","Docs":"","Values":[{"Name":"PolicyEmpty","Value":"","Docs":""},{"Name":"PolicyNone","Value":"none","Docs":""},{"Name":"PolicyQuarantine","Value":"quarantine","Docs":""},{"Name":"PolicyReject","Value":"reject","Docs":""}]},
 	"Align": {"Name":"Align","Docs":"","Values":[{"Name":"AlignStrict","Value":"s","Docs":""},{"Name":"AlignRelaxed","Value":"r","Docs":""}]},
 	"RUA": {"Name":"RUA","Docs":"","Values":null},
 	"Mode": {"Name":"Mode","Docs":"","Values":[{"Name":"ModeEnforce","Value":"enforce","Docs":""},{"Name":"ModeTesting","Value":"testing","Docs":""},{"Name":"ModeNone","Value":"none","Docs":""}]},
-	"IP": {"Name":"IP","Docs":"","Values":[]},
+	"DKIMStatus": {"Name":"DKIMStatus","Docs":"","Values":null},
+	"Localpart": {"Name":"Localpart","Docs":"","Values":null},
 }
 
 export const parser = {
-	SPFReceived: (v: any) => parse("SPFReceived", v) as SPFReceived,
+	AddressCheckResult: (v: any) => parse("AddressCheckResult", v) as AddressCheckResult,
 	Domain: (v: any) => parse("Domain", v) as Domain,
-	Record: (v: any) => parse("Record", v) as Record,
-	DKIMResult: (v: any) => parse("DKIMResult", v) as DKIMResult,
-	Sig: (v: any) => parse("Sig", v) as Sig,
-	Identity: (v: any) => parse("Identity", v) as Identity,
+	Finding: (v: any) => parse("Finding", v) as Finding,
+	IPDomain: (v: any) => parse("IPDomain", v) as IPDomain,
+	AddressProbe: (v: any) => parse("AddressProbe", v) as AddressProbe,
+	Proto: (v: any) => parse("Proto", v) as Proto,
+	DomainBatchResult: (v: any) => parse("DomainBatchResult", v) as DomainBatchResult,
+	DomainSummary: (v: any) => parse("DomainSummary", v) as DomainSummary,
 	DomainResult: (v: any) => parse("DomainResult", v) as DomainResult,
 	DomainSPF: (v: any) => parse("DomainSPF", v) as DomainSPF,
 	SPFRecord: (v: any) => parse("SPFRecord", v) as SPFRecord,
 	Directive: (v: any) => parse("Directive", v) as Directive,
 	Modifier: (v: any) => parse("Modifier", v) as Modifier,
+	DNSBLIP: (v: any) => parse("DNSBLIP", v) as DNSBLIP,
+	DNSBLResult: (v: any) => parse("DNSBLResult", v) as DNSBLResult,
+	DKIMDiscoverResult: (v: any) => parse("DKIMDiscoverResult", v) as DKIMDiscoverResult,
+	DKIMDiscovered: (v: any) => parse("DKIMDiscovered", v) as DKIMDiscovered,
+	Record: (v: any) => parse("Record", v) as Record,
 	DomainDMARC: (v: any) => parse("DomainDMARC", v) as DomainDMARC,
 	DMARCRecord: (v: any) => parse("DMARCRecord", v) as DMARCRecord,
 	URI: (v: any) => parse("URI", v) as URI,
@@ -467,8 +1054,8 @@ export const parser = {
 	MX: (v: any) => parse("MX", v) as MX,
 	DomainMX: (v: any) => parse("DomainMX", v) as DomainMX,
 	DomainMXHost: (v: any) => parse("DomainMXHost", v) as DomainMXHost,
-	IPDomain: (v: any) => parse("IPDomain", v) as IPDomain,
 	DomainIP: (v: any) => parse("DomainIP", v) as DomainIP,
+	IPRevResult: (v: any) => parse("IPRevResult", v) as IPRevResult,
 	DomainDANE: (v: any) => parse("DomainDANE", v) as DomainDANE,
 	TLSARecord: (v: any) => parse("TLSARecord", v) as TLSARecord,
 	DomainDial: (v: any) => parse("DomainDial", v) as DomainDial,
@@ -478,17 +1065,59 @@ export const parser = {
 	TLSRPTResultPolicy: (v: any) => parse("TLSRPTResultPolicy", v) as TLSRPTResultPolicy,
 	TLSRPTSummary: (v: any) => parse("TLSRPTSummary", v) as TLSRPTSummary,
 	TLSRPTFailureDetails: (v: any) => parse("TLSRPTFailureDetails", v) as TLSRPTFailureDetails,
-	Proto: (v: any) => parse("Proto", v) as Proto,
+	SMTPEHLO: (v: any) => parse("SMTPEHLO", v) as SMTPEHLO,
+	SMTPExtension: (v: any) => parse("SMTPExtension", v) as SMTPExtension,
+	DomainMXIP: (v: any) => parse("DomainMXIP", v) as DomainMXIP,
+	DomainParity: (v: any) => parse("DomainParity", v) as DomainParity,
+	DomainGrade: (v: any) => parse("DomainGrade", v) as DomainGrade,
+	ClientConfigResult: (v: any) => parse("ClientConfigResult", v) as ClientConfigResult,
+	ClientConfigSRV: (v: any) => parse("ClientConfigSRV", v) as ClientConfigSRV,
+	SRVRecord: (v: any) => parse("SRVRecord", v) as SRVRecord,
+	ClientConfigAutoconfig: (v: any) => parse("ClientConfigAutoconfig", v) as ClientConfigAutoconfig,
+	ClientConfigServer: (v: any) => parse("ClientConfigServer", v) as ClientConfigServer,
+	ClientConfigAutodiscover: (v: any) => parse("ClientConfigAutodiscover", v) as ClientConfigAutodiscover,
+	ClientConfigEndpoint: (v: any) => parse("ClientConfigEndpoint", v) as ClientConfigEndpoint,
+	TestDeliveryResult: (v: any) => parse("TestDeliveryResult", v) as TestDeliveryResult,
+	DKIMBreakage: (v: any) => parse("DKIMBreakage", v) as DKIMBreakage,
+	DKIMResult: (v: any) => parse("DKIMResult", v) as DKIMResult,
+	Sig: (v: any) => parse("Sig", v) as Sig,
+	Identity: (v: any) => parse("Identity", v) as Identity,
+	DKIMHypothesis: (v: any) => parse("DKIMHypothesis", v) as DKIMHypothesis,
+	DKIMDebug: (v: any) => parse("DKIMDebug", v) as DKIMDebug,
+	DKIMDebugHeader: (v: any) => parse("DKIMDebugHeader", v) as DKIMDebugHeader,
+	DKIMDebugLine: (v: any) => parse("DKIMDebugLine", v) as DKIMDebugLine,
+	DSN: (v: any) => parse("DSN", v) as DSN,
+	DSNRecipient: (v: any) => parse("DSNRecipient", v) as DSNRecipient,
+	MIMEHeader: (v: any) => parse("MIMEHeader", v) as MIMEHeader,
+	Expectations: (v: any) => parse("Expectations", v) as Expectations,
+	ExpectedDKIM: (v: any) => parse("ExpectedDKIM", v) as ExpectedDKIM,
+	ExpectationsResult: (v: any) => parse("ExpectationsResult", v) as ExpectationsResult,
+	ExpectationCheck: (v: any) => parse("ExpectationCheck", v) as ExpectationCheck,
+	SPFReceived: (v: any) => parse("SPFReceived", v) as SPFReceived,
+	MIMEInspection: (v: any) => parse("MIMEInspection", v) as MIMEInspection,
+	MIMEPart: (v: any) => parse("MIMEPart", v) as MIMEPart,
+	Envelope: (v: any) => parse("Envelope", v) as Envelope,
+	Address: (v: any) => parse("Address", v) as Address,
+	ReflectorResult: (v: any) => parse("ReflectorResult", v) as ReflectorResult,
+	ReflectorMessage: (v: any) => parse("ReflectorMessage", v) as ReflectorMessage,
+	ReflectorSPF: (v: any) => parse("ReflectorSPF", v) as ReflectorSPF,
+	ReflectorDMARC: (v: any) => parse("ReflectorDMARC", v) as ReflectorDMARC,
+	SMTPAuthResult: (v: any) => parse("SMTPAuthResult", v) as SMTPAuthResult,
+	SMTPReply: (v: any) => parse("SMTPReply", v) as SMTPReply,
+	SMTPReplyTag: (v: any) => parse("SMTPReplyTag", v) as SMTPReplyTag,
+	TLSScanResult: (v: any) => parse("TLSScanResult", v) as TLSScanResult,
+	TLSScanVersion: (v: any) => parse("TLSScanVersion", v) as TLSScanVersion,
+	TLSScanCipherSuite: (v: any) => parse("TLSScanCipherSuite", v) as TLSScanCipherSuite,
 	TLSAUsage: (v: any) => parse("TLSAUsage", v) as TLSAUsage,
 	TLSASelector: (v: any) => parse("TLSASelector", v) as TLSASelector,
 	TLSAMatchType: (v: any) => parse("TLSAMatchType", v) as TLSAMatchType,
-	DKIMStatus: (v: any) => parse("DKIMStatus", v) as DKIMStatus,
-	Localpart: (v: any) => parse("Localpart", v) as Localpart,
+	IP: (v: any) => parse("IP", v) as IP,
 	DMARCPolicy: (v: any) => parse("DMARCPolicy", v) as DMARCPolicy,
 	Align: (v: any) => parse("Align", v) as Align,
 	RUA: (v: any) => parse("RUA", v) as RUA,
 	Mode: (v: any) => parse("Mode", v) as Mode,
-	IP: (v: any) => parse("IP", v) as IP,
+	DKIMStatus: (v: any) => parse("DKIMStatus", v) as DKIMStatus,
+	Localpart: (v: any) => parse("Localpart", v) as Localpart,
 }
 
 let defaultOptions: ClientOptions = {slicesNullable: true, mapsNullable: true, nullableOptional: true}
@@ -518,6 +1147,94 @@ export class Client {
 		return c
 	}
 
+	async AddressCheck(address: string, probe: boolean): Promise<AddressCheckResult> {
+		const fn: string = "AddressCheck"
+		const paramTypes: string[][] = [["string"],["bool"]]
+		const returnTypes: string[][] = [["AddressCheckResult"]]
+		const params: any[] = [address, probe]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as AddressCheckResult
+	}
+
+	async DomainCheckBatch(domains: string[] | null): Promise<DomainBatchResult> {
+		const fn: string = "DomainCheckBatch"
+		const paramTypes: string[][] = [["[]","string"]]
+		const returnTypes: string[][] = [["DomainBatchResult"]]
+		const params: any[] = [domains]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DomainBatchResult
+	}
+
+	async ClientConfigCheck(domain: string): Promise<ClientConfigResult> {
+		const fn: string = "ClientConfigCheck"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["ClientConfigResult"]]
+		const params: any[] = [domain]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as ClientConfigResult
+	}
+
+	async TestDelivery(address: string, dkimSign: boolean, requireTLS: boolean, eightbit: boolean): Promise<TestDeliveryResult> {
+		const fn: string = "TestDelivery"
+		const paramTypes: string[][] = [["string"],["bool"],["bool"],["bool"]]
+		const returnTypes: string[][] = [["TestDeliveryResult"]]
+		const params: any[] = [address, dkimSign, requireTLS, eightbit]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as TestDeliveryResult
+	}
+
+	async DKIMBreakage(message: string | null): Promise<DKIMBreakage[] | null> {
+		const fn: string = "DKIMBreakage"
+		const paramTypes: string[][] = [["nullable","string"]]
+		const returnTypes: string[][] = [["[]","DKIMBreakage"]]
+		const params: any[] = [message]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DKIMBreakage[] | null
+	}
+
+	async DKIMDebug(message: string | null): Promise<DKIMDebug[] | null> {
+		const fn: string = "DKIMDebug"
+		const paramTypes: string[][] = [["nullable","string"]]
+		const returnTypes: string[][] = [["[]","DKIMDebug"]]
+		const params: any[] = [message]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DKIMDebug[] | null
+	}
+
+	async DKIMDiscover(domain: string): Promise<DKIMDiscoverResult> {
+		const fn: string = "DKIMDiscover"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["DKIMDiscoverResult"]]
+		const params: any[] = [domain]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DKIMDiscoverResult
+	}
+
+	async DNSBLCheck(ipstr: string): Promise<DNSBLIP> {
+		const fn: string = "DNSBLCheck"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["DNSBLIP"]]
+		const params: any[] = [ipstr]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DNSBLIP
+	}
+
+	async DSNParse(message: string | null): Promise<DSN> {
+		const fn: string = "DSNParse"
+		const paramTypes: string[][] = [["nullable","string"]]
+		const returnTypes: string[][] = [["DSN"]]
+		const params: any[] = [message]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DSN
+	}
+
+	async DomainExpect(exp: Expectations): Promise<ExpectationsResult> {
+		const fn: string = "DomainExpect"
+		const paramTypes: string[][] = [["Expectations"]]
+		const returnTypes: string[][] = [["ExpectationsResult"]]
+		const params: any[] = [exp]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as ExpectationsResult
+	}
+
+	async IPRevCheck(ipstr: string): Promise<IPRevResult> {
+		const fn: string = "IPRevCheck"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["IPRevResult"]]
+		const params: any[] = [ipstr]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as IPRevResult
+	}
+
 	async SPFCheck(domain: string, ipstr: string): Promise<[SPFReceived, Domain, string, boolean]> {
 		const fn: string = "SPFCheck"
 		const paramTypes: string[][] = [["string"],["string"]]
@@ -526,17 +1243,18 @@ export class Client {
 		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as [SPFReceived, Domain, string, boolean]
 	}
 
-	async DKIMLookup(selector: string, domain: string): Promise<[DKIMStatus, Record | null, string, boolean]> {
+	// DKIMLookup looks up a DKIM record, and audits the key and fields.
+	async DKIMLookup(selector: string, domain: string): Promise<[DKIMStatus, Record | null, string, boolean, Finding[] | null]> {
 		const fn: string = "DKIMLookup"
 		const paramTypes: string[][] = [["string"],["string"]]
-		const returnTypes: string[][] = [["DKIMStatus"],["nullable","Record"],["string"],["bool"]]
+		const returnTypes: string[][] = [["DKIMStatus"],["nullable","Record"],["string"],["bool"],["[]","Finding"]]
 		const params: any[] = [selector, domain]
-		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as [DKIMStatus, Record | null, string, boolean]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as [DKIMStatus, Record | null, string, boolean, Finding[] | null]
 	}
 
-	async DKIMVerify(message: string): Promise<DKIMResult[] | null> {
+	async DKIMVerify(message: string | null): Promise<DKIMResult[] | null> {
 		const fn: string = "DKIMVerify"
-		const paramTypes: string[][] = [["string"]]
+		const paramTypes: string[][] = [["nullable","string"]]
 		const returnTypes: string[][] = [["[]","DKIMResult"]]
 		const params: any[] = [message]
 		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DKIMResult[] | null
@@ -549,6 +1267,70 @@ export class Client {
 		const params: any[] = [domain]
 		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as DomainResult
 	}
+
+	// MIMEInspect parses a message, and shows the MIME structure and problems.
+	async MIMEInspect(message: string | null): Promise<MIMEInspection> {
+		const fn: string = "MIMEInspect"
+		const paramTypes: string[][] = [["nullable","string"]]
+		const returnTypes: string[][] = [["MIMEInspection"]]
+		const params: any[] = [message]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as MIMEInspection
+	}
+
+	// ReflectorStart returns a new token and the address to send a message to.
+	async ReflectorStart(): Promise<[string, string]> {
+		const fn: string = "ReflectorStart"
+		const paramTypes: string[][] = []
+		const returnTypes: string[][] = [["string"],["string"]]
+		const params: any[] = []
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as [string, string]
+	}
+
+	// ReflectorResults returns the messages received for the token.
+	async ReflectorResults(token: string): Promise<ReflectorResult> {
+		const fn: string = "ReflectorResults"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["ReflectorResult"]]
+		const params: any[] = [token]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as ReflectorResult
+	}
+
+	// DomainReport renders a domain check result as a self-contained document, for
+	// attaching to tickets. The result is passed in, so the domain doesn't have to be
+	// checked again.
+	async DomainReport(dr: DomainResult, format: string): Promise<string> {
+		const fn: string = "DomainReport"
+		const paramTypes: string[][] = [["DomainResult"],["string"]]
+		const returnTypes: string[][] = [["string"]]
+		const params: any[] = [dr, format]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as string
+	}
+
+	async SMTPAuthTest(host: string, port: number, security: string, mechanism: string, username: string, password: string): Promise<SMTPAuthResult> {
+		const fn: string = "SMTPAuthTest"
+		const paramTypes: string[][] = [["string"],["int32"],["string"],["string"],["string"],["string"]]
+		const returnTypes: string[][] = [["SMTPAuthResult"]]
+		const params: any[] = [host, port, security, mechanism, username, password]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as SMTPAuthResult
+	}
+
+	// SMTPReplyExplain breaks an SMTP reply, e.g. "550 5.7.26 ..." or an error from an
+	// SMTP client, into its parts and explains them.
+	async SMTPReplyExplain(text: string): Promise<SMTPReply> {
+		const fn: string = "SMTPReplyExplain"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["SMTPReply"]]
+		const params: any[] = [text]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as SMTPReply
+	}
+
+	async TLSScan(domain: string): Promise<TLSScanResult[] | null> {
+		const fn: string = "TLSScan"
+		const paramTypes: string[][] = [["string"]]
+		const returnTypes: string[][] = [["[]","TLSScanResult"]]
+		const params: any[] = [domain]
+		return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params) as TLSScanResult[] | null
+	}
 }
 
 export const defaultBaseURL = (function() {
@@ -4,6 +4,7 @@ declare let meta: {
 	GoVersion: string
 	GoOs: string
 	GoArch: string
+	Reflector: boolean
 }
 
 // All logging goes through log() instead of console.log, except "should not happen" logging.
@@ -100,6 +101,52 @@ const formatDANERecord = (r: api.TLSARecord) => {
 
 const duration = (ms: number) => [' ', dom.span(dom._class('duration'), ''+ms+'ms')]
 
+const dnsblIPs = (l: api.DNSBLIP[] | null | undefined) => (l || []).length === 0 ? dom.div('-') : (l || []).map(ipr =>
+	dom.div(
+		ipr.IP, ' ',
+		(ipr.Results || []).map(r => [
+			r.Status === 'fail' ? tag(red, 'listed: '+domainString(r.Zone), attr.title([...(r.Codes || []), r.Reason].filter(s => s).join('\n'))) :
+			r.Status === 'pass' ? tag(green, domainString(r.Zone)) :
+			tag(orange, domainString(r.Zone), attr.title(r.Error || r.Status)),
+			' ',
+		]),
+	)
+)
+
+const ehloExtensions = (e: api.SMTPEHLO) =>
+	(e.Extensions || []).map(x =>
+		dom.div(style({paddingLeft: '1em'}), x.Explanation ? attr.title(x.Explanation) : [], verbatim(x.Keyword + (x.Params ? ' ' + x.Params : ''))),
+	)
+
+const iprevResult = (r: api.IPRevResult) =>
+	dom.div(
+		r.IP, ' ',
+		authTag(r.Status), ' ',
+		r.Name ? verbatim(r.Name) : [],
+		!r.Name && (r.Names || []).length > 0 ? ['PTR names not resolving to IP: ', verbatim((r.Names || []).join(', '))] : [],
+		r.EHLO ? [' ', r.EHLOMatch ? tag(green, 'matches ehlo') : tag(orange, 'ehlo mismatch', attr.title('EHLO hostname: '+r.EHLO))] : [],
+		errorTag(r.Error),
+	)
+
+const findingsList = (l: api.Finding[] | null | undefined) => (l || []).map(f =>
+	group(
+		dom.div(tag(f.Severity === 'error' ? red : (f.Severity === 'warning' ? orange : grey), f.Severity), ' ', dom.span(style({fontWeight: 'bold'}), f.Title), f.Points ? ' (-'+f.Points+')' : []),
+		dom.div(f.Explanation),
+		f.Remediation ? dom.div('Remediation: ', f.Remediation) : [],
+	),
+)
+
+const downloadReport = async (dr: api.DomainResult, format: string, ext: string, mimeType: string) => {
+	try {
+		const s = await client.DomainReport(dr, format)
+		const a = dom.a(attr.href(URL.createObjectURL(new Blob([s], {type: mimeType}))), attr.download('report-'+dr.Domain.ASCII+'.'+ext))
+		a.click()
+		setTimeout(() => URL.revokeObjectURL(a.href), 1000)
+	} catch (err) {
+		window.alert('Error: '+errmsg(err))
+	}
+}
+
 const domainCheckResult = (dr: api.DomainResult) => {
 	const mtastsExplain = 'MTA-STS protects MX records of domains without DNSSEC, and requires PKIX/WebPKI verification of MX host TLS certificates (the historical default "opportunistic TLS" does not verify at all). MTA-STS depends on PKIX/WebPKI  (well-known Certificate Authorities) and trust-on-first-use ("TOFU"). DANE has similar goals and can coexist with MTA-STS.'
 	const tlsrptExplain = 'TLSRPT is a mechanism to request reports about SMTP TLS connections, both success and failures, such as invalid certificates.'
@@ -107,6 +154,27 @@ const domainCheckResult = (dr: api.DomainResult) => {
 
 	return dom.div(
 		dom.h3('Results for receiving from ', domainString(dr.Domain)),
+		dom.div(
+			'Download report: ',
+			dom.clickbutton('HTML', async function click() { await downloadReport(dr, 'html', 'html', 'text/html') }), ' ',
+			dom.clickbutton('Markdown', async function click() { await downloadReport(dr, 'markdown', 'md', 'text/markdown') }), ' ',
+			dom.clickbutton('JSON', async function click() { await downloadReport(dr, 'json', 'json', 'application/json') }),
+		),
+		dom.br(),
+		dom.div(dom._class('row'),
+			dom.div(dom._class('result'),
+				dom.h4('Grade', attr.title('Score calculated from the findings, each finding deducts points. A is 90 and higher, F is below 60.')),
+				dom.div(
+					dom.span(style({fontSize: '2em', fontWeight: 'bold', color: dr.Grade.Grade === 'A' || dr.Grade.Grade === 'B' ? green : (dr.Grade.Grade === 'F' ? red : orange)}), dr.Grade.Grade),
+					' ', ''+dr.Grade.Score+'/100',
+				),
+			),
+			dom.div(dom._class('result'), style({maxWidth: '50em'}),
+				dom.h4('Findings'),
+				(dr.Grade.Findings || []).length === 0 ? dom.div('No findings.') : [],
+				findingsList(dr.Grade.Findings),
+			),
+		),
 		dom.div(dom._class('row'),
 			dom.div(dom._class('result'),
 				dom.h4('SPF', duration(dr.SPF.DurationMS)),
@@ -145,11 +213,34 @@ const domainCheckResult = (dr: api.DomainResult) => {
 					dom.div(dnsTXT(dr.SPF.TXT)),
 					dnssecTag(dr.SPF.Authentic),
 				),
+				!dr.SPF.Record ? [] : group(
+					title('DNS lookups', attr.title('Number of DNS lookups needed to evaluate the SPF record, for include, a, mx, ptr, exists and redirect, including nested records. At most 10 are allowed, more results in a permerror.')),
+					dom.div(tag(dr.SPF.Lookups > 10 ? red : green, ''+dr.SPF.Lookups+'/10')),
+					errorTag(dr.SPF.LookupsErr),
+				),
+				(dr.SPF.DNSBL || []).length === 0 ? [] : group(
+					title('DNSBL', attr.title('Listing in DNS blocklists of the single IPs in the SPF record.')),
+					dnsblIPs(dr.SPF.DNSBL),
+				),
 			),
 			dom.div(dom._class('result'),
-				dom.h4('DKIM'),
+				dom.h4('DKIM', duration(dr.DKIM.DurationMS)),
 				group(
-					dom.div('Not checked.', attr.title('Not checked because DKIM records (selectors) cannot typically be enumerated. A domain can publish DKIM public keys in DNS, under a selector, and add DKIM-Signature headers to outgoing messages for verification by a receiving mail server.')),
+					dom.div('Probed '+dr.DKIM.Probed+' common selectors.', attr.title('DKIM records (selectors) cannot be enumerated, so only commonly used selectors are looked up. A domain can publish DKIM public keys in DNS, under a selector, and add DKIM-Signature headers to outgoing messages for verification by a receiving mail server.')),
+					(dr.DKIM.Selectors || []).length === 0 ? dom.div(tag(grey, 'none found')) : [],
+					dr.DKIM.Wildcard ? dom.div(tag(orange, 'wildcard', attr.title('A random selector has a DKIM record too, found selectors may not be in use.'))) : [],
+				),
+				(dr.DKIM.Selectors || []).map(d =>
+					group(
+						title('Selector ', verbatim(d.Selector)),
+						d.Error ? errorTag(d.Error) : dom.div(
+							d.Revoked ? tag(red, 'revoked') : [tag(d.KeyType === 'rsa' && d.KeyBits < 1024 ? red : green, d.KeyType+' '+d.KeyBits+' bits'), ' '],
+							(d.Flags || []).includes('y') ? [tag(orange, 'testing', attr.title('Flag t=y, the domain is testing DKIM, verifiers must not treat messages differently based on the signature.')), ' '] : [],
+							(d.Flags || []).includes('s') ? tag(grey, 'strict', attr.title('Flag t=s, the i= domain in signatures must be the same as d=.')) : [],
+						),
+						dnssecTag(d.Authentic),
+						findingsList((d.Audit || []).filter(f => f.Severity !== 'info' && f.Check !== 'dkim.syntax' && f.Check !== 'dkim.revoked')),
+					)
 				),
 			),
 			dom.div(dom._class('result'),
@@ -308,6 +399,14 @@ const domainCheckResult = (dr: api.DomainResult) => {
 							dnssecTag(mx.IP.Authentic),
 						),
 					),
+					group(
+						title('DNSBL', attr.title('Listing in DNS blocklists. Messages from listed IPs are often rejected.')),
+						dnsblIPs(mx.DNSBL),
+					),
+					group(
+						title('Reverse DNS', attr.title('PTR records of the IPs, forward-confirmed, and compared with the hostname from EHLO. Messages from IPs without matching PTR records are often penalized.')),
+						(mx.IPRev || []).length === 0 ? dom.div('-') : (mx.IPRev || []).map(r => iprevResult(r)),
+					),
 					group(
 						title('DANE', duration(mx.DANE.DurationMS)),
 						dom.div(
@@ -316,6 +415,7 @@ const domainCheckResult = (dr: api.DomainResult) => {
 							mx.DANE.Required ?
 								dom.div('Delivery to this MX host is protected with verified TLS.', attr.title(daneExplain)) :
 								dom.div('Delivery to this MX host is not protected with DANE-verified TLS.', attr.title(daneExplain)),
+							mx.DANE.WithoutDNSSEC ? dom.div(tag(red, 'TLSA records without DNSSEC'), ' TLSA records exist, but the MX records or IPs of the MX host are not DNSSEC-protected, so senders ignore them.') : [],
 							mx.DANE.Required ? [
 								mx.DANE.TLSABaseDomain.ASCII !== mx.Host.Domain.ASCII ? [
 									title('TLSA base domain:'),
@@ -347,6 +447,18 @@ const domainCheckResult = (dr: api.DomainResult) => {
 							) : '-',
 						),
 					),
+					!mx.SMTP.EHLO ? [] : group(
+						title('EHLO', attr.title('Parsed EHLO responses. Hover over an extension for an explanation.')),
+						(mx.SMTP.Warnings || []).map(w => dom.div(tag(orange, 'warning'), ' ', w)),
+						dom.div('Greeting hostname: ', mx.SMTP.GreetingHostname ? verbatim(mx.SMTP.GreetingHostname) : '-'),
+						dom.div('EHLO hostname: ', verbatim(mx.SMTP.EHLO.Hostname || '-')),
+						dom.div('Before STARTTLS:'),
+						ehloExtensions(mx.SMTP.EHLO),
+						mx.SMTP.EHLOTLS ? [
+							dom.div('After STARTTLS:'),
+							ehloExtensions(mx.SMTP.EHLOTLS),
+						] : [],
+					),
 					group(
 						title('TLS'),
 						dom.div('Version: ', mx.SMTP.TLSConnectionState ? verbatim(mx.SMTP.TLSConnectionState.Version) : '-'),
@@ -354,6 +466,11 @@ const domainCheckResult = (dr: api.DomainResult) => {
 						dom.div('PKIX verification: ', mx.SMTP.RecipientDomainResult ? (mx.SMTP.RecipientDomainResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-'),
 						dom.div('DANE verification: ',  mx.DANE.Required && mx.SMTP.HostResult ? (mx.SMTP.HostResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-'),
 					),
+					!mx.Parity ? [] : group(
+						title('IPv4/IPv6 parity', attr.title('Compares SMTP sessions over IPv4 and IPv6: greeting, EHLO extensions, STARTTLS, TLS certificate and DANE verification.')),
+						dom.div('Compared ', verbatim(mx.Parity.IPv4.IP || '-'), ' and ', verbatim(mx.Parity.IPv6.IP || '-')),
+						(mx.Parity.Differences || []).length === 0 ? dom.div(tag(green, 'no differences')) : (mx.Parity.Differences || []).map(d => dom.div(tag(red, 'difference'), ' ', d)),
+					),
 					!mx.SMTP.Trace ? [] : group(
 						title('Transcript'),
 						(mx.SMTP.Trace || []).map((l, index) => {
@@ -378,6 +495,458 @@ const domainCheckResult = (dr: api.DomainResult) => {
 	)
 }
 
+const clientConfigServers = (l: api.ClientConfigServer[] | null | undefined) => {
+	if (!l || l.length === 0) {
+		return dom.div('-')
+	}
+	return dom.table(
+		dom.tr(dom.th('Protocol'), dom.th('Host'), dom.th('Port'), dom.th('Security'), dom.th('Username'), dom.th('Authentication')),
+		l.map(s => dom.tr(dom.td(s.Protocol), dom.td(s.Host), dom.td(''+s.Port), dom.td(s.Security), dom.td(s.Username), dom.td(s.Authentication))),
+	)
+}
+
+const clientConfigResult = (r: api.ClientConfigResult) => {
+	return dom.div(
+		dom.h3('Client configuration for ', domainString(r.Domain), duration(r.DurationMS)),
+		dom.div(dom._class('row'),
+			dom.div(dom._class('result'),
+				dom.h4('Mismatches and problems'),
+				(r.Mismatches || []).length === 0 ? dom.div(tag(green, 'ok'), ' No mismatches found.') : (r.Mismatches || []).map(s => dom.div(tag(red, 'problem'), ' ', s)),
+			),
+			dom.div(dom._class('result'),
+				dom.h4('SRV records', attr.title('SRV records for finding IMAP, POP3 and submission servers, as specified in RFC 6186 and RFC 8314.')),
+				dom.table(
+					dom.tr(dom.th('Service'), dom.th('Target'), dom.th('Port'), dom.th('Priority'), dom.th('Weight'), dom.th('DNSSEC')),
+					(r.SRV || []).map(s => [
+						s.Error ? dom.tr(dom.td(s.Service), dom.td(attr.colspan('5'), errorTag(s.Error))) : [],
+						!s.Error && (s.Records || []).length === 0 ? dom.tr(dom.td(s.Service), dom.td(attr.colspan('5'), '-')) : [],
+						(s.Records || []).map(rec => dom.tr(dom.td(s.Service), dom.td(rec.Target || dom.span('.', attr.title('Service is explicitly not available.'))), dom.td(''+rec.Port), dom.td(''+rec.Priority), dom.td(''+rec.Weight), dom.td(s.Authentic ? 'yes' : 'no'))),
+					]),
+				),
+			),
+			dom.div(dom._class('result'),
+				dom.h4('Autoconfig', duration(r.Autoconfig.DurationMS), attr.title('Thunderbird-style autoconfig, also used by other email clients.')),
+				dom.div(verbatim(r.Autoconfig.URL)),
+				errorTag(r.Autoconfig.Error),
+				clientConfigServers(r.Autoconfig.Servers),
+				r.Autoconfig.XML ? group(title('Raw XML'), detailsLink(verbatim(r.Autoconfig.XML))) : [],
+			),
+			dom.div(dom._class('result'),
+				dom.h4('Autodiscover', duration(r.Autodiscover.DurationMS), attr.title('Microsoft-style autodiscover, mostly used by Outlook.')),
+				dom.div(verbatim(r.Autodiscover.URL)),
+				errorTag(r.Autodiscover.Error),
+				clientConfigServers(r.Autodiscover.Servers),
+				r.Autodiscover.XML ? group(title('Raw XML'), detailsLink(verbatim(r.Autodiscover.XML))) : [],
+			),
+		),
+		dom.div(dom._class('row'),
+			dom.div(dom._class('result'),
+				dom.h4('Endpoints'),
+				dom.table(
+					dom.tr(dom.th('Protocol'), dom.th('Host'), dom.th('Port'), dom.th('Security'), dom.th('Sources'), dom.th('IP'), dom.th('TLS'), dom.th('Greeting'), dom.th('Result')),
+					(r.Endpoints || []).map(e => dom.tr(
+						dom.td(e.Protocol),
+						dom.td(e.Host),
+						dom.td(''+e.Port),
+						dom.td(e.Security),
+						dom.td((e.Sources || []).join(', ')),
+						dom.td(e.IP || '-'),
+						dom.td(e.TLSConnectionState ? e.TLSConnectionState.Version + ', ' + e.TLSConnectionState.CipherSuite : '-'),
+						dom.td(verbatim(e.Greeting)),
+						dom.td(e.Error ? errorTag(e.Error) : tag(green, 'ok'), duration(e.DurationMS)),
+					)),
+				),
+			),
+		),
+		dom.br(),
+
+		dom.div(
+			dom.h4('Raw results as JSON'),
+			detailsLink(
+				dom.div(dom._class('result'), formatJSON(r)),
+			),
+		)
+	)
+}
+
+const authTag = (status: string) => tag(status === 'pass' ? green : (status === '' || status === 'none' || status === 'neutral' ? grey : red), status || 'none')
+
+const reflectorResult = (r: api.ReflectorResult, refresh: () => Promise<void>) => {
+	return dom.div(
+		dom.h3('Reflector'),
+		dom.div('Send a message to ', verbatim(r.Address), '. Messages are shown until ', r.Expires.toLocaleString(), '. ', dom.clickbutton('Refresh', attr.title('Check for newly received messages.'), async function click() { await refresh() })),
+		dom.br(),
+		(r.Messages || []).length === 0 ? dom.div('No messages received yet.') : [],
+		dom.div(dom._class('row'),
+			(r.Messages || []).map(m =>
+				dom.div(dom._class('result'),
+					dom.h4('Message received at ', m.Received.toLocaleString(), duration(m.DurationMS)),
+					errorTag(m.Error),
+					group(
+						title('Connection'),
+						dom.div('Remote IP: ', verbatim(m.RemoteIP)),
+						dom.div(m.EHLO ? 'EHLO: ' : 'HELO: ', verbatim(m.Hello)),
+						dom.div('Reverse DNS: ', iprevResult(m.IPRev)),
+						dom.div('TLS: ', m.TLSConnectionState ? m.TLSConnectionState.Version + ', ' + m.TLSConnectionState.CipherSuite : tag(red, 'none')),
+					),
+					group(
+						title('Message'),
+						dom.div('MAIL FROM: ', m.MailFrom ? verbatim(m.MailFrom) : '<> (null sender)'),
+						dom.div('From: ', m.From ? verbatim(m.From) : '-'),
+						dom.div('Subject: ', m.Subject || '-'),
+						dom.div('Size: ', ''+m.Size, ' bytes'),
+					),
+					group(
+						title('SPF'),
+						dom.div(authTag(m.SPF.Status), m.SPF.Identity ? [' for ', m.SPF.Identity, ' domain ', domainString(m.SPF.Domain)] : [], m.SPF.Mechanism ? [', mechanism ', verbatim(m.SPF.Mechanism)] : []),
+						m.SPF.Explanation ? dom.div('Explanation: ', m.SPF.Explanation) : [],
+						errorTag(m.SPF.Error),
+					),
+					group(
+						title('DKIM'),
+						(m.DKIM || []).length === 0 ? dom.div(authTag('none'), ' No DKIM signatures.') : [],
+						(m.DKIM || []).map(d =>
+							dom.div(authTag(d.Status), d.Sig ? [' ', verbatim(d.Sig.Domain.ASCII), ', selector ', verbatim(d.Sig.Selector.ASCII)] : [], errorTag(d.Error)),
+						),
+					),
+					group(
+						title('DMARC'),
+						dom.div(authTag(m.DMARC.Status), m.DMARC.Domain.ASCII ? [' for ', domainString(m.DMARC.Domain)] : [], m.DMARC.Record ? [', policy ', m.DMARC.Record.Policy] : []),
+						dom.div('Aligned SPF pass: ', m.DMARC.AlignedSPFPass ? tag(green, 'yes') : tag(red, 'no'), ', aligned DKIM pass: ', m.DMARC.AlignedDKIMPass ? tag(green, 'yes') : tag(red, 'no')),
+						m.DMARC.Reject ? dom.div(tag(red, 'reject'), ' Message would be rejected by the DMARC policy.') : [],
+						errorTag(m.DMARC.Error),
+					),
+				),
+			),
+		),
+		dom.br(),
+
+		dom.div(
+			dom.h4('Raw results as JSON'),
+			detailsLink(
+				dom.div(dom._class('result'), formatJSON(r)),
+			),
+		)
+	)
+}
+
+const domainBatchResult = (br: api.DomainBatchResult) => {
+	let details: HTMLElement
+	const yesno = (v: boolean) => v ? tag(green, 'yes') : tag(red, 'no')
+	const gradeColor = (g: string) => g === 'A' || g === 'B' ? green : (g === 'F' ? red : orange)
+	return dom.div(
+		dom.h3('Results', duration(br.DurationMS)),
+		dom.div('Click a domain for the full results.'),
+		dom.table(
+			dom.tr(['Domain', 'Grade', 'SPF', 'DMARC', 'MTA-STS', 'TLSRPT', 'DNSSEC', 'DANE', 'STARTTLS', 'MX hosts', 'Findings'].map(s => dom.th(s))),
+			(br.Summaries || []).map((s, i) => {
+				const dr = (br.Results || [])[i]
+				return dom.tr(
+					dom.td(dr ? dom.clickbutton(s.Domain, function click() { dom._kids(details, domainCheckResult(dr)) }) : s.Domain),
+					s.Error ? dom.td(attr.colspan('10'), errorTag(s.Error)) : [
+						dom.td(tag(gradeColor(s.Grade), s.Grade), ' ', ''+s.Score),
+						dom.td(tag(s.SPF === 'fail' || s.SPF === 'softfail' ? green : (s.SPF === 'neutral' || s.SPF === 'redirect' ? grey : red), s.SPF)),
+						dom.td(s.DMARC),
+						dom.td(s.MTASTS),
+						dom.td(yesno(s.TLSRPT)),
+						dom.td(yesno(s.DNSSEC)),
+						dom.td(yesno(s.DANE)),
+						dom.td(yesno(s.STARTTLS)),
+						dom.td(''+s.MXHosts),
+						dom.td(''+s.Findings),
+					],
+				)
+			}),
+		),
+		dom.br(),
+		details=dom.div(),
+	)
+}
+
+const expectationsResult = (r: api.ExpectationsResult) =>
+	dom.div(
+		dom.h3('Expectations for ', domainString(r.Domain), duration(r.DurationMS)),
+		dom.div(r.Violations === 0 ? tag(green, 'ok') : tag(red, ''+r.Violations+' violation'+(r.Violations === 1 ? '' : 's'))),
+		dom.br(),
+		dom.table(
+			dom.tr(['Expectation', 'Expected', 'Actual', 'Result'].map(s => dom.th(s))),
+			(r.Checks || []).map(c =>
+				dom.tr(
+					dom.td(c.Name),
+					dom.td(c.Expected),
+					dom.td(c.Actual),
+					dom.td(c.OK ? tag(green, 'ok') : tag(red, 'violation')),
+				)
+			),
+		),
+		dom.br(),
+		dom.h4('Domain check results'),
+		detailsLink(dom.div(domainCheckResult(r.Result))),
+	)
+
+// binaryString returns a string with a character per byte, as used by btoa.
+const binaryString = (buf: Uint8Array) => {
+	let s = ''
+	for (let i = 0; i < buf.length; i += 8192) {
+		s += String.fromCharCode(...buf.subarray(i, i+8192))
+	}
+	return s
+}
+
+// mboxMessages splits an mbox file into messages. A "From " line only starts a
+// message at the start or after an empty line. Lines escaped with ">" are
+// unescaped.
+const mboxMessages = (s: string): string[] => {
+	const l = s.split(/(?:^|\n\r?\n)From [^\n]*\n/).slice(1)
+	return l.map((m, i) => (i < l.length-1 ? m+'\n' : m.replace(/(\r?\n)\r?\n$/, '$1')).replace(/^>(>*From )/mg, '$1'))
+}
+
+const mboxSubject = (m: string) => (m.split(/\r?\n\r?\n/)[0].match(/^Subject:[ \t]*(.*)$/mi) || [])[1] || '(no subject)'
+
+// messageInput returns form fields for pasting a message or selecting a message or
+// mbox file, and a function returning the selected message, base64-encoded as
+// messages are sent as bytes, or an empty string if none.
+const messageInput = () => {
+	let text: HTMLTextAreaElement
+	let file: HTMLInputElement
+	let mbox: HTMLSelectElement
+	let messages: string[] = [] // From file, as binary strings.
+
+	const root = [
+		dom.div(
+			dom.label(
+				'Message',
+				dom.div(text=dom.textarea(attr.rows('10'))),
+			),
+		),
+		dom.div(
+			dom.label(
+				'Or a file, a message (.eml) or an mbox file',
+				dom.div(
+					file=dom.input(attr.type('file'), async function change() {
+						messages = []
+						dom._kids(mbox)
+						mbox.style.display = 'none'
+						const f = (file.files || [])[0]
+						if (!f) {
+							return
+						}
+						const s = binaryString(new Uint8Array(await f.arrayBuffer()))
+						if (s.startsWith('From ')) {
+							messages = mboxMessages(s)
+							dom._kids(mbox, messages.map((m, i) => dom.option(attr.value(''+i), ''+(i+1)+': '+mboxSubject(m))))
+							mbox.style.display = ''
+						} else {
+							messages = [s]
+						}
+					}),
+					' ',
+					mbox=dom.select(style({display: 'none', maxWidth: '30em'})),
+				),
+			),
+		),
+	]
+	const message = () => {
+		if (messages.length > 0) {
+			return btoa(messages[parseInt(mbox.value || '0')])
+		} else if (text.value) {
+			return btoa(binaryString(new TextEncoder().encode(text.value)))
+		}
+		return ''
+	}
+	return {root, message}
+}
+
+const headersTable = (l: api.MIMEHeader[] | null | undefined) =>
+	dom.table(
+		dom.tr(['Header', 'Value', 'Decoded'].map(s => dom.th(s))),
+		(l || []).map(h => dom.tr(dom.td(h.Name), dom.td(verbatim(h.Value)), dom.td(h.Decoded ? verbatim(h.Decoded) : '-'))),
+	)
+
+const mimePartResult = (p: api.MIMEPart): HTMLElement =>
+	dom.div(style({borderLeft: '2px solid '+grey, paddingLeft: '.75em', margin: '.5em 0'}),
+		dom.div(
+			dom.span(style({fontWeight: 'bold'}), p.Path ? 'Part '+p.Path : 'Message'), ' ',
+			verbatim(p.ContentType || 'text/plain'), p.ContentType ? [] : ' (default)',
+			p.Charset ? [', charset ', verbatim(p.Charset)] : [],
+			', ', p.ContentTransferEncoding || '7bit',
+			p.Disposition ? [', ', p.Disposition] : [],
+			p.Filename ? [', filename ', verbatim(p.Filename)] : [],
+			', ', p.Size < 0 ? 'incomplete' : ''+p.Size+' bytes'+(p.Size !== p.DecodedSize && (p.Parts || []).length === 0 ? ', '+p.DecodedSize+' decoded' : '')+', '+p.Lines+' lines',
+		),
+		p.Envelope ? dom.div(
+			p.Envelope.Subject ? dom.div('Subject: ', verbatim(p.Envelope.Subject)) : [],
+			(p.Envelope.From || []).map(a => dom.div('From: ', verbatim((a.Name ? a.Name+' ' : '')+'<'+a.User+'@'+a.Host+'>'))),
+		) : [],
+		detailsLink(
+			dom.div(
+				headersTable(p.Headers),
+				p.Envelope ? [dom.div('Envelope, as parsed from the headers:'), formatJSON(p.Envelope)] : [],
+			),
+		),
+		(p.Parts || []).map(pp => mimePartResult(pp)),
+		p.Message ? mimePartResult(p.Message) : [],
+	)
+
+const dsnResult = (d: api.DSN) =>
+	dom.div(dom._class('result'), style({flexGrow: '1'}),
+		dom.h4('Bounce'),
+		group(
+			title('Format'),
+			d.Format === 'rfc3464' ? 'Delivery status notification (RFC 3464)' : 'No delivery-status part, recipients and SMTP replies were searched for in the text',
+		),
+		d.ReportingMTA ? group(title('Reporting MTA'), d.ReportingMTA) : [],
+		d.ArrivalDate ? group(title('Arrival date'), d.ArrivalDate) : [],
+		group(
+			title('Recipients'),
+			(d.Recipients || []).length === 0 ? dom.div('No recipients found.') : dom.table(
+				dom.tr(['Recipient', 'Action', 'Status', 'Diagnostic', 'Remote MTA'].map(s => dom.th(s))),
+				(d.Recipients || []).map(r =>
+					dom.tr(
+						dom.td(r.FinalRecipient || '-', r.OriginalRecipient && r.OriginalRecipient !== r.FinalRecipient ? dom.div('Original: ', r.OriginalRecipient) : []),
+						dom.td(r.Action ? tag(r.Action === 'failed' ? red : (r.Action === 'delayed' ? orange : green), r.Action) : '-'),
+						dom.td(r.Status || '-', r.StatusExplanation ? dom.div(r.StatusExplanation) : []),
+						dom.td(r.DiagnosticCode ? verbatim(r.DiagnosticCode) : '-', r.LastAttemptDate ? dom.div('Last attempt: ', r.LastAttemptDate) : [], r.WillRetryUntil ? dom.div('Will retry until: ', r.WillRetryUntil) : []),
+						dom.td(r.RemoteMTA || '-'),
+					)
+				),
+			),
+		),
+		group(
+			title('Original message headers'),
+			(d.OriginalHeaders || []).length === 0 ? dom.div('Not included.') : headersTable(d.OriginalHeaders),
+		),
+		d.Text ? group(title('Text'), dom.div(style({maxHeight: '20em', overflow: 'auto'}), verbatim(d.Text))) : [],
+	)
+
+const smtpReplyResult = (r: api.SMTPReply) =>
+	dom.div(dom._class('result'), style({flexGrow: '1'}),
+		dom.h4('Reply'),
+		group(
+			title('Reply code'),
+			dom.div(tag(r.Code >= 500 ? red : (r.Code >= 400 ? orange : green), ''+r.Code), ' ', r.CodeExplanation || 'Unknown reply code.'),
+		),
+		r.EnhancedCode ? group(
+			title('Enhanced status code', attr.title('Class, subject and detail, RFC 3463.')),
+			dom.div(verbatim(r.EnhancedCode)),
+			dom.table(
+				dom.tr(dom.td('Class'), dom.td(r.Class || 'Unknown')),
+				dom.tr(dom.td('Subject'), dom.td(r.Subject || 'Unknown')),
+				dom.tr(dom.td('Detail'), dom.td(r.Detail || 'Unknown, not in the registry.')),
+			),
+		) : [],
+		r.ClientError ? group(title('Client error'), r.ClientError) : [],
+		(r.Tags || []).length === 0 ? [] : group(
+			title('Provider-specific codes'),
+			dom.table(
+				dom.tr(['Provider', 'Code', 'Explanation'].map(s => dom.th(s))),
+				(r.Tags || []).map(t => dom.tr(dom.td(t.Provider), dom.td(verbatim(t.Tag)), dom.td(t.Explanation || '-'))),
+			),
+		),
+		r.Text ? group(title('Text'), verbatim(r.Text)) : [],
+		r.Advice ? group(title('What to do'), r.Advice) : [],
+	)
+
+const addressCheckResult = (r: api.AddressCheckResult) =>
+	dom.div(dom._class('result'), style({flexGrow: '1'}),
+		dom.h4('Address'),
+		group(
+			title('Syntax'),
+			r.Valid ? dom.div(tag(green, 'valid'), ' ', verbatim(r.Normalized), r.SMTPUTF8 ? [' ', tag(orange, 'requires SMTPUTF8')] : []) : dom.div(tag(red, 'invalid'), ' ', r.SyntaxError),
+			r.Valid && r.Domain.Unicode ? dom.div('Domain: ', domainString(r.Domain)) : [],
+		),
+		!r.Valid ? [] : group(
+			title('Destinations', attr.title('MX targets, or the domain itself if it has no MX record (implicit MX).')),
+			r.NullMX ? dom.div(tag(red, 'null MX'), ' the domain does not accept email') : (r.Deliverable ? dom.div(tag(green, 'deliverable'), r.HaveMX ? [] : [' ', tag(orange, 'no MX record')]) : dom.div(tag(red, 'not deliverable'))),
+			errorTag(r.NullMX ? '' : r.DestinationsError),
+			r.ExpandedDomain.ASCII && r.ExpandedDomain.ASCII !== r.Domain.ASCII ? dom.div('After following CNAMEs: ', domainName(r.ExpandedDomain)) : [],
+			(r.Hosts || []).map(h => dom.div(verbatim(h.IP || domainName(h.Domain)))),
+		),
+		!r.Probe ? [] : group(
+			title('Probe', attr.title('MAIL FROM and RCPT TO at the first MX host that responds, without delivering a message. A random address at the same domain is tried to detect servers that accept all addresses (catch-all).')),
+			dom.div(tag(r.Probe.Result === 'accept' ? green : (r.Probe.Result === 'reject' ? red : orange), r.Probe.Result), ' at ', domainName(r.Probe.Host.Domain), r.Probe.IP ? ' ('+r.Probe.IP+')' : ''),
+			errorTag(r.Probe.Error),
+			r.Probe.Response ? dom.div('Response: ', verbatim(r.Probe.Response)) : [],
+			r.Probe.Explanation ? dom.div(r.Probe.Explanation) : [],
+			r.Probe.RandomAddress ? dom.div('Response for random address ', verbatim(r.Probe.RandomAddress), ': ', verbatim(r.Probe.RandomResponse || '-')) : [],
+			r.Probe.Result === 'catchall' ? dom.div('The server accepts any address at the domain, the probe cannot tell whether the address exists.') : [],
+			r.Probe.Result === 'unknown' ? dom.div('The server did not accept or reject the address, e.g. because of a temporary error or greylisting.') : [],
+			detailsLink(
+				dom.div((r.Probe.Trace || []).map(l => dom.div(dom._class('mono'), style({paddingLeft: '.5em', whiteSpace: 'pre-wrap', color: l.ClientWrite ? '#e48b00' : blue}), l.Text))),
+			),
+		),
+	)
+
+const dkimBreakageResult = (b: api.DKIMBreakage) =>
+	group(
+		title('Failure analysis', attr.title('Changes commonly made by intermediaries, like mailing lists, are reversed, and the signature is verified again.')),
+		dom.div(tag(b.HeadersOK ? green : red, b.HeadersOK ? 'headers ok' : 'headers modified'), ' ', tag(b.BodyOK ? green : red, b.BodyOK ? 'body ok' : 'body modified')),
+		errorTag(b.Error),
+		(b.Hypotheses || []).length === 0 ? dom.div('No explanation found.') : [],
+		(b.Hypotheses || []).map(h =>
+			dom.div(h.Confirmed ? tag(green, 'confirmed', attr.title('Reversing the change makes the signature, or the modified part, verify.')) : tag(grey, 'possible'), ' ', h.Text)
+		),
+	)
+
+const dkimDebugResult = (d: api.DKIMDebug) => {
+	const other = d.BodyCanon === 'simple' ? 'relaxed' : 'simple'
+	return dom.div(dom._class('result'), style({flexGrow: '1'}),
+		dom.h4('Signature'),
+		errorTag(d.Result.Error),
+		errorTag(d.Error),
+		group(
+			title('Status'),
+			d.Result.Status,
+		),
+		!d.HeaderCanon ? [] : [
+			group(
+				title('Canonicalization', attr.title('Header and body canonicalization. With "simple", headers and body are hashed mostly as is. With "relaxed", whitespace is normalized, which survives more modifications by intermediaries.')),
+				d.HeaderCanon+'/'+d.BodyCanon,
+			),
+			group(
+				title('Hashed headers', attr.title('In the order they are hashed, from the bottom of the header section upwards. Headers in h= that are not present are not hashed, adding them later breaks the signature. The DKIM-Signature header itself is hashed last, without the value of b=.')),
+				dom.table(
+					dom.tr(['Header', 'Canonicalized, as hashed', 'Other header canonicalization'].map(s => dom.th(s))),
+					(d.Headers || []).map(h =>
+						dom.tr(
+							dom.td(h.Name, h.Missing ? [' ', tag(grey, 'not present', attr.title('Not hashed. Adding this header breaks the signature.'))] : []),
+							dom.td(verbatim(h.Canonical)),
+							dom.td(h.Canonical === h.Other ? '-' : verbatim(h.Other)),
+						)
+					),
+				),
+			),
+			group(
+				title('Body hash'),
+				dom.div('Claimed (bh=): ', verbatim(d.BodyHash)),
+				dom.div('Computed: ', verbatim(d.BodyHashComputed), ' ', d.BodyHash === d.BodyHashComputed ? tag(green, 'match') : tag(red, 'mismatch')),
+				dom.div('Computed with '+other+' body canonicalization: ', verbatim(d.BodyHashOther), d.BodyHash === d.BodyHashOther ? [' ', tag(orange, 'match', attr.title('The signer may have canonicalized the body differently than specified in the signature.'))] : []),
+			),
+			d.Length < 0 ? [] : group(
+				title('Length (l=)', attr.title('Only the first l= bytes of the canonicalized body are signed, content can be added after it.')),
+				dom.div(''+d.Length+' of '+d.BodyLength+' bytes of the canonicalized body signed.'),
+			),
+			group(
+				title('Lines differing with '+other+' body canonicalization', attr.title('Helps find whitespace changes. At most 100 lines are shown. Values are quoted to show whitespace.')),
+				(d.BodyLines || []).length === 0 ? dom.div('None.') : dom.table(
+					dom.tr(['Line', d.BodyCanon, other].map(s => dom.th(s))),
+					(d.BodyLines || []).map(l =>
+						dom.tr(
+							dom.td(''+l.Line),
+							dom.td(verbatim(JSON.stringify(l.Canonical))),
+							dom.td(verbatim(JSON.stringify(l.Other))),
+						)
+					),
+				),
+			),
+			group(
+				title('Canonicalized body', attr.title('As hashed, truncated to l= if present.')),
+				dom.div(style({maxHeight: '30em', overflow: 'auto'}), verbatim(d.Body)),
+			),
+		],
+	)
+}
+
 const showTimer = (result: HTMLElement, left: number): number => {
 	let timer: number
 	const showTimeleft = () => {
@@ -398,20 +967,71 @@ const init = async () => {
 	let spfDomain: HTMLInputElement
 	let spfIP: HTMLInputElement
 
+	let dnsblForm: HTMLFormElement
+	let dnsblFieldset: HTMLFieldSetElement
+	let dnsblIP: HTMLInputElement
+
+	let iprevForm: HTMLFormElement
+	let iprevFieldset: HTMLFieldSetElement
+	let iprevIP: HTMLInputElement
+
 	let dkimForm: HTMLFormElement
 	let dkimFieldset: HTMLFieldSetElement
 	let dkimDomain: HTMLInputElement
 	let dkimSelector: HTMLInputElement
 
 	let dkimverifyFieldset: HTMLFieldSetElement
-	let dkimverifyMessage: HTMLTextAreaElement
+	let dkimverifyDebug: HTMLInputElement
+	const dkimverifyInput = messageInput()
+
+	let mimeFieldset: HTMLFieldSetElement
+	const mimeInput = messageInput()
+
+	let dsnFieldset: HTMLFieldSetElement
+	const dsnInput = messageInput()
+
+	let smtpreplyFieldset: HTMLFieldSetElement
+	let smtpreplyText: HTMLTextAreaElement
+
+	let addressFieldset: HTMLFieldSetElement
+	let addressAddress: HTMLInputElement
+	let addressProbe: HTMLInputElement
 
 	let domainForm: HTMLFormElement
 	let domainFieldset: HTMLFieldSetElement
 	let domainName: HTMLInputElement
 
+	let batchFieldset: HTMLFieldSetElement
+	let batchDomains: HTMLTextAreaElement
+
+	let expectFieldset: HTMLFieldSetElement
+	let expectJSON: HTMLTextAreaElement
+
+	let clientconfigForm: HTMLFormElement
+	let clientconfigFieldset: HTMLFieldSetElement
+	let clientconfigDomain: HTMLInputElement
+
+	let reflectorFieldset: HTMLFieldSetElement
+
 	let result: HTMLElement
 
+	const reflectorShow = async (token: string) => {
+		const r = await client.ReflectorResults(token)
+		dom._kids(result,
+			dom.div(
+				dom._class('results'),
+				reflectorResult(r, async () => {
+					try {
+						await reflectorShow(token)
+					} catch (err) {
+						window.alert('Error: '+errmsg(err))
+					}
+				}),
+			),
+		)
+		result.scrollIntoView({block: 'nearest'})
+	}
+
 	dom._kids(document.body,
 		dom.div(
 			dom.div(style({float: 'right', color: '#888'}), dom.div(meta?.Version, ' ', meta?.GoVersion, ' ', meta?.GoOs, '/', meta?.GoArch)),
@@ -468,108 +1088,389 @@ const init = async () => {
 			),
 
 			dom.div(dom._class('inputs'), style({width: '20em'}),
-				dom.h2('Check SPF'),
-				spfForm=dom.form(
+				dom.h2('Batch domain check'),
+				dom.form(
 					async function submit(e: SubmitEvent) {
 						e.preventDefault()
 						e.stopPropagation()
 
-						window.location.hash = ['#spfcheck', encodeURIComponent(spfDomain.value), encodeURIComponent(spfIP.value)].join('/')
-
-						const timer = showTimer(result, 15)
+						const domains = batchDomains.value.split('\n').map(s => s.trim()).filter(s => s && !s.startsWith('#'))
+						const timer = showTimer(result, 30*Math.ceil(domains.length/5))
 						try {
-							spfFieldset.disabled = true
-							result.scrollIntoView({block: 'nearest'})
-							const [received, _, explanation, authentic] = await client.SPFCheck(spfDomain.value, spfIP.value)
+							batchFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+							const br = await client.DomainCheckBatch(domains)
 							clearInterval(timer)
 							dom._kids(result,
 								dom.div(
 									dom._class('results'),
-									dom.h3('Results'),
-									dom.div(dom._class('row'),
-										dom.div(dom._class('result'),
-											group(
-												title('Status'),
-												dom.div(received.Status),
-											),
-											group(
-												title('Mechanism'),
-												dom.div(received.Mechanism),
-											),
-											group(
-												title('Explanation'),
-												dom.div(explanation || '-'),
-											),
-											dnssecTag(authentic),
-										),
-									),
+									domainBatchResult(br),
 								),
 							)
-							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+							result.scrollIntoView({block: 'nearest'})
 						} catch (err) {
 							dom._kids(result)
 							window.alert('Error: '+errmsg(err))
 						} finally {
 							clearInterval(timer)
-							spfFieldset.disabled = false
+							batchFieldset.disabled = false
 						}
 					},
-					spfFieldset=dom.fieldset(
-						dom.div(
-							dom.label(
-								'Domain',
-								dom.div(spfDomain=dom.input(attr.required(''))),
-							),
-						),
+					batchFieldset=dom.fieldset(
 						dom.div(
 							dom.label(
-								'IP',
-								dom.div(spfIP=dom.input(attr.required(''))),
+								'Domains, one per line',
+								dom.div(batchDomains=dom.textarea(attr.required(''), attr.rows('5'), style({width: '100%'}))),
 							),
 						),
 						dom.div(
-							dom.submitbutton('Check'),
+							dom.submitbutton('Verify'),
 						),
 					),
 				),
-				dom.div(dom._class('explanation'), 'Evaluates the IP address against the SPF policy of the domain.'),
+				dom.div(dom._class('explanation'), 'Checks up to 100 domains, 5 at a time, and shows a summary table. Use the "domaincheckbatch" subcommand for more domains, with CSV output.'),
 			),
 
 			dom.div(dom._class('inputs'), style({width: '20em'}),
-				dom.h2('Lookup DKIM record'),
-				dkimForm=dom.form(
+				dom.h2('Domain expectations'),
+				dom.form(
 					async function submit(e: SubmitEvent) {
 						e.preventDefault()
 						e.stopPropagation()
 
-						window.location.hash = ['#dkimlookup', encodeURIComponent(dkimSelector.value), encodeURIComponent(dkimDomain.value)].join('/')
-
-						const timer = showTimer(result, 15)
+						let exp: api.Expectations
 						try {
-							dkimFieldset.disabled = true
+							exp = JSON.parse(expectJSON.value)
+						} catch (err) {
+							window.alert('Error: parsing json: '+errmsg(err))
+							return
+						}
+						const timer = showTimer(result, 30)
+						try {
+							expectFieldset.disabled = true
 							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
-							const [status, record, txt, authentic] = await client.DKIMLookup(dkimSelector.value, dkimDomain.value)
+							const r = await client.DomainExpect(exp)
 							clearInterval(timer)
 							dom._kids(result,
 								dom.div(
 									dom._class('results'),
-									dom.h3('Results'),
-									dom.div(dom._class('row'),
-										dom.div(dom._class('result'),
-											group(
-												title('Status'),
-												dom.div(status),
-											),
-											group(
-												title('DNS TXT'),
-												dom.div(txt),
-												dnssecTag(authentic),
-											),
-											group(
+									expectationsResult(r),
+								),
+							)
+							result.scrollIntoView({block: 'nearest'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							clearInterval(timer)
+							expectFieldset.disabled = false
+						}
+					},
+					expectFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'Expectations as JSON',
+								dom.div(expectJSON=dom.textarea(attr.required(''), attr.rows('12'), style({width: '100%', fontFamily: 'monospace'}), JSON.stringify({Domain: 'example.org', DMARCPolicy: 'reject', SPFAll: '-all', MX: ['mx.example.org'], DANERequired: true, MTASTSMode: 'enforce', MTASTSMinMaxAge: 604800, TLSRPT: true, MinScore: 80, DKIM: [{Selector: 's1', KeyType: 'ed25519'}]}, undefined, '\t'))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Verify'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Checks a domain against expected state, e.g. for compliance. Fields that are absent are not checked. Use the "expect" subcommand to check files with expectations.'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Client configuration'),
+				clientconfigForm=dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						window.location.hash = ['#clientconfig', encodeURIComponent(clientconfigDomain.value)].join('/')
+
+						const timer = showTimer(result, 30)
+						try {
+							clientconfigFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+							const r = await client.ClientConfigCheck(clientconfigDomain.value)
+							clearInterval(timer)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									clientConfigResult(r),
+								),
+							)
+							result.scrollIntoView({block: 'nearest'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							clearInterval(timer)
+							clientconfigFieldset.disabled = false
+						}
+					},
+					clientconfigFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'Domain',
+								dom.div(clientconfigDomain=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Check'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Looks up SRV records for IMAP, POP3 and submission, fetches autoconfig and autodiscover configuration, compares them, and connects to each configured endpoint to verify TLS.'),
+			),
+
+			!meta?.Reflector ? [] : dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Reflector'),
+				dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						try {
+							reflectorFieldset.disabled = true
+							const [token, _] = await client.ReflectorStart()
+							window.location.hash = ['#reflector', encodeURIComponent(token)].join('/')
+							await reflectorShow(token)
+						} catch (err) {
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							reflectorFieldset.disabled = false
+						}
+					},
+					reflectorFieldset=dom.fieldset(
+						dom.div(
+							dom.submitbutton('Get address'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Get an address to send a message to. Shows how the message arrived: IP, EHLO, TLS, and the SPF, DKIM and DMARC results.'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Check SPF'),
+				spfForm=dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						window.location.hash = ['#spfcheck', encodeURIComponent(spfDomain.value), encodeURIComponent(spfIP.value)].join('/')
+
+						const timer = showTimer(result, 15)
+						try {
+							spfFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest'})
+							const [received, _, explanation, authentic] = await client.SPFCheck(spfDomain.value, spfIP.value)
+							clearInterval(timer)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'),
+											group(
+												title('Status'),
+												dom.div(received.Status),
+											),
+											group(
+												title('Mechanism'),
+												dom.div(received.Mechanism),
+											),
+											group(
+												title('Explanation'),
+												dom.div(explanation || '-'),
+											),
+											dnssecTag(authentic),
+										),
+									),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							clearInterval(timer)
+							spfFieldset.disabled = false
+						}
+					},
+					spfFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'Domain',
+								dom.div(spfDomain=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.label(
+								'IP',
+								dom.div(spfIP=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Check'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Evaluates the IP address against the SPF policy of the domain.'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Check DNSBL'),
+				dnsblForm=dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						window.location.hash = ['#dnsbl', encodeURIComponent(dnsblIP.value)].join('/')
+
+						const timer = showTimer(result, 15)
+						try {
+							dnsblFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest'})
+							const r = await client.DNSBLCheck(dnsblIP.value)
+							clearInterval(timer)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'),
+											group(
+												title('DNSBL'),
+												dnsblIPs([r]),
+											),
+										),
+									),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							clearInterval(timer)
+							dnsblFieldset.disabled = false
+						}
+					},
+					dnsblFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'IP',
+								dom.div(dnsblIP=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Check'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Looks up the IP address in DNS blocklists. Hover over a listing for the return codes and reason.'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Check reverse DNS'),
+				iprevForm=dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						window.location.hash = ['#iprev', encodeURIComponent(iprevIP.value)].join('/')
+
+						const timer = showTimer(result, 15)
+						try {
+							iprevFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest'})
+							const r = await client.IPRevCheck(iprevIP.value)
+							clearInterval(timer)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'),
+											group(
+												title('Reverse DNS', duration(r.DurationMS)),
+												iprevResult(r),
+												dnssecTag(r.Authentic),
+											),
+											group(
+												title('PTR names'),
+												(r.Names || []).length === 0 ? dom.div('-') : (r.Names || []).map(s => dom.div(verbatim(s))),
+											),
+										),
+									),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							clearInterval(timer)
+							iprevFieldset.disabled = false
+						}
+					},
+					iprevFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'IP',
+								dom.div(iprevIP=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Check'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Looks up the PTR records for the IP address, and checks if the names resolve back to the IP address (forward-confirmed reverse DNS, "iprev").'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Lookup DKIM record'),
+				dkimForm=dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						window.location.hash = ['#dkimlookup', encodeURIComponent(dkimSelector.value), encodeURIComponent(dkimDomain.value)].join('/')
+
+						const timer = showTimer(result, 15)
+						try {
+							dkimFieldset.disabled = true
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+							const [status, record, txt, authentic, audit] = await client.DKIMLookup(dkimSelector.value, dkimDomain.value)
+							clearInterval(timer)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'),
+											group(
+												title('Status'),
+												dom.div(status),
+											),
+											group(
+												title('DNS TXT'),
+												dom.div(txt),
+												dnssecTag(authentic),
+											),
+											group(
 												title('Record in parsed form'),
 												dom.div(record ? formatJSON(record) : '-'),
 											),
 										),
+										dom.div(dom._class('result'), style({maxWidth: '50em'}),
+											dom.h4('Audit', attr.title('Evaluation of the key strength and the fields of the record.')),
+											findingsList(audit),
+										),
 									),
 								),
 							)
@@ -610,11 +1511,35 @@ const init = async () => {
 						e.preventDefault()
 						e.stopPropagation()
 
+						const message = dkimverifyInput.message()
+						if (!message) {
+							window.alert('Paste a message or select a file.')
+							return
+						}
+
 						const timer = showTimer(result, 15)
 						try {
 							dkimverifyFieldset.disabled = true
 							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
-							const results = await client.DKIMVerify(dkimverifyMessage.value)
+							if (dkimverifyDebug.checked) {
+								const results = await client.DKIMDebug(message)
+								clearInterval(timer)
+								dom._kids(result,
+									dom.div(
+										dom._class('results'),
+										dom.h3('Results'),
+										dom.div(dom._class('row'),
+											(results || []).length === 0 ? dom.div(dom._class('result'), 'No DKIM signatures') : [],
+											(results || []).map(d => dkimDebugResult(d)),
+										),
+									),
+								)
+								result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+								return
+							}
+							const results = await client.DKIMVerify(message)
+							// Try to explain failed signatures.
+							const breakage = (results || []).some(r => r.Status === 'fail') ? await client.DKIMBreakage(message) : []
 							clearInterval(timer)
 							dom._kids(result,
 								dom.div(
@@ -622,7 +1547,7 @@ const init = async () => {
 									dom.h3('Results'),
 									dom.div(dom._class('row'),
 										(results || []).length === 0 ? dom.div(dom._class('result'), 'No DKIM signatures') : [],
-										(results || []).map(r =>
+										(results || []).map((r, i) =>
 											dom.div(dom._class('result'),
 												dom.h4('Signature'),
 												errorTag(r.Error),
@@ -639,6 +1564,7 @@ const init = async () => {
 													dom.div(r.Record ? formatJSON(r.Record) : '-'),
 													r.Record ? dnssecTag(r.RecordAuthentic) : [],
 												),
+												r.Status === 'fail' && breakage && breakage[i] ? dkimBreakageResult(breakage[i]) : [],
 											),
 										),
 									),
@@ -654,10 +1580,11 @@ const init = async () => {
 						}
 					},
 					dkimverifyFieldset=dom.fieldset(
+						dkimverifyInput.root,
 						dom.div(
 							dom.label(
-								'Message',
-								dom.div(dkimverifyMessage=dom.textarea(attr.rows('10'), attr.required(''))),
+								dkimverifyDebug=dom.input(attr.type('checkbox')),
+								' Debug: show canonicalized headers and body, and computed body hashes',
 							),
 						),
 						dom.div(
@@ -665,7 +1592,188 @@ const init = async () => {
 						),
 					),
 				),
-				dom.div(dom._class('explanation'), 'Parses the email message, finds all DKIM-Signature headers, and looks up their DKIM record and verifies their signature. Keep in mind that old messages can reference DKIM selectors that no longer exist in DNS and will not verify successfully anymore.'),
+				dom.div(dom._class('explanation'), 'Parses the email message, finds all DKIM-Signature headers, and looks up their DKIM record and verifies their signature. Keep in mind that old messages can reference DKIM selectors that no longer exist in DNS and will not verify successfully anymore. For failed signatures, changes commonly made by mailing lists and other intermediaries are reversed to find what broke the signature.'),
+			),
+
+			dom.div(dom._class('inputs'), style({flexGrow: '1', maxWidth: '80em'}),
+				dom.h2('Inspect MIME structure'),
+				dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						const message = mimeInput.message()
+						if (!message) {
+							window.alert('Paste a message or select a file.')
+							return
+						}
+						try {
+							mimeFieldset.disabled = true
+							const mi = await client.MIMEInspect(message)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'), style({flexGrow: '1'}),
+											dom.h4('Findings'),
+											(mi.Findings || []).length === 0 ? dom.div('No problems found.') : findingsList(mi.Findings),
+										),
+									),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'), style({flexGrow: '1'}),
+											dom.h4('Structure'),
+											dom.div(''+mi.Size+' bytes'),
+											mi.Part ? mimePartResult(mi.Part) : dom.div('Message header could not be parsed.'),
+										),
+									),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							mimeFieldset.disabled = false
+						}
+					},
+					mimeFieldset=dom.fieldset(
+						mimeInput.root,
+						dom.div(
+							dom.submitbutton('Inspect'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Parses the message and shows its MIME tree with content types, character sets, transfer encodings, sizes and filenames, and the headers with encoded-words (RFC 2047) decoded. Problems are flagged, such as missing boundaries, non-ASCII headers that require SMTPUTF8, bare newlines and lines longer than 998 characters.'),
+			),
+
+			dom.div(dom._class('inputs'), style({flexGrow: '1', maxWidth: '80em'}),
+				dom.h2('Parse bounce message'),
+				dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						const message = dsnInput.message()
+						if (!message) {
+							window.alert('Paste a message or select a file.')
+							return
+						}
+						try {
+							dsnFieldset.disabled = true
+							const d = await client.DSNParse(message)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'), dsnResult(d)),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							dsnFieldset.disabled = false
+						}
+					},
+					dsnFieldset=dom.fieldset(
+						dsnInput.root,
+						dom.div(
+							dom.submitbutton('Parse'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Parses a bounce message (delivery status notification, DSN) and shows the status per recipient, with an explanation of the enhanced status code, and the headers of the original message. Bounces without machine-readable delivery-status part (RFC 3464), e.g. from Exim, qmail and Yahoo, are parsed heuristically.'),
+			),
+
+			dom.div(dom._class('inputs'), style({flexGrow: '1', maxWidth: '80em'}),
+				dom.h2('Explain SMTP reply'),
+				dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						try {
+							smtpreplyFieldset.disabled = true
+							const r = await client.SMTPReplyExplain(smtpreplyText.value)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'), smtpReplyResult(r)),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							smtpreplyFieldset.disabled = false
+						}
+					},
+					smtpreplyFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'SMTP reply or error',
+								dom.div(smtpreplyText=dom.textarea(attr.required(''), attr.rows('4'), style({width: '100%', fontFamily: 'monospace'}), attr.placeholder('550 5.7.26 This mail has been blocked because the sender is unauthenticated.'))),
+							),
+						),
+						dom.div(
+							dom.submitbutton('Explain'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Breaks an SMTP reply, e.g. from a bounce or a delivery log, into its basic reply code, enhanced status code (class, subject and detail) and provider-specific codes from Google, Microsoft, Yahoo and DNS blocklists, and explains what it means and what to do. Errors from the mox SMTP client that include a reply are recognized too.'),
+			),
+
+			dom.div(dom._class('inputs'), style({width: '20em'}),
+				dom.h2('Address check'),
+				dom.form(
+					async function submit(e: SubmitEvent) {
+						e.preventDefault()
+						e.stopPropagation()
+
+						try {
+							addressFieldset.disabled = true
+							const r = await client.AddressCheck(addressAddress.value, addressProbe.checked)
+							dom._kids(result,
+								dom.div(
+									dom._class('results'),
+									dom.h3('Results'),
+									dom.div(dom._class('row'),
+										dom.div(dom._class('result'), style({flexGrow: '1'}),
+											dom.h4('Findings'),
+											(r.Findings || []).length === 0 ? dom.div('No problems found.') : findingsList(r.Findings),
+										),
+										addressCheckResult(r),
+									),
+								),
+							)
+							result.scrollIntoView({block: 'nearest', behavior: 'smooth'})
+						} catch (err) {
+							dom._kids(result)
+							window.alert('Error: '+errmsg(err))
+						} finally {
+							addressFieldset.disabled = false
+						}
+					},
+					addressFieldset=dom.fieldset(
+						dom.div(
+							dom.label(
+								'Address',
+								dom.div(addressAddress=dom.input(attr.required(''))),
+							),
+						),
+						dom.div(
+							dom.label(addressProbe=dom.input(attr.type('checkbox')), ' Probe with RCPT TO at the mail server'),
+						),
+						dom.div(
+							dom.submitbutton('Check'),
+						),
+					),
+				),
+				dom.div(dom._class('explanation'), 'Parses the email address, including quoted localparts, internationalized domains and non-ASCII localparts (SMTPUTF8), and explains syntax problems. Looks up the mail servers for the domain, with null MX and implicit MX. Optionally connects to the mail server and checks if the address is accepted, rejected, or if the server accepts all addresses, without delivering a message. Probing must be enabled on the instance.'),
 			),
 		),
 		result=dom.div(),
@@ -684,10 +1792,21 @@ const init = async () => {
 			spfDomain.value = t[1]
 			spfIP.value = t[2]
 			spfForm.requestSubmit()
+		} else if (t[0] === 'dnsbl' && t.length === 2) {
+			dnsblIP.value = t[1]
+			dnsblForm.requestSubmit()
+		} else if (t[0] === 'iprev' && t.length === 2) {
+			iprevIP.value = t[1]
+			iprevForm.requestSubmit()
 		} else if (t[0] === 'dkimlookup' && t.length === 3) {
 			dkimSelector.value = t[1]
 			dkimDomain.value = t[2]
 			dkimForm.requestSubmit()
+		} else if (t[0] === 'clientconfig' && t.length === 2) {
+			clientconfigDomain.value = t[1]
+			clientconfigForm.requestSubmit()
+		} else if (t[0] === 'reflector' && t.length === 2) {
+			await reflectorShow(t[1])
 		} else {
 			window.location.hash = ''
 		}
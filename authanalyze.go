@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjl-/adns"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dmarc"
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/smtp"
+	"github.com/mjl-/mox/spf"
+)
+
+// AuthAnalysis is the evaluation of DKIM, SPF and DMARC for a stored message,
+// as a receiving mail server would have done it.
+type AuthAnalysis struct {
+	File         string
+	Message      int // In file, starting at 1.
+	MessageID    string
+	FromDomain   string
+	DKIMDomains  []string // Of each signature, empty for an unparsable signature.
+	DKIMStatuses []string
+	SPFIP        string // From Received header, empty if not found.
+	SPFDomain    string // Mail from or ehlo domain that was evaluated.
+	SPFStatus    string
+	DMARCStatus  string
+	DMARCPolicy  string
+	Reasons      []string // Why checks did not pass.
+}
+
+var authAnalysisHeader = []string{"file", "message", "messageid", "fromdomain", "dkimdomains", "dkimstatuses", "spfip", "spfdomain", "spfstatus", "dmarcstatus", "dmarcpolicy", "reasons"}
+
+func (a AuthAnalysis) csvRecord() []string {
+	return []string{a.File, strconv.Itoa(a.Message), a.MessageID, a.FromDomain, strings.Join(a.DKIMDomains, " "), strings.Join(a.DKIMStatuses, " "), a.SPFIP, a.SPFDomain, a.SPFStatus, a.DMARCStatus, a.DMARCPolicy, strings.Join(a.Reasons, "; ")}
+}
+
+// txtCacheResolver caches TXT lookups. Messages in a mailbox are mostly from a
+// small set of domains, and signed with a few selectors. DKIM, SPF and DMARC
+// records are all TXT records.
+type txtCacheResolver struct {
+	dns.Resolver
+
+	sync.Mutex
+	txt map[string]*txtLookup
+}
+
+type txtLookup struct {
+	done   chan struct{}
+	l      []string
+	result adns.Result
+	err    error
+}
+
+func (r *txtCacheResolver) LookupTXT(ctx context.Context, name string) ([]string, adns.Result, error) {
+	r.Lock()
+	t, ok := r.txt[name]
+	if !ok {
+		t = &txtLookup{done: make(chan struct{})}
+		r.txt[name] = t
+	}
+	r.Unlock()
+
+	if ok {
+		select {
+		case <-t.done:
+			return t.l, t.result, t.err
+		case <-ctx.Done():
+			return nil, adns.Result{}, ctx.Err()
+		}
+	}
+
+	t.l, t.result, t.err = r.Resolver.LookupTXT(ctx, name)
+	if errors.Is(t.err, context.Canceled) || errors.Is(t.err, context.DeadlineExceeded) {
+		// Not cached, a later message can try again.
+		r.Lock()
+		delete(r.txt, name)
+		r.Unlock()
+	}
+	close(t.done)
+	return t.l, t.result, t.err
+}
+
+// authMessage is a message read from an mbox file, a Maildir or a message file.
+type authMessage struct {
+	file  string
+	index int
+	msg   []byte
+}
+
+// readAuthMessages reads messages from mbox or message files, and Maildir
+// directories, with files from "cur" and "new".
+func readAuthMessages(paths []string) ([]authMessage, error) {
+	var l []authMessage
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			msgs, err := readMessages(p)
+			if err != nil {
+				return nil, err
+			}
+			for i, msg := range msgs {
+				l = append(l, authMessage{p, i + 1, msg})
+			}
+			continue
+		}
+
+		var files []string
+		for _, sub := range []string{"cur", "new"} {
+			entries, err := os.ReadDir(filepath.Join(p, sub))
+			if err != nil && errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+					files = append(files, filepath.Join(p, sub, e.Name()))
+				}
+			}
+		}
+		if files == nil {
+			return nil, fmt.Errorf("%s: not a maildir, no messages in new or cur", p)
+		}
+		for _, f := range files {
+			buf, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, authMessage{f, 1, messageCRLF(buf)})
+		}
+	}
+	return l, nil
+}
+
+// receivedSender returns the ip and ehlo from the first Received header from the
+// top with a public IP address in its "from" clause. It was added by the mail
+// server at the boundary of the receiving organization, headers above it are from
+// internal hops, e.g. to a content filter.
+func receivedSender(received []string) (net.IP, dns.IPDomain) {
+	for _, v := range received {
+		v = strings.Join(strings.Fields(v), " ")
+		from, ok := strings.CutPrefix(v, "from ")
+		if !ok {
+			continue
+		}
+		from, _, _ = strings.Cut(from, " by ")
+
+		var ip net.IP
+		for s := from; ip == nil; {
+			var t string
+			var ok bool
+			if _, s, ok = strings.Cut(s, "["); !ok {
+				break
+			}
+			t, s, _ = strings.Cut(s, "]")
+			ip = net.ParseIP(strings.TrimPrefix(t, "IPv6:"))
+		}
+		if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			continue
+		}
+
+		var hello dns.IPDomain
+		ehlo, _, _ := strings.Cut(from, " ")
+		if s, ok := strings.CutPrefix(ehlo, "["); ok && strings.HasSuffix(s, "]") {
+			hello.IP = net.ParseIP(strings.TrimPrefix(strings.TrimSuffix(s, "]"), "IPv6:"))
+		} else if d, err := dns.ParseDomain(ehlo); err == nil {
+			hello.Domain = d
+		}
+		return ip, hello
+	}
+	return nil, dns.IPDomain{}
+}
+
+// authAnalyze evaluates DKIM, SPF and DMARC for a message. SPF is evaluated
+// for the IP in the Received headers and the Return-Path address.
+func authAnalyze(ctx context.Context, log mlog.Log, resolver dns.Resolver, m authMessage) (a AuthAnalysis) {
+	a = AuthAnalysis{File: m.file, Message: m.index, DKIMDomains: []string{}, DKIMStatuses: []string{}, Reasons: []string{}}
+	reason := func(format string, args ...any) {
+		a.Reasons = append(a.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	p, err := message.Parse(log.Logger, false, bytes.NewReader(m.msg))
+	if err != nil {
+		reason("parsing message: %v", err)
+		return
+	}
+	h, err := p.Header()
+	if err != nil {
+		reason("parsing message header: %v", err)
+		return
+	}
+	a.MessageID = h.Get("Message-Id")
+
+	dkimResults0, err := dkim.Verify(ctx, log.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(m.msg), false)
+	if err != nil {
+		reason("verifying dkim: %v", err)
+	}
+	for _, r := range dkimResults0 {
+		var d string
+		if r.Sig != nil {
+			d = r.Sig.Domain.Name()
+		}
+		a.DKIMDomains = append(a.DKIMDomains, d)
+		a.DKIMStatuses = append(a.DKIMStatuses, string(r.Status))
+		if r.Status != dkim.StatusPass {
+			s := string(r.Status)
+			if r.Err != nil {
+				s = strings.TrimPrefix(r.Err.Error(), "dkim: ")
+			}
+			reason("dkim %s: %s", d, s)
+		}
+	}
+	if len(dkimResults0) == 0 {
+		reason("dkim: no signatures")
+	}
+
+	var spfStatus spf.Status
+	var spfIdentity *dns.Domain
+	ip, hello := receivedSender(h.Values("Received"))
+	if ip == nil {
+		reason("spf: no received header with public ip address")
+	} else {
+		a.SPFIP = ip.String()
+		args := spf.Args{RemoteIP: ip, HelloDomain: hello}
+		rp := strings.TrimSpace(h.Get("Return-Path"))
+		rp = strings.TrimSuffix(strings.TrimPrefix(rp, "<"), ">")
+		if rp != "" {
+			if addr, err := smtp.ParseAddress(rp); err != nil {
+				reason("spf: parsing return-path address: %v", err)
+			} else {
+				args.MailFromLocalpart = addr.Localpart
+				args.MailFromDomain = addr.Domain
+			}
+		}
+		received, dom, explanation, _, err := spf.Verify(ctx, log.Logger, resolver, args)
+		a.SPFDomain = dom.Name()
+		spfStatus = received.Result
+		a.SPFStatus = string(spfStatus)
+		// Only the mail from domain is used for DMARC alignment.
+		if received.Identity == spf.ReceivedMailFrom {
+			spfIdentity = &dom
+		}
+		if spfStatus != spf.StatusPass {
+			s := fmt.Sprintf("spf %s: %s", a.SPFDomain, spfStatus)
+			if err != nil {
+				s += ": " + err.Error()
+			} else if explanation != "" {
+				s += ": " + explanation
+			}
+			reason("%s", s)
+		}
+	}
+
+	from, _, _, err := message.From(log.Logger, false, nil, &p)
+	if err != nil {
+		reason("dmarc: parsing from address: %v", err)
+		return
+	}
+	a.FromDomain = from.Domain.Name()
+	_, result := dmarc.Verify(ctx, log.Logger, resolver, from.Domain, dkimResults0, spfStatus, spfIdentity, false)
+	a.DMARCStatus = string(result.Status)
+	if result.Record != nil {
+		a.DMARCPolicy = string(result.Record.Policy)
+	}
+	if result.Err != nil {
+		reason("dmarc: %v", result.Err)
+	} else if result.Status == dmarc.StatusFail {
+		var l []string
+		for i, r := range dkimResults0 {
+			if r.Status == dkim.StatusPass && !slices.Contains(l, a.DKIMDomains[i]) {
+				l = append(l, a.DKIMDomains[i])
+			}
+		}
+		if spfStatus == spf.StatusPass && spfIdentity != nil {
+			l = append(l, "spf "+spfIdentity.Name())
+		}
+		if len(l) == 0 {
+			reason("dmarc: no passing dkim signature or spf")
+		} else {
+			reason("dmarc: passing domains (%s) not aligned with from domain %s", strings.Join(l, ", "), a.FromDomain)
+		}
+	}
+	return
+}
+
+func cmdAuthanalyze(c *cmd) {
+	var concurrency int
+	c.flag.IntVar(&concurrency, "concurrency", 10, "number of messages to analyze at the same time")
+	args := c.Parse()
+	if len(args) == 0 || concurrency <= 0 {
+		c.Usage()
+	}
+
+	msgs, err := readAuthMessages(args)
+	xcmdcheck(err, "reading messages")
+
+	r := &txtCacheResolver{Resolver: resolver, txt: map[string]*txtLookup{}}
+	results := make([]AuthAnalysis, len(msgs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, m := range msgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer logPanic(pkglog)
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			results[i] = authAnalyze(ctx, pkglog, r, m)
+		}()
+	}
+	wg.Wait()
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write(authAnalysisHeader)
+	for _, a := range results {
+		w.Write(a.csvRecord())
+	}
+	w.Flush()
+	xcmdcheck(w.Error(), "write csv")
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+)
+
+// Maximum number of domains in a batch through the API, keeping the request
+// within a few minutes. The command line has no limit.
+const batchMax = 20
+
+// DomainSummary has the key statuses of a domain check, for a table with one row
+// per domain.
+type DomainSummary struct {
+	Domain   string
+	Error    string // E.g. invalid domain. Other fields are empty.
+	Grade    string
+	Score    int
+	SPF      string // "none" if no record, "softfail" for ~all, "permissive" for +all, etc.
+	DMARC    string // Policy, or "none" if no record.
+	MTASTS   string // Mode, or "none" if no record.
+	TLSRPT   bool
+	DNSSEC   bool // For the MX records.
+	DANE     bool // All MX hosts have DANE.
+	STARTTLS bool // All dialed MX hosts support STARTTLS, false if none could be dialed.
+	MXHosts  int
+	Findings int // Number of errors and warnings.
+}
+
+type DomainBatchResult struct {
+	DurationMS int
+	Summaries  []DomainSummary
+	Results    []*DomainResult // Full results for drill-down, nil if the domain was not checked.
+}
+
+func (API) DomainCheckBatch(ctx context.Context, domains []string) DomainBatchResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	if len(domains) > batchMax {
+		xcheckuser(fmt.Errorf("%d domains, maximum is %d, use the domaincheckbatch subcommand for larger batches", len(domains), batchMax), "checking domains")
+	}
+	// Each domain counts against the domain rate limiter, charged for the whole
+	// batch at once.
+	if limitedN(ctx, &apiDomainLimiter, int64(len(domains))) {
+		xcheckuser(errors.New("too many domains for rate limit, try fewer domains or again soon, or use the domaincheckbatch subcommand"), "rate limiter")
+	}
+
+	log.Debug("domaincheckbatch call", slog.Int("domains", len(domains)))
+
+	return domainCheckBatch(ctx, log, domains, 5)
+}
+
+// domainCheckBatch checks domains with at most concurrency checks at a time.
+// Dialing MX hosts goes through the SMTP dial rate limiter like a regular domain
+// check.
+func domainCheckBatch(ctx context.Context, log mlog.Log, domains []string, concurrency int) (br DomainBatchResult) {
+	start := time.Now()
+	br.Summaries = make([]DomainSummary, len(domains))
+	br.Results = make([]*DomainResult, len(domains))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		br.Summaries[i].Domain = domain
+
+		dom, err := dns.ParseDomain(domain)
+		if err != nil {
+			br.Summaries[i].Error = fmt.Sprintf("parsing domain: %v", err)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			dr := domainCheck(ctx, log, dom, false)
+			br.Results[i] = &dr
+			br.Summaries[i] = domainSummary(domain, dr)
+		}()
+	}
+	wg.Wait()
+	br.DurationMS = timeSince(start)
+	return
+}
+
+func domainSummary(domain string, dr DomainResult) DomainSummary {
+	s := DomainSummary{
+		Domain:  domain,
+		Grade:   dr.Grade.Grade,
+		Score:   dr.Grade.Score,
+		SPF:     "none",
+		DMARC:   "none",
+		MTASTS:  "none",
+		TLSRPT:  dr.TLSRPT.Record != nil,
+		DNSSEC:  dr.MX.OrigNextHopAuthentic,
+		DANE:    len(dr.MXHosts) > 0,
+		MXHosts: len(dr.MXHosts),
+	}
+	if dr.SPF.Record != nil {
+		// Without all mechanism, the result is neutral, or from the redirect.
+		s.SPF = "neutral"
+		if dr.SPF.Record.Redirect != "" {
+			s.SPF = "redirect"
+		}
+		for _, d := range dr.SPF.Record.Directives {
+			if d.Mechanism != "all" {
+				continue
+			}
+			switch d.Qualifier {
+			case "", "+":
+				// Any IP passes, no protection.
+				s.SPF = "permissive"
+			case "-":
+				s.SPF = "fail"
+			case "~":
+				s.SPF = "softfail"
+			case "?":
+				s.SPF = "neutral"
+			}
+		}
+	} else if dr.SPF.Error != "" && dr.SPF.Status != "none" {
+		s.SPF = dr.SPF.Status
+	}
+	if dr.DMARC.Record != nil {
+		s.DMARC = string(dr.DMARC.Record.Policy)
+	} else if dr.DMARC.Status != "none" {
+		s.DMARC = dr.DMARC.Status
+	}
+	if dr.MTASTS.Policy != nil {
+		s.MTASTS = string(dr.MTASTS.Policy.Mode)
+	} else if dr.MTASTS.Implemented {
+		s.MTASTS = "error"
+	}
+	var dialed int
+	starttls := true
+	for _, mx := range dr.MXHosts {
+		s.DANE = s.DANE && mx.DANE.Required
+		if mx.Dial.IP != nil || mx.Dial.Error != "" {
+			dialed++
+			starttls = starttls && mx.Dial.Error == "" && mx.SMTP.Error == "" && mx.SMTP.SupportsSTARTTLS
+		}
+	}
+	s.STARTTLS = dialed > 0 && starttls
+	for _, f := range dr.Grade.Findings {
+		if f.Severity != "info" {
+			s.Findings++
+		}
+	}
+	return s
+}
+
+var domainSummaryHeader = []string{"domain", "error", "grade", "score", "spf", "dmarc", "mtasts", "tlsrpt", "dnssec", "dane", "starttls", "mxhosts", "findings"}
+
+func (s DomainSummary) csvRecord() []string {
+	return []string{s.Domain, s.Error, s.Grade, strconv.Itoa(s.Score), s.SPF, s.DMARC, s.MTASTS, strconv.FormatBool(s.TLSRPT), strconv.FormatBool(s.DNSSEC), strconv.FormatBool(s.DANE), strconv.FormatBool(s.STARTTLS), strconv.Itoa(s.MXHosts), strconv.Itoa(s.Findings)}
+}
+
+func cmdDomaincheckbatch(c *cmd) {
+	var concurrency int
+	var csvOutput bool
+	var resultsFile string
+	c.flag.IntVar(&concurrency, "concurrency", 5, "number of domains to check at the same time")
+	c.flag.BoolVar(&csvOutput, "csv", false, "print summary as csv instead of json with summaries and full results")
+	c.flag.StringVar(&resultsFile, "results", "", "with -csv, file to write json with full results to")
+	args := c.Parse()
+	if len(args) != 1 || concurrency <= 0 || resultsFile != "" && !csvOutput {
+		c.Usage()
+	}
+
+	// One domain per line, empty lines and lines starting with # are skipped.
+	f := os.Stdin
+	if args[0] != "-" {
+		var err error
+		f, err = os.Open(args[0])
+		xcmdcheck(err, "open domains file")
+		defer f.Close()
+	}
+	var domains []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s := strings.TrimSpace(scanner.Text())
+		if s != "" && !strings.HasPrefix(s, "#") {
+			domains = append(domains, s)
+		}
+	}
+	xcmdcheck(scanner.Err(), "reading domains")
+	if len(domains) == 0 {
+		xcmdcheck(errors.New("no domains"), "reading domains")
+	}
+
+	br := domainCheckBatch(context.Background(), pkglog, domains, concurrency)
+
+	writeJSON := func(f *os.File) {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "\t")
+		err := enc.Encode(br)
+		xcmdcheck(err, "write results")
+	}
+	if !csvOutput {
+		writeJSON(os.Stdout)
+		return
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write(domainSummaryHeader)
+	for _, s := range br.Summaries {
+		w.Write(s.csvRecord())
+	}
+	w.Flush()
+	xcmdcheck(w.Error(), "write csv")
+
+	if resultsFile != "" {
+		rf, err := os.Create(resultsFile)
+		xcmdcheck(err, "create results file")
+		writeJSON(rf)
+		err = rf.Close()
+		xcmdcheck(err, "close results file")
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mtasts"
+)
+
+// With offline, set by the ci subcommand when checking zone files, MX hosts are
+// not connected to, and no reverse DNS lookups are done.
+var offline bool
+
+// CIPolicy is read from a JSON file, and configures which checks fail a CI run.
+// Patterns match check IDs, with "*" matching any text, e.g. "mx[*].starttls".
+type CIPolicy struct {
+	Severities []string // Findings with these severities are failures. Default "error".
+	Fail       []string // Checks that are failures regardless of severity.
+	Ignore     []string // Checks that are never failures. Takes precedence over Fail.
+}
+
+// CICheck is the outcome of a single check, a testcase in JUnit output.
+type CICheck struct {
+	ID       string
+	Status   string // "pass", "fail" or "skip".
+	Skip     string // Reason for skip.
+	Failure  bool   // Whether a failed check fails the run, according to policy.
+	Findings []Finding
+}
+
+func ciMatch(pattern, id string) bool {
+	re := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	return regexp.MustCompile(re).MatchString(id)
+}
+
+func (p CIPolicy) failure(c CICheck) bool {
+	match := func(l []string) bool {
+		return slices.ContainsFunc(l, func(pat string) bool { return ciMatch(pat, c.ID) })
+	}
+	if c.Status != "fail" || match(p.Ignore) {
+		return false
+	}
+	if match(p.Fail) {
+		return true
+	}
+	severities := p.Severities
+	if severities == nil {
+		severities = []string{"error"}
+	}
+	return slices.ContainsFunc(c.Findings, func(f Finding) bool { return slices.Contains(severities, f.Severity) })
+}
+
+// ciChecks returns all checks for the domain, passing unless the grade has
+// findings for them. Checks that could not be done are skipped.
+func ciChecks(dr DomainResult, policy CIPolicy) []CICheck {
+	var l []CICheck
+	add := func(id string, skip string) {
+		l = append(l, CICheck{ID: id, Skip: skip})
+	}
+	when := func(ok bool, reason string) string {
+		if ok {
+			return ""
+		}
+		return reason
+	}
+
+	add("spf.record-valid", "")
+	add("spf.all", when(dr.SPF.Record != nil, "no spf record"))
+	add("spf.lookup-limit", when(dr.SPF.Record != nil, "no spf record"))
+	add("spf.includes-valid", when(dr.SPF.Record != nil, "no spf record"))
+	add("dmarc.record-valid", "")
+	add("dmarc.policy", when(dr.DMARC.Record != nil, "no dmarc record"))
+	add("dmarc.pct", when(dr.DMARC.Record != nil, "no dmarc record"))
+	add("dmarc.rua", when(dr.DMARC.Record != nil, "no dmarc record"))
+	add("mtasts.record-valid", "")
+	add("mtasts.mode", when(dr.MTASTS.Policy != nil, "no mta-sts policy"))
+	add("tlsrpt.record-valid", "")
+	add("mx.lookup", "")
+	add("mx.dnssec", when(dr.MX.Error == "", "no mx records"))
+	for i, mx := range dr.MXHosts {
+		id := func(s string) string {
+			return fmt.Sprintf("mx[%d].%s", i, s)
+		}
+		// With all IPs checked, the connections are in IPResults instead of Dial.
+		connected := when(mx.Dial.IP != nil || mx.Dial.Error != "" || slices.ContainsFunc(mx.IPResults, func(r DomainMXIP) bool { return r.Dial.IP != nil || r.Dial.Error != "" }), "not connected")
+		add(id("mtasts-match"), when(dr.MTASTS.Policy != nil, "no mta-sts policy"))
+		add(id("dane"), when(dr.MX.OrigNextHopAuthentic && mx.IP.Authentic, "no dnssec"))
+		daneSkip := when(mx.DANE.Required, "no dane")
+		if daneSkip == "" {
+			daneSkip = connected
+		}
+		add(id("dane-verified"), daneSkip)
+		add(id("dnsbl"), when(len(mx.DNSBL) > 0 && len(dnsblZones) > 0, "no dnsbl lookups"))
+		add(id("iprev"), when(len(mx.IPRev) > 0, "no reverse dns lookups"))
+		add(id("dial"), connected)
+		add(id("smtp"), connected)
+		add(id("starttls"), connected)
+		add(id("tls-version"), connected)
+		add(id("ehlo"), connected)
+		add(id("parity"), when(mx.Parity != nil, "not dual-stack or not connected"))
+	}
+
+	for _, f := range dr.Grade.Findings {
+		i := slices.IndexFunc(l, func(c CICheck) bool { return c.ID == f.Check })
+		if i < 0 {
+			l = append(l, CICheck{ID: f.Check})
+			i = len(l) - 1
+		}
+		l[i].Findings = append(l[i].Findings, f)
+	}
+	for i := range l {
+		c := &l[i]
+		if len(c.Findings) > 0 {
+			c.Status = "fail"
+			c.Skip = ""
+		} else if c.Skip != "" {
+			c.Status = "skip"
+		} else {
+			c.Status = "pass"
+		}
+		c.Failure = policy.failure(*c)
+	}
+	return l
+}
+
+func ciFindingsText(c CICheck) string {
+	var l []string
+	for _, f := range c.Findings {
+		s := f.Severity + ": " + f.Title + "\n" + f.Explanation
+		if f.Remediation != "" {
+			s += "\nRemediation: " + f.Remediation
+		}
+		l = append(l, s)
+	}
+	return strings.Join(l, "\n\n")
+}
+
+type junitTestsuites struct {
+	XMLName   xml.Name         `xml:"testsuites"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Testsuite []junitTestsuite `xml:"testsuite"`
+}
+
+type junitTestsuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Testcase []junitTestcase `xml:"testcase"`
+}
+
+type junitTestcase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// ciJUnit writes a testsuite per domain, with a testcase per check. Failed checks
+// that are not failures according to the policy pass, with the findings as output.
+func ciJUnit(w io.Writer, drs []DomainResult, checks [][]CICheck) error {
+	suites := junitTestsuites{Name: "moxtools"}
+	for i, dr := range drs {
+		suite := junitTestsuite{Name: dr.Domain.Name(), Time: fmt.Sprintf("%.3f", float64(dr.DurationMS)/1000)}
+		for _, c := range checks[i] {
+			tc := junitTestcase{Classname: dr.Domain.Name(), Name: c.ID}
+			switch {
+			case c.Failure:
+				tc.Failure = &junitMessage{c.Findings[0].Title, c.Findings[0].Severity, ciFindingsText(c)}
+				suite.Failures++
+			case c.Status == "fail":
+				tc.SystemOut = ciFindingsText(c)
+			case c.Status == "skip":
+				tc.Skipped = &junitMessage{Message: c.Skip}
+				suite.Skipped++
+			}
+			suite.Testcase = append(suite.Testcase, tc)
+		}
+		suite.Tests = len(suite.Testcase)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Testsuite = append(suites.Testsuite, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ciSARIF writes SARIF 2.1.0, with a result for each finding. Rules are check
+// IDs without the MX host index, e.g. "mx.starttls".
+func ciSARIF(w io.Writer, drs []DomainResult, checks [][]CICheck, zoneFiles []string) error {
+	type text struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string `json:"id"`
+		ShortDescription text   `json:"shortDescription"`
+	}
+	type artifactLocation struct {
+		URI string `json:"uri"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation artifactLocation `json:"artifactLocation"`
+	}
+	type logicalLocation struct {
+		Name               string `json:"name"`
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+	type location struct {
+		PhysicalLocation *physicalLocation `json:"physicalLocation,omitempty"`
+		LogicalLocations []logicalLocation `json:"logicalLocations"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   text       `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	var rules []rule
+	results := []result{}
+	index := regexp.MustCompile(`\[[0-9]+\]`)
+	for i, dr := range drs {
+		for _, c := range checks[i] {
+			if c.Status != "fail" {
+				continue
+			}
+			ruleID := index.ReplaceAllString(c.ID, "")
+			for _, f := range c.Findings {
+				if !slices.ContainsFunc(rules, func(r rule) bool { return r.ID == ruleID }) {
+					rules = append(rules, rule{ruleID, text{f.Title}})
+				}
+				level := "note"
+				if c.Failure {
+					level = "error"
+				} else if f.Severity != "info" {
+					level = "warning"
+				}
+				msg := f.Title + ": " + f.Explanation
+				if f.Remediation != "" {
+					msg += " Remediation: " + f.Remediation
+				}
+				loc := location{LogicalLocations: []logicalLocation{{c.ID, dr.Domain.Name() + "/" + c.ID, "member"}}}
+				if len(zoneFiles) > 0 {
+					loc.PhysicalLocation = &physicalLocation{artifactLocation{zoneFiles[0]}}
+				}
+				results = append(results, result{ruleID, level, text{msg}, []location{loc}})
+			}
+		}
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{
+			map[string]any{
+				"tool": map[string]any{
+					"driver": map[string]any{
+						"name":           "moxtools",
+						"informationUri": "https://github.com/mjl-/moxtools",
+						"version":        version,
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(log)
+}
+
+// policyTransport serves an MTA-STS policy from a file when checking zone files.
+type policyTransport string
+
+func (t policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t == "" {
+		return nil, errors.New("offline, no mta-sts policy file specified")
+	}
+	f, err := os.Open(string(t))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{"Content-Type": {"text/plain"}}, Body: f, Request: req}, nil
+}
+
+func cmdCI(c *cmd) {
+	var all, dnssec bool
+	var format, policyFile, mtastsPolicy string
+	var zoneFiles []string
+	c.flag.BoolVar(&all, "all", false, "connect to all mx hosts, and to each of their ips separately")
+	c.flag.StringVar(&format, "format", "junit", "output format: junit or sarif")
+	c.flag.StringVar(&policyFile, "policy", "", "json file with policy for which checks are failures, with fields Severities, Fail and Ignore")
+	c.flag.Func("zone", "zone file to use instead of dns, can be repeated; no connections are made to mx hosts", func(s string) error {
+		zoneFiles = append(zoneFiles, s)
+		return nil
+	})
+	c.flag.BoolVar(&dnssec, "dnssec", false, "with -zone, treat zone data as dnssec-signed")
+	c.flag.StringVar(&mtastsPolicy, "mtasts-policy", "", "with -zone, file with mta-sts policy to use instead of fetching it")
+	args := c.Parse()
+	if len(args) == 0 || format != "junit" && format != "sarif" || len(zoneFiles) == 0 && (dnssec || mtastsPolicy != "") {
+		c.Usage()
+	}
+
+	var policy CIPolicy
+	if policyFile != "" {
+		f, err := os.Open(policyFile)
+		xcmdcheck(err, "open policy file")
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&policy)
+		xcmdcheck(err, "parsing policy file")
+		f.Close()
+	}
+
+	var domains []dns.Domain
+	for _, s := range args {
+		dom, err := dns.ParseDomain(s)
+		xcmdcheck(err, "parsing domain")
+		domains = append(domains, dom)
+	}
+
+	if len(zoneFiles) > 0 {
+		r, err := zoneResolver(zoneFiles, domains[0], dnssec)
+		xcmdcheck(err, "parsing zone files")
+		resolver = r
+		offline = true
+		dnsblZones = nil
+		mtasts.HTTPClient.Transport = policyTransport(mtastsPolicy)
+	}
+
+	var drs []DomainResult
+	var checks [][]CICheck
+	failed := false
+	for _, dom := range domains {
+		dr := domainCheck(context.Background(), pkglog, dom, all)
+		l := ciChecks(dr, policy)
+		failed = failed || slices.ContainsFunc(l, func(c CICheck) bool { return c.Failure })
+		drs = append(drs, dr)
+		checks = append(checks, l)
+	}
+
+	var err error
+	if format == "junit" {
+		err = ciJUnit(os.Stdout, drs, checks)
+	} else {
+		err = ciSARIF(os.Stdout, drs, checks, zoneFiles)
+	}
+	xcmdcheck(err, "write output")
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,644 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+)
+
+// Client configuration, for email clients (MUAs) to find the IMAP/POP3 and
+// submission servers of a domain. Can be through SRV records (RFC 6186, RFC 8314),
+// Thunderbird autoconfig and Microsoft autodiscover.
+
+type SRVRecord struct {
+	Target   string
+	Port     int
+	Priority int
+	Weight   int
+}
+
+type ClientConfigSRV struct {
+	DurationMS int
+	Service    string // E.g. "_submissions._tcp".
+	Protocol   string // "imap", "pop3" or "submission".
+	Security   string // "tls" for immediate TLS, "starttls" otherwise.
+	Records    []SRVRecord
+	Authentic  bool
+	Error      string
+}
+
+type ClientConfigServer struct {
+	Protocol       string // "imap", "pop3" or "submission".
+	Host           string
+	Port           int
+	Security       string // "tls", "starttls" or "plain".
+	Username       string
+	Authentication string
+}
+
+type ClientConfigAutoconfig struct {
+	DurationMS int
+	URL        string
+	Servers    []ClientConfigServer
+	XML        string
+	Error      string
+}
+
+type ClientConfigAutodiscover struct {
+	DurationMS int
+	URL        string
+	Servers    []ClientConfigServer
+	XML        string
+	Error      string
+}
+
+type ClientConfigEndpoint struct {
+	DurationMS         int
+	Protocol           string
+	Host               string
+	Port               int
+	Security           string
+	Sources            []string // "srv", "autoconfig" and/or "autodiscover".
+	IP                 net.IP
+	Greeting           string
+	TLSConnectionState *TLSConnectionState
+	Error              string
+}
+
+type ClientConfigResult struct {
+	DurationMS   int
+	Domain       dns.Domain
+	SRV          []ClientConfigSRV
+	Autoconfig   ClientConfigAutoconfig
+	Autodiscover ClientConfigAutodiscover
+	Endpoints    []ClientConfigEndpoint
+	Mismatches   []string
+}
+
+var clientConfigSRVs = []struct {
+	service  string
+	protocol string
+	security string
+}{
+	{"_submissions", "submission", "tls"},
+	{"_submission", "submission", "starttls"},
+	{"_imaps", "imap", "tls"},
+	{"_imap", "imap", "starttls"},
+	{"_pop3s", "pop3", "tls"},
+	{"_pop3", "pop3", "starttls"},
+}
+
+// Endpoints come from DNS and XML controlled by the owner of the checked domain.
+// We only connect to public IPs, at mail ports, and for HTTP at the default
+// ports, so we can't be used to reach internal services.
+var clientConfigPorts = []int{25, 465, 587, 143, 993, 110, 995}
+
+var clientConfigHTTPClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		// No proxy, publicDialControl must see the IP of the endpoint.
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: publicDialControl}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("too many redirects")
+		}
+		return clientConfigCheckURL(req.URL)
+	},
+}
+
+// clientConfigCheckURL checks that u is http or https at the default port.
+func clientConfigCheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme %q", u.Scheme)
+	}
+	if p := u.Port(); p != "" && !(u.Scheme == "http" && p == "80" || u.Scheme == "https" && p == "443") {
+		return fmt.Errorf("redirect to non-default port %s", p)
+	}
+	return nil
+}
+
+// publicDialControl refuses connections to IPs that are not public, e.g.
+// loopback, private and link-local addresses.
+func publicDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !publicIP(ip) {
+		return fmt.Errorf("refusing connection to non-public ip %s", host)
+	}
+	return nil
+}
+
+// Special-purpose IPv4 ranges not covered by the net.IP methods: "this network"
+// (RFC 1122), shared address space for carrier-grade NAT (RFC 6598) and
+// benchmarking (RFC 2544).
+var nonPublicNets = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	{IP: net.IPv4(198, 18, 0, 0), Mask: net.CIDRMask(15, 32)},
+}
+
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (API) ClientConfigCheck(ctx context.Context, domain string) (r ClientConfigResult) {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	xlimit(ctx, &apiDomainLimiter)
+
+	log.Debug("clientconfigcheck call", slog.String("domain", domain))
+
+	dom, err := dns.ParseDomain(domain)
+	xcheckuser(err, "parsing domain")
+
+	start := time.Now()
+	r.Domain = dom
+
+	opctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// We need an address for autoconfig/autodiscover requests. Postmaster must
+	// exist for every mail domain.
+	address := "postmaster@" + dom.ASCII
+
+	var wg sync.WaitGroup
+
+	r.SRV = make([]ClientConfigSRV, len(clientConfigSRVs))
+	for i, s := range clientConfigSRVs {
+		wg.Add(1)
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+
+			t0 := time.Now()
+			_, srvs, result, err := resolver.LookupSRV(opctx, s.service[1:], "tcp", dom.ASCII+".")
+			if dns.IsNotFound(err) {
+				err = nil
+			}
+			records := make([]SRVRecord, len(srvs))
+			for j, srv := range srvs {
+				records[j] = SRVRecord{strings.TrimSuffix(srv.Target, "."), int(srv.Port), int(srv.Priority), int(srv.Weight)}
+			}
+			r.SRV[i] = ClientConfigSRV{timeSince(t0), s.service + "._tcp", s.protocol, s.security, records, result.Authentic, errmsg(err)}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		t0 := time.Now()
+		u := fmt.Sprintf("https://autoconfig.%s/mail/config-v1.1.xml?emailaddress=%s", dom.ASCII, url.QueryEscape(address))
+		servers, xmlText, err := autoconfigFetch(opctx, u)
+		r.Autoconfig = ClientConfigAutoconfig{timeSince(t0), u, servers, xmlText, errmsg(err)}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		t0 := time.Now()
+		u := fmt.Sprintf("https://autodiscover.%s/autodiscover/autodiscover.xml", dom.ASCII)
+		servers, xmlText, err := autodiscoverFetch(opctx, u, address)
+		r.Autodiscover = ClientConfigAutodiscover{timeSince(t0), u, servers, xmlText, errmsg(err)}
+	}()
+
+	wg.Wait()
+
+	// Gather all endpoints, keeping track of where they were found.
+	add := func(source string, s ClientConfigServer) {
+		s.Host = strings.ToLower(strings.TrimSuffix(s.Host, "."))
+		for i, e := range r.Endpoints {
+			if e.Protocol == s.Protocol && e.Host == s.Host && e.Port == s.Port && e.Security == s.Security {
+				if !slices.Contains(e.Sources, source) {
+					r.Endpoints[i].Sources = append(r.Endpoints[i].Sources, source)
+				}
+				return
+			}
+		}
+		r.Endpoints = append(r.Endpoints, ClientConfigEndpoint{Protocol: s.Protocol, Host: s.Host, Port: s.Port, Security: s.Security, Sources: []string{source}})
+	}
+	for _, s := range r.SRV {
+		for _, rec := range s.Records {
+			// Target "." indicates the service is explicitly not available. ../rfc/6186:202
+			if rec.Target == "" {
+				continue
+			}
+			add("srv", ClientConfigServer{Protocol: s.Protocol, Host: rec.Target, Port: rec.Port, Security: s.Security})
+		}
+	}
+	for _, s := range r.Autoconfig.Servers {
+		add("autoconfig", s)
+	}
+	for _, s := range r.Autodiscover.Servers {
+		add("autodiscover", s)
+	}
+
+	r.Mismatches = clientConfigMismatches(r)
+
+	for i := range r.Endpoints {
+		wg.Add(1)
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+
+			clientConfigEndpointCheck(opctx, log, &r.Endpoints[i])
+		}()
+	}
+	wg.Wait()
+
+	for _, e := range r.Endpoints {
+		if e.Security == "plain" {
+			r.Mismatches = append(r.Mismatches, fmt.Sprintf("%s endpoint %s:%d does not use TLS, credentials would be sent in plain text", e.Protocol, e.Host, e.Port))
+		}
+		if e.Error != "" {
+			r.Mismatches = append(r.Mismatches, fmt.Sprintf("%s endpoint %s:%d, configured through %s, is not working: %s", e.Protocol, e.Host, e.Port, strings.Join(e.Sources, ", "), e.Error))
+		}
+	}
+
+	r.DurationMS = timeSince(start)
+	return
+}
+
+// clientConfigMismatches compares the endpoints per protocol between the
+// configuration mechanisms.
+func clientConfigMismatches(r ClientConfigResult) (l []string) {
+	sources := map[string]bool{}
+	for _, s := range r.SRV {
+		if len(s.Records) > 0 {
+			sources["srv"] = true
+		}
+	}
+	if len(r.Autoconfig.Servers) > 0 {
+		sources["autoconfig"] = true
+	}
+	if len(r.Autodiscover.Servers) > 0 {
+		sources["autodiscover"] = true
+	}
+	if len(sources) == 0 {
+		return []string{"no client configuration found through SRV records, autoconfig or autodiscover"}
+	}
+	if len(sources) == 1 {
+		return nil
+	}
+
+	for _, proto := range []string{"submission", "imap", "pop3"} {
+		var have []string
+		for source := range sources {
+			if slices.ContainsFunc(r.Endpoints, func(e ClientConfigEndpoint) bool {
+				return e.Protocol == proto && slices.Contains(e.Sources, source)
+			}) {
+				have = append(have, source)
+			}
+		}
+		if len(have) == 0 {
+			continue
+		}
+		slices.Sort(have)
+		for source := range sources {
+			if !slices.Contains(have, source) {
+				l = append(l, fmt.Sprintf("%s is configured through %s, but not through %s", proto, strings.Join(have, ", "), source))
+			}
+		}
+		for _, e := range r.Endpoints {
+			if e.Protocol != proto {
+				continue
+			}
+			for _, source := range have {
+				if !slices.Contains(e.Sources, source) {
+					l = append(l, fmt.Sprintf("%s endpoint %s:%d (%s) from %s is not present in %s", proto, e.Host, e.Port, e.Security, strings.Join(e.Sources, ", "), source))
+				}
+			}
+		}
+	}
+	slices.Sort(l)
+	return
+}
+
+func clientConfigFetch(ctx context.Context, req *http.Request) (string, error) {
+	resp, err := clientConfigHTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http response status %s", resp.Status)
+	}
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+	return string(buf), nil
+}
+
+func autoconfigFetch(ctx context.Context, u string) (servers []ClientConfigServer, xmlText string, rerr error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	xmlText, err = clientConfigFetch(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	type server struct {
+		Type           string `xml:"type,attr"`
+		Hostname       string `xml:"hostname"`
+		Port           int    `xml:"port"`
+		SocketType     string `xml:"socketType"`
+		Username       string `xml:"username"`
+		Authentication string `xml:"authentication"`
+	}
+	var config struct {
+		XMLName       xml.Name `xml:"clientConfig"`
+		EmailProvider struct {
+			IncomingServers []server `xml:"incomingServer"`
+			OutgoingServers []server `xml:"outgoingServer"`
+		} `xml:"emailProvider"`
+	}
+	if err := xml.Unmarshal([]byte(xmlText), &config); err != nil {
+		return nil, xmlText, fmt.Errorf("parsing autoconfig xml: %w", err)
+	}
+	for _, s := range append(config.EmailProvider.IncomingServers, config.EmailProvider.OutgoingServers...) {
+		proto := strings.ToLower(s.Type)
+		if proto == "smtp" {
+			proto = "submission"
+		}
+		var security string
+		switch strings.ToUpper(s.SocketType) {
+		case "SSL":
+			security = "tls"
+		case "STARTTLS":
+			security = "starttls"
+		case "PLAIN":
+			security = "plain"
+		default:
+			security = strings.ToLower(s.SocketType)
+		}
+		servers = append(servers, ClientConfigServer{proto, s.Hostname, s.Port, security, s.Username, s.Authentication})
+	}
+	if len(servers) == 0 {
+		rerr = errors.New("no servers in autoconfig xml")
+	}
+	return
+}
+
+func autodiscoverFetch(ctx context.Context, u, address string) (servers []ClientConfigServer, xmlText string, rerr error) {
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<Autodiscover xmlns="http://schemas.microsoft.com/exchange/autodiscover/outlook/requestschema/2006">
+	<Request>
+		<EMailAddress>%s</EMailAddress>
+		<AcceptableResponseSchema>http://schemas.microsoft.com/exchange/autodiscover/outlook/responseschema/2006a</AcceptableResponseSchema>
+	</Request>
+</Autodiscover>
+`, address)
+	req, err := http.NewRequest("POST", u, strings.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	xmlText, err = clientConfigFetch(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var autodiscover struct {
+		Response struct {
+			Account struct {
+				Protocols []struct {
+					Type       string `xml:"Type"`
+					Server     string `xml:"Server"`
+					Port       int    `xml:"Port"`
+					LoginName  string `xml:"LoginName"`
+					SSL        string `xml:"SSL"`
+					Encryption string `xml:"Encryption"`
+					SPA        string `xml:"SPA"`
+				} `xml:"Protocol"`
+			} `xml:"Account"`
+		} `xml:"Response"`
+	}
+	if err := xml.Unmarshal([]byte(xmlText), &autodiscover); err != nil {
+		return nil, xmlText, fmt.Errorf("parsing autodiscover xml: %w", err)
+	}
+	for _, p := range autodiscover.Response.Account.Protocols {
+		var proto string
+		switch strings.ToUpper(p.Type) {
+		case "IMAP":
+			proto = "imap"
+		case "POP3":
+			proto = "pop3"
+		case "SMTP":
+			proto = "submission"
+		default:
+			continue
+		}
+		// Encryption overrides SSL if present.
+		security := "plain"
+		switch strings.ToUpper(p.Encryption) {
+		case "SSL":
+			security = "tls"
+		case "TLS":
+			security = "starttls"
+		case "NONE":
+		case "":
+			if !strings.EqualFold(p.SSL, "off") {
+				security = "tls"
+			}
+		default:
+			security = strings.ToLower(p.Encryption)
+		}
+		var auth string
+		if strings.EqualFold(p.SPA, "on") {
+			auth = "spa"
+		}
+		servers = append(servers, ClientConfigServer{proto, p.Server, p.Port, security, p.LoginName, auth})
+	}
+	if len(servers) == 0 {
+		rerr = errors.New("no imap, pop3 or smtp protocols in autodiscover xml")
+	}
+	return
+}
+
+// clientConfigEndpointCheck connects to the endpoint, reads the greeting and
+// verifies the TLS certificate, either with immediate TLS or after the
+// protocol-specific STARTTLS command.
+func clientConfigEndpointCheck(ctx context.Context, log mlog.Log, e *ClientConfigEndpoint) {
+	t0 := time.Now()
+	defer func() {
+		e.DurationMS = timeSince(t0)
+	}()
+
+	fail := func(err error) {
+		e.Error = err.Error()
+	}
+
+	host, err := dns.ParseDomain(e.Host)
+	if err != nil {
+		fail(fmt.Errorf("parsing host: %w", err))
+		return
+	}
+	if !slices.Contains(clientConfigPorts, e.Port) {
+		fail(fmt.Errorf("port %d is not a known mail port, not connecting", e.Port))
+		return
+	}
+	ips, _, err := resolver.LookupIP(ctx, "ip", host.ASCII+".")
+	if err != nil {
+		fail(fmt.Errorf("looking up ip addresses: %w", err))
+		return
+	}
+
+	dialer := &limitDialer{Control: publicDialControl}
+	var conn net.Conn
+	for _, ip := range ips {
+		dctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		conn, err = dialer.DialContext(dctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(e.Port)))
+		cancel()
+		if err == nil {
+			e.IP = ip
+			break
+		}
+	}
+	if err != nil {
+		fail(fmt.Errorf("dial: %w", err))
+		return
+	} else if conn == nil {
+		fail(errors.New("no ip addresses"))
+		return
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	handshake := func() error {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host.ASCII})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("tls handshake: %w", err)
+		}
+		cs := tlsConn.ConnectionState()
+		e.TLSConnectionState = tlsConnectionState(&cs)
+		conn = tlsConn
+		return nil
+	}
+
+	if e.Security == "tls" {
+		if err := handshake(); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	br := bufio.NewReader(conn)
+	readline := func() (string, error) {
+		line, err := br.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	// Read SMTP response, possibly multiline.
+	readsmtp := func() (string, error) {
+		for {
+			line, err := readline()
+			if err != nil || len(line) < 4 || line[3] != '-' {
+				return line, err
+			}
+		}
+	}
+	command := func(cmd string) error {
+		_, err := fmt.Fprintf(conn, "%s\r\n", cmd)
+		return err
+	}
+
+	if e.Protocol == "submission" {
+		e.Greeting, err = readsmtp()
+	} else {
+		e.Greeting, err = readline()
+	}
+	if err != nil {
+		fail(fmt.Errorf("reading greeting: %w", err))
+		return
+	}
+	log.Debug("client config endpoint greeting", slog.String("host", e.Host), slog.Int("port", e.Port), slog.String("greeting", e.Greeting))
+
+	if e.Security != "starttls" {
+		return
+	}
+
+	var line string
+	switch e.Protocol {
+	case "submission":
+		if err = command("EHLO " + dnsHostname.ASCII); err == nil {
+			if line, err = readsmtp(); err == nil && !strings.HasPrefix(line, "250") {
+				err = fmt.Errorf("unexpected response to EHLO: %q", line)
+			}
+		}
+		if err == nil {
+			if err = command("STARTTLS"); err == nil {
+				if line, err = readsmtp(); err == nil && !strings.HasPrefix(line, "220") {
+					err = fmt.Errorf("unexpected response to STARTTLS: %q", line)
+				}
+			}
+		}
+	case "imap":
+		if err = command("x0 STARTTLS"); err == nil {
+			// Skip untagged responses.
+			for err == nil {
+				if line, err = readline(); err == nil && !strings.HasPrefix(line, "* ") {
+					break
+				}
+			}
+			if err == nil && !strings.HasPrefix(strings.ToUpper(line), "X0 OK") {
+				err = fmt.Errorf("unexpected response to STARTTLS: %q", line)
+			}
+		}
+	case "pop3":
+		if err = command("STLS"); err == nil {
+			if line, err = readline(); err == nil && !strings.HasPrefix(line, "+OK") {
+				err = fmt.Errorf("unexpected response to STLS: %q", line)
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown protocol %q", e.Protocol)
+	}
+	if err == nil && br.Buffered() > 0 {
+		err = errors.New("remote sent data after starttls response")
+	}
+	if err != nil {
+		fail(fmt.Errorf("starttls: %w", err))
+		return
+	}
+	if err := handshake(); err != nil {
+		fail(err)
+	}
+}
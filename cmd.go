@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mjl-/sherpa"
+)
+
+// Commands run a single check from the command line, printing the results, instead
+// of serving the web interface.
+
+type cmd struct {
+	name   string
+	params string
+	flag   *flag.FlagSet
+	args   []string
+}
+
+var cmds = []struct {
+	name   string
+	params string
+	fn     func(c *cmd)
+}{
+	{"addresscheck", "[-probe] address", cmdAddresscheck},
+	{"authanalyze", "[-concurrency n] mbox|maildir|file ...", cmdAuthanalyze},
+	{"authtest", "[-port port] [-security tls|starttls] [-mechanism mechanism] host username <password", cmdAuthtest},
+	{"ci", "[-all] [-format junit|sarif] [-policy file] [-zone file ... [-dnssec] [-mtasts-policy file]] domain ...", cmdCI},
+	{"dkimdiscover", "domain", cmdDKIMDiscover},
+	{"dkimverify", "[-debug | -explain] [-message n] [file]", cmdDKIMVerify},
+	{"dnsbl", "ip ...", cmdDNSBL},
+	{"domaincheck", "[-all] domain", cmdDomaincheck},
+	{"domaincheckbatch", "[-concurrency n] [-csv [-results file]] file|-", cmdDomaincheckbatch},
+	{"domainreport", "[-all] [-format html|markdown|json] domain | -input file|-", cmdDomainreport},
+	{"dsnparse", "[-message n] [file]", cmdDSNParse},
+	{"expect", "[-all] [-json] file.{json,yaml} ...", cmdExpect},
+	{"mimeinspect", "[-message n] [file]", cmdMIMEInspect},
+	{"tlsscan", "domain", cmdTLSScan},
+	{"testdelivery", "[-dkim] [-requiretls] [-8bit] address", cmdTestdelivery},
+}
+
+func (c *cmd) Usage() {
+	fmt.Fprintf(os.Stderr, "usage: moxtools %s %s\n", c.name, c.params)
+	c.flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// Parse parses the command flags and returns the remaining arguments.
+func (c *cmd) Parse() []string {
+	c.flag.Usage = c.Usage
+	c.flag.Parse(c.args)
+	return c.flag.Args()
+}
+
+func runCmd(args []string) {
+	for _, x := range cmds {
+		if x.name != args[0] {
+			continue
+		}
+
+		// API errors cause a panic with a sherpa error, we print them like other errors.
+		defer func() {
+			x := recover()
+			if x == nil {
+				return
+			}
+			if err, ok := x.(*sherpa.Error); ok {
+				xcmdcheck(errors.New(err.Message), "")
+			}
+			panic(x)
+		}()
+
+		c := &cmd{name: x.name, params: x.params, flag: flag.NewFlagSet("moxtools "+x.name, flag.ExitOnError), args: args[1:]}
+		x.fn(c)
+		return
+	}
+	flag.Usage()
+}
+
+func xcmdcheck(err error, msg string) {
+	if err == nil {
+		return
+	}
+	if msg != "" {
+		fmt.Fprintf(os.Stderr, "moxtools: %s: %s\n", msg, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "moxtools: %s\n", err)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/smtp"
+	"github.com/mjl-/mox/smtpclient"
+)
+
+// Test deliveries are opt-in for the API, we don't want to be used for sending
+// messages to arbitrary addresses.
+var testDelivery bool
+var testDeliveryFrom string
+var testDeliveryDKIMSelector string
+var testDeliveryDKIMKey string
+
+var testDeliveryFromAddr smtp.Address
+var testDeliveryDKIM *dkim.Selector
+
+// Parse the flags for test deliveries, called at startup.
+func testDeliveryInit() {
+	var err error
+	if testDeliveryFrom == "" {
+		testDeliveryFromAddr = smtp.NewAddress("moxtools", dnsHostname)
+	} else {
+		testDeliveryFromAddr, err = smtp.ParseAddress(testDeliveryFrom)
+		xcheck(err, "parsing test delivery from address")
+	}
+
+	if testDeliveryDKIMSelector == "" && testDeliveryDKIMKey == "" {
+		return
+	} else if testDeliveryDKIMSelector == "" || testDeliveryDKIMKey == "" {
+		xcheck(errors.New("both selector and key are required"), "dkim signing for test delivery")
+	}
+	sel, err := dns.ParseDomain(testDeliveryDKIMSelector)
+	xcheck(err, "parsing dkim selector")
+	buf, err := os.ReadFile(testDeliveryDKIMKey)
+	xcheck(err, "reading dkim private key")
+	key, err := parseDKIMPrivateKey(buf)
+	xcheck(err, "parsing dkim private key")
+	testDeliveryDKIM = &dkim.Selector{
+		Hash:          "sha256",
+		HeaderRelaxed: true,
+		BodyRelaxed:   true,
+		Headers:       strings.Split("From,To,Subject,Date,Message-ID,MIME-Version,Content-Type,Content-Transfer-Encoding", ","),
+		SealHeaders:   true,
+		PrivateKey:    key,
+		Domain:        sel,
+	}
+}
+
+// parseDKIMPrivateKey parses a PEM-encoded PKCS#8 RSA or ed25519 key, or a
+// PKCS#1 RSA key.
+func parseDKIMPrivateKey(buf []byte) (crypto.Signer, error) {
+	b, _ := pem.Decode(buf)
+	if b == nil {
+		return nil, errors.New("no pem block")
+	}
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(b.Bytes)
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := k.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", k)
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported pem block type %q", b.Type)
+}
+
+type TestDeliveryResult struct {
+	DurationMS         int
+	MailFrom           string
+	RcptTo             string
+	MessageID          string
+	DKIMSigned         bool
+	Host               dns.IPDomain // MX target the message was delivered to, or the last one attempted.
+	IP                 net.IP
+	TLSConnectionState *TLSConnectionState
+
+	// Extensions announced by the server.
+	Supports8bitMIME   bool
+	SupportsRequireTLS bool
+	SupportsSMTPUTF8   bool
+
+	// Extensions required for this delivery, with delivery failing if the server
+	// doesn't support them.
+	Need8bitMIME   bool
+	NeedSMTPUTF8   bool
+	NeedRequireTLS bool
+
+	Response string // Last line of the SMTP response to the message data, or of the failed command.
+	QueueID  string // Heuristically parsed from Response.
+	Success  bool
+	Error    string
+
+	Trace []Proto // Of the last delivery attempt, message data replaced with "...".
+}
+
+func (API) TestDelivery(ctx context.Context, address string, dkimSign, requireTLS, eightbit bool) TestDeliveryResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	xlimit(ctx, &apiDomainLimiter)
+
+	if !testDelivery {
+		xcheckuser(errors.New("not enabled on this instance"), "test delivery")
+	}
+
+	log.Debug("testdelivery call", slog.String("address", address), slog.Bool("dkimsign", dkimSign), slog.Bool("requiretls", requireTLS), slog.Bool("eightbit", eightbit))
+
+	return testDeliver(ctx, log, address, dkimSign, requireTLS, eightbit)
+}
+
+func testDeliver(ctx context.Context, log mlog.Log, address string, dkimSign, requireTLS, eightbit bool) (r TestDeliveryResult) {
+	rcpt, err := smtp.ParseAddress(address)
+	xcheckuser(err, "parsing address")
+	if dkimSign && testDeliveryDKIM == nil {
+		xcheckuser(errors.New("no dkim key configured on this instance"), "dkim signing")
+	}
+
+	start := time.Now()
+	defer func() {
+		r.DurationMS = timeSince(start)
+	}()
+
+	smtputf8 := rcpt.Localpart.IsInternational() || testDeliveryFromAddr.Localpart.IsInternational()
+	msg, msgID, has8bit, err := testDeliveryMessage(testDeliveryFromAddr, rcpt, smtputf8, eightbit)
+	xcheckuser(err, "composing message")
+
+	r.MailFrom = testDeliveryFromAddr.Pack(smtputf8)
+	r.RcptTo = rcpt.Pack(smtputf8)
+	r.MessageID = msgID
+	r.Need8bitMIME = has8bit
+	r.NeedSMTPUTF8 = smtputf8
+	r.NeedRequireTLS = requireTLS
+	r.Trace = []Proto{}
+
+	if dkimSign {
+		hdrs, err := dkim.Sign(ctx, log.Logger, testDeliveryFromAddr.Localpart, testDeliveryFromAddr.Domain, []dkim.Selector{*testDeliveryDKIM}, smtputf8, bytes.NewReader(msg))
+		xcheckuser(err, "dkim signing message")
+		msg = append([]byte(hdrs), msg...)
+		r.DKIMSigned = true
+	}
+
+	opctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	_, _, _, _, hosts, _, err := smtpclient.GatherDestinations(opctx, log.Logger, resolver, dns.IPDomain{Domain: rcpt.Domain})
+	if err != nil {
+		r.Error = fmt.Sprintf("looking up destinations: %v", err)
+		return
+	}
+
+	// Like a queue, try the hosts in order until one of them accepts or rejects the
+	// message, continuing with the next host on connection failures.
+	for _, h := range hosts {
+		r.Host = h
+		r.IP = nil
+		r.TLSConnectionState = nil
+		r.Trace = []Proto{}
+		var smtpErr bool
+		smtpErr, err = testDeliverHost(opctx, log, &r, h, msg, has8bit, smtputf8, requireTLS)
+		if err == nil || smtpErr {
+			break
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+		return
+	}
+	r.Success = true
+	return
+}
+
+func testDeliverHost(ctx context.Context, log mlog.Log, r *TestDeliveryResult, host dns.IPDomain, msg []byte, has8bit, smtputf8, requireTLS bool) (smtpErr bool, rerr error) {
+	dialedIPs := map[string][]net.IP{}
+	_, _, _, ips, _, err := smtpclient.GatherIPs(ctx, log.Logger, resolver, "ip", host, dialedIPs)
+	if err != nil {
+		return false, fmt.Errorf("looking up ips for %s: %v", host, err)
+	}
+
+	dialer := &limitDialer{Control: publicDialControl}
+	conn, ip, err := smtpclient.Dial(ctx, log.Logger, dialer, host, ips, 25, dialedIPs, nil)
+	r.IP = ip
+	if err != nil {
+		return false, fmt.Errorf("dial %s: %v", host, err)
+	}
+	defer conn.Close()
+
+	// REQUIRETLS needs a verified TLS connection, typically through MTA-STS or DANE.
+	// We just require a PKIX-verified certificate.
+	tlsMode := smtpclient.TLSOpportunistic
+	opts := smtpclient.Opts{IgnoreTLSVerifyErrors: true} // note: not generally safe
+	if requireTLS {
+		tlsMode = smtpclient.TLSRequiredStartTLS
+		opts.IgnoreTLSVerifyErrors = false
+	}
+
+	th := traceHandler{Trace: []Proto{}}
+	tracelog := slog.New(&th)
+	defer func() {
+		r.Trace = th.Trace
+	}()
+	client, err := smtpclient.New(ctx, tracelog, conn, tlsMode, requireTLS, dnsHostname, host.Domain, opts)
+	if err != nil {
+		r.Response = testDeliveryResponse(err, th.Trace)
+		return r.Response != "", fmt.Errorf("smtp connection with %s: %v", host, err)
+	}
+	defer client.Close()
+
+	r.TLSConnectionState = tlsConnectionState(client.TLSConnectionState())
+	r.Supports8bitMIME = client.Supports8BITMIME()
+	r.SupportsRequireTLS = client.SupportsRequireTLS()
+	r.SupportsSMTPUTF8 = client.SupportsSMTPUTF8()
+
+	err = client.Deliver(ctx, r.MailFrom, r.RcptTo, int64(len(msg)), bytes.NewReader(msg), has8bit, smtputf8, requireTLS)
+	r.Response = testDeliveryResponse(err, th.Trace)
+	if err != nil {
+		return r.Response != "", fmt.Errorf("delivery to %s: %v", host, err)
+	}
+	r.QueueID = smtpQueueID(r.Response)
+	return false, nil
+}
+
+// testDeliveryResponse returns the SMTP response line from the error, or the last
+// line read from the server.
+func testDeliveryResponse(err error, trace []Proto) string {
+	var cerr smtpclient.Error
+	if errors.As(err, &cerr) {
+		return cerr.Line
+	} else if err != nil {
+		return ""
+	}
+	for i := len(trace) - 1; i >= 0; i-- {
+		if !trace[i].ClientWrite {
+			lines := strings.Split(strings.TrimRight(trace[i].Text, "\r\n"), "\n")
+			return strings.TrimRight(lines[len(lines)-1], "\r")
+		}
+	}
+	return ""
+}
+
+var queueIDRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)queued as ([^ ;,]+)`),      // Postfix.
+	regexp.MustCompile(`(?i)\bid=([^ ;,]+)`),           // Exim.
+	regexp.MustCompile(`(?i)message ([^ ]+) accepted`), // Various.
+	regexp.MustCompile(`^250[ -][0-9.]+ <([^>]+)> `),
+	regexp.MustCompile(`^250[ -]2\.0\.0 OK +[0-9]+ ([^ ]+)`), // Gmail.
+}
+
+// smtpQueueID tries to find a queue ID in an SMTP response line. There is no
+// standard, so it is a best effort.
+func smtpQueueID(line string) string {
+	for _, re := range queueIDRegexps {
+		if m := re.FindStringSubmatch(line); m != nil {
+			return strings.Trim(m[1], "<>")
+		}
+	}
+	return ""
+}
+
+func testDeliveryMessage(from, rcpt smtp.Address, smtputf8, eightbit bool) (msg []byte, msgID string, has8bit bool, rerr error) {
+	var buf bytes.Buffer
+	xc := message.NewComposer(&buf, 0, smtputf8)
+	defer func() {
+		x := recover()
+		if x == nil {
+			return
+		}
+		if err, ok := x.(error); ok && errors.Is(err, message.ErrCompose) {
+			rerr = err
+			return
+		}
+		panic(x)
+	}()
+
+	idbuf := make([]byte, 12)
+	if _, err := rand.Read(idbuf); err != nil {
+		return nil, "", false, err
+	}
+	msgID = fmt.Sprintf("%s@%s", base64.RawURLEncoding.EncodeToString(idbuf), dnsHostname.ASCII)
+
+	xc.HeaderAddrs("From", []message.NameAddress{{DisplayName: "moxtools", Address: from}})
+	xc.HeaderAddrs("To", []message.NameAddress{{Address: rcpt}})
+	xc.Subject("moxtools test message")
+	xc.Header("Date", time.Now().Format(message.RFC5322Z))
+	xc.Header("Message-ID", "<"+msgID+">")
+	xc.Header("MIME-Version", "1.0")
+
+	text := fmt.Sprintf(`This is a test message sent by moxtools on request, to:
+
+	%s
+
+Sent at %s, by moxtools version %s.
+`, rcpt.Pack(smtputf8), time.Now().Format(time.RFC3339), version)
+	if eightbit {
+		text += "\nNon-ASCII text, sent as 8bit: ☺ Grüße.\n"
+	}
+	textBody, ct, cte := xc.TextPart("plain", text)
+	xc.Header("Content-Type", ct)
+	xc.Header("Content-Transfer-Encoding", cte)
+	xc.Line()
+	xc.Write(textBody)
+	xc.Flush()
+	return buf.Bytes(), msgID, cte == "8bit", nil
+}
+
+func cmdTestdelivery(c *cmd) {
+	var dkimSign, requireTLS, eightbit bool
+	c.flag.BoolVar(&dkimSign, "dkim", false, "dkim-sign message with the key configured with the -dkim-selector and -dkim-key flags")
+	c.flag.BoolVar(&requireTLS, "requiretls", false, "require verified tls and request the REQUIRETLS extension")
+	c.flag.BoolVar(&eightbit, "8bit", false, "add non-ascii text to the message, sent as 8bit, requiring 8BITMIME")
+	args := c.Parse()
+	if len(args) != 1 {
+		c.Usage()
+	}
+
+	r := testDeliver(context.Background(), pkglog, args[0], dkimSign, requireTLS, eightbit)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err := enc.Encode(r)
+	xcmdcheck(err, "write result")
+	if !r.Success {
+		os.Exit(1)
+	}
+}
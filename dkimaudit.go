@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dns"
+)
+
+// dkimSyntaxTXT returns the TXT record for the selector that looks like a DKIM
+// record, for auditing a record with a syntax error. dkim.Lookup does not return
+// the TXT record in that case.
+func dkimSyntaxTXT(ctx context.Context, sel, dom dns.Domain) string {
+	l, _, err := resolver.LookupTXT(ctx, sel.ASCII+"._domainkey."+dom.ASCII+".")
+	if err != nil {
+		return ""
+	}
+	for _, s := range l {
+		if strings.HasPrefix(s, "v=DKIM1") || strings.Contains(s, "p=") {
+			return s
+		}
+	}
+	return ""
+}
+
+// dkimAudit evaluates a DKIM record: strength of the public key and fields that
+// limit or prevent its use. The txt is the record as looked up in DNS, record is
+// nil and err set if it could not be parsed. DNS resolvers join the strings of a
+// TXT record, so how the record was split cannot be seen directly, only the
+// artifacts of incorrect splitting. Findings have no points, they are not part of
+// a score.
+func dkimAudit(record *dkim.Record, txt string, err error) []Finding {
+	l := []Finding{}
+	add := func(check, severity, title, explanation, remediation string) {
+		l = append(l, Finding{check, severity, title, explanation, remediation, 0})
+	}
+
+	if err != nil {
+		add("dkim.syntax", "error", "Invalid record", "The record could not be parsed, signatures with this selector fail verification: "+err.Error(), "Fix the record, e.g. by publishing it again as generated by the mail server.")
+	}
+	if strings.ContainsAny(txt, `"\`) {
+		add("dkim.txt-split", "warning", "Quotes or backslashes in record", "The TXT record contains quote or backslash characters. This usually happens when a long record is split into multiple strings in a zone file, and then pasted including the quotes into a DNS management interface. Verifiers may fail to parse the public key.", `Publish the record without the quotes. A TXT record consists of strings of at most 255 bytes, split long records into multiple strings, e.g. "v=DKIM1; k=rsa; p=MIIB..." "...AQAB", without separators between them.`)
+	}
+	for _, t := range strings.Split(txt, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(t), "=")
+		if strings.TrimSpace(k) == "p" && strings.ContainsAny(strings.TrimSpace(v), " \t\r\n") {
+			add("dkim.txt-split", "warning", "Whitespace in public key", "The public key (p=) contains whitespace. This is allowed by RFC 6376, but often the result of splitting the record into strings with spaces in between, and some verifiers fail to parse the key.", "Split the record into multiple strings without adding whitespace between them.")
+		}
+	}
+	if record == nil {
+		return l
+	}
+
+	switch pk := record.PublicKey.(type) {
+	case nil:
+		if len(record.Pubkey) == 0 {
+			add("dkim.revoked", "warning", "Key revoked", "The record has an empty public key (p=), which means the key has been revoked. Messages signed with this selector fail DKIM verification. This is expected for a selector that is no longer used after a key rotation.", "If messages are still signed with this selector, publish the public key, or switch signing to a selector with a key.")
+		}
+	case *rsa.PublicKey:
+		bits := pk.N.BitLen()
+		if bits < 1024 {
+			add("dkim.key-size", "error", fmt.Sprintf("RSA key too small: %d bits", bits), "RSA keys smaller than 1024 bits can be broken, and must not be used for DKIM (RFC 8301). Verifiers, including mox, treat signatures with such keys as failed.", "Generate a new RSA key of at least 2048 bits, publish it under a new selector and switch signing to it.")
+		} else if bits < 2048 {
+			add("dkim.key-size", "warning", fmt.Sprintf("Weak RSA key: %d bits", bits), "RSA keys of less than 2048 bits are considered weak, RFC 8301 recommends at least 2048 bits. 1024 bit keys are within reach of well-funded attackers.", "Generate a new RSA key of 2048 bits, publish it under a new selector and switch signing to it.")
+		} else {
+			add("dkim.key-size", "info", fmt.Sprintf("RSA key: %d bits", bits), "The RSA key is of sufficient size.", "")
+		}
+	case ed25519.PublicKey:
+		add("dkim.key-size", "info", "Ed25519 key", "Ed25519 keys are strong and small (RFC 8463), but not all verifiers support them yet. Signatures with this key are ignored by those verifiers.", "Also sign with an RSA key, under a different selector.")
+	}
+
+	if slices.ContainsFunc(record.Flags, func(s string) bool { return strings.EqualFold(s, "y") }) {
+		add("dkim.testing", "warning", "Testing mode (t=y)", "The domain signals it is testing DKIM. Verifiers must not treat messages differently based on signature verification (RFC 6376 section 3.6.1), so a failing signature does not hurt, but a passing signature does not help either. DMARC evaluation may also ignore the signature.", "Remove t=y from the record once DKIM signing works.")
+	}
+	if slices.ContainsFunc(record.Flags, func(s string) bool { return strings.EqualFold(s, "s") }) {
+		add("dkim.strict", "info", "Strict identity (t=s)", "The i= identity in signatures must have exactly the domain of d=, not a subdomain. Signatures with a subdomain in i= fail verification.", "")
+	}
+
+	if len(record.Hashes) > 0 {
+		sha256 := slices.ContainsFunc(record.Hashes, func(s string) bool { return strings.EqualFold(s, "sha256") })
+		sha1 := slices.ContainsFunc(record.Hashes, func(s string) bool { return strings.EqualFold(s, "sha1") })
+		if !sha256 {
+			add("dkim.hashes", "error", "Hash algorithms without sha256 (h="+strings.Join(record.Hashes, ":")+")", "The record only allows the listed hash algorithms. Signers must use sha256 (RFC 8301), and verifiers reject signatures with other algorithms for this key. No signature with this key can pass.", "Remove the h= field, or set it to h=sha256.")
+		} else if sha1 {
+			add("dkim.hashes", "warning", "Hash algorithm sha1 allowed (h="+strings.Join(record.Hashes, ":")+")", "The sha1 hash algorithm is broken and must not be used for DKIM (RFC 8301). Verifiers, including mox, reject signatures using sha1.", "Remove the h= field, or set it to h=sha256.")
+		} else {
+			add("dkim.hashes", "info", "Restricted hash algorithms (h="+strings.Join(record.Hashes, ":")+")", "The record only allows the listed hash algorithms. This is fine as long as signers use them, but the record must be updated before signing with new algorithms.", "")
+		}
+	}
+
+	if !record.ServiceAllowed("email") {
+		add("dkim.services", "error", "Key not for email (s="+strings.Join(record.Services, ":")+")", "The s= field limits the services the key can be used for, and does not include email or *. Verifiers must reject email signatures with this key.", "Remove the s= field, or set it to s=email.")
+	} else if len(record.Services) > 0 && (len(record.Services) != 1 || record.Services[0] != "*") {
+		// The parser defaults to "*" without s=.
+		add("dkim.services", "info", "Services limited (s="+strings.Join(record.Services, ":")+")", "The key can only be used for the listed services, which includes email.", "")
+	}
+
+	return l
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+)
+
+// DKIMHypothesis is a possible explanation of a failing DKIM signature.
+type DKIMHypothesis struct {
+	Text      string
+	Confirmed bool // Reversing the change makes the signature, or the failing half of it, verify.
+}
+
+// DKIMBreakage is the analysis of a DKIM signature, for explaining failures.
+type DKIMBreakage struct {
+	Result     DKIMResult
+	HeadersOK  bool // Signature over the headers verifies.
+	BodyOK     bool // Body hash matches.
+	Hypotheses []DKIMHypothesis
+	Error      string
+}
+
+func (API) DKIMBreakage(ctx context.Context, message []byte) []DKIMBreakage {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("dkimbreakage call")
+
+	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	l, err := dkimBreakage(opctx, log, xmessage(message))
+	xcheckuser(err, "verifying dkim signatures in message")
+	return l
+}
+
+var subjectTagRegexp = regexp.MustCompile(`^((?i:\s*(re|fwd?|aw|sv):)*\s*)(\[[^\]]*\]\s*)`)
+
+// Footers are searched at most this many lines long, and only in smaller bodies.
+// The search stops after hashing dkimFooterMaxHashed bytes of candidate bodies
+// for a signature.
+const (
+	dkimFooterMaxLines  = 40
+	dkimFooterMaxBody   = 1024 * 1024
+	dkimFooterMaxHashed = 64 * 1024 * 1024
+)
+
+// dkimBreakage verifies the DKIM signatures in msg. For failed signatures, it
+// reverses changes commonly made by intermediaries like mailing lists and
+// verifies again.
+func dkimBreakage(ctx context.Context, log mlog.Log, msg []byte) ([]DKIMBreakage, error) {
+	results, err := dkim.Verify(ctx, log.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(msg), false)
+	if err != nil {
+		return nil, err
+	}
+	hdrs, body := dkimSplitMessage(msg)
+	var sigs []dkimHeader
+	for _, h := range hdrs {
+		if h.lkey == "dkim-signature" {
+			sigs = append(sigs, h)
+		}
+	}
+
+	l := make([]DKIMBreakage, len(results))
+	for i, r := range dkimResults(results) {
+		b := &l[i]
+		*b = DKIMBreakage{Result: r, Hypotheses: []DKIMHypothesis{}}
+		if r.Status == DKIMStatus(dkim.StatusPass) {
+			b.HeadersOK, b.BodyOK = true, true
+			continue
+		} else if r.Status != DKIMStatus(dkim.StatusFail) || r.Sig == nil || r.Record == nil || i >= len(sigs) {
+			// Not a failed verification, e.g. a missing DNS record.
+			continue
+		}
+		h, headerSimple, bodySimple, err := dkimParams(r.Sig)
+		if err != nil {
+			b.Error = err.Error()
+			continue
+		}
+
+		verifyHeaders := func(hdrs []dkimHeader) bool {
+			var data string
+			for _, dh := range dkimHashedHeaders(hdrs, r.Sig, sigs[i], headerSimple) {
+				data += dh.Canonical
+			}
+			hh := h.New()
+			io.WriteString(hh, data)
+			digest := hh.Sum(nil)
+			switch k := r.Record.PublicKey.(type) {
+			case *rsa.PublicKey:
+				return rsa.VerifyPKCS1v15(k, h, digest, r.Sig.Signature) == nil
+			case ed25519.PublicKey:
+				return ed25519.Verify(k, digest, r.Sig.Signature)
+			}
+			return false
+		}
+		bodyHash := base64.StdEncoding.EncodeToString(r.Sig.BodyHash)
+		verifyBody := func(body []byte, simple bool) bool {
+			return dkimHash(h, dkimTruncate(dkimCanonBody(body, simple), r.Sig.Length)) == bodyHash
+		}
+		b.HeadersOK = verifyHeaders(hdrs)
+		b.BodyOK = verifyBody(body, bodySimple)
+
+		add := func(confirmed bool, format string, args ...any) {
+			b.Hypotheses = append(b.Hypotheses, DKIMHypothesis{fmt.Sprintf(format, args...), confirmed})
+		}
+		// Describes the effect of reversing a change to the headers or body.
+		wouldPass := func(headers bool) string {
+			if headers && b.BodyOK || !headers && b.HeadersOK {
+				return "Signature would pass"
+			} else if headers {
+				return "Signature over headers would verify"
+			}
+			return "Body hash would match"
+		}
+		signed := func(name string) bool {
+			for _, s := range r.Sig.SignedHeaders {
+				if strings.EqualFold(s, name) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if !b.HeadersOK {
+			var found bool
+
+			// Tag like "[list]" added to subject. Headers can also have been added, so
+			// removing them is tried on the headers with and without the tag.
+			var tag string
+			bases := [][]dkimHeader{hdrs}
+			for j := len(hdrs) - 1; j >= 0; j-- {
+				if hdrs[j].lkey != "subject" || !signed("Subject") {
+					continue
+				}
+				k, v, _ := strings.Cut(hdrs[j].raw, ":")
+				if m := subjectTagRegexp.FindStringSubmatchIndex(v); m != nil {
+					tag = strings.TrimSpace(v[m[6]:m[7]])
+					xhdrs := append([]dkimHeader{}, hdrs...)
+					xhdrs[j].raw = k + ":" + v[:m[6]] + v[m[7]:]
+					if verifyHeaders(xhdrs) {
+						found = true
+						add(true, "%s if the subject tag %q were removed, it was likely added by a mailing list.", wouldPass(true), tag)
+					} else {
+						bases = append(bases, xhdrs)
+					}
+				}
+				break
+			}
+
+			// Headers added after signing, of which the signer "oversigned" instances that
+			// were not present, to prevent additions.
+			for bi, base := range bases {
+				for j, hdr := range base {
+					if !signed(hdr.key) || hdr.lkey == "dkim-signature" {
+						continue
+					}
+					xhdrs := append(append([]dkimHeader{}, base[:j]...), base[j+1:]...)
+					if !verifyHeaders(xhdrs) {
+						continue
+					}
+					found = true
+					if bi == 0 {
+						add(true, "%s if the %s header %q were removed. It was likely added after signing, and the signer oversigned it to prevent additions.", wouldPass(true), hdr.key, dkimHeaderValue(hdr))
+					} else {
+						add(true, "%s if the subject tag %q and the %s header %q were removed. They were likely added by a mailing list, and the signer oversigned the %s header to prevent additions.", wouldPass(true), tag, hdr.key, dkimHeaderValue(hdr), hdr.key)
+					}
+				}
+			}
+			if !found && tag != "" {
+				add(false, "Subject has tag %q, possibly added by a mailing list, but removing it does not make the signature verify.", tag)
+			}
+
+			// Content-Transfer-Encoding changed along with re-encoding of the body.
+			for j, hdr := range hdrs {
+				if hdr.lkey != "content-transfer-encoding" || !signed(hdr.key) {
+					continue
+				}
+				for _, cte := range []string{"7bit", "8bit", "quoted-printable", "base64"} {
+					xhdrs := append([]dkimHeader{}, hdrs...)
+					xhdrs[j].raw = hdr.key + ": " + cte + "\r\n"
+					if strings.EqualFold(dkimHeaderValue(hdr), cte) || !verifyHeaders(xhdrs) {
+						continue
+					}
+					found = true
+					add(true, "%s if the Content-Transfer-Encoding header were %q, the message was likely re-encoded, e.g. when relaying to a server without 8BITMIME support.", wouldPass(true), cte)
+				}
+			}
+
+			if !found {
+				if headerSimple {
+					add(false, "Headers are canonicalized with \"simple\", any change in whitespace or folding of signed headers by an intermediary breaks the signature. Signers should use \"relaxed\".")
+				}
+				for _, hdr := range hdrs {
+					if hdr.lkey == "list-id" {
+						add(false, "Message passed through mailing list %s, mailing lists often modify signed headers like Subject, From, Reply-To or Sender.", dkimHeaderValue(hdr))
+						break
+					}
+				}
+			}
+		}
+
+		if !b.BodyOK {
+			var found bool
+
+			if verifyBody(body, !bodySimple) {
+				found = true
+				add(true, "%s with %s body canonicalization, the signer likely canonicalized differently than indicated in c=.", wouldPass(false), dkimCanonName(!bodySimple))
+			}
+			canon := dkimTruncate(dkimCanonBody(body, bodySimple), r.Sig.Length)
+			if dkimHash(h, strings.ReplaceAll(canon, "\r\n", "\n")) == bodyHash {
+				found = true
+				add(true, "%s with bare newlines instead of CRLF line endings, the signer likely hashed the body before converting line endings.", wouldPass(false))
+			}
+
+			// Footer appended at the end of the body, or at the end of a MIME part.
+			if !found && len(body) <= dkimFooterMaxBody {
+				lines := strings.SplitAfter(string(body), "\r\n")
+				if lines[len(lines)-1] == "" {
+					lines = lines[:len(lines)-1]
+				}
+				// Positions where a footer could end: the end, or a line starting a MIME boundary.
+				ends := []int{len(lines)}
+				for p := len(lines) - 1; p > 0 && p >= len(lines)-200; p-- {
+					if strings.HasPrefix(lines[p], "--") && strings.TrimSpace(lines[p]) != "--" {
+						ends = append(ends, p)
+					}
+				}
+				var hashed int
+			Footer:
+				for _, p := range ends {
+					suffix := strings.Join(lines[p:], "")
+					for n := 1; n <= dkimFooterMaxLines && n <= p; n++ {
+						if ctx.Err() != nil || hashed > dkimFooterMaxHashed {
+							break Footer
+						}
+						xbody := strings.Join(lines[:p-n], "") + suffix
+						hashed += len(xbody)
+						if verifyBody([]byte(xbody), bodySimple) {
+							found = true
+							footer := strings.TrimSpace(strings.Join(lines[p-n:p], ""))
+							if len(footer) > 200 {
+								footer = footer[:200] + "..."
+							}
+							where := "at the end of the body"
+							if p < len(lines) {
+								where = "before MIME boundary " + strings.TrimSpace(lines[p])
+							}
+							add(true, "%s if the %d-line footer %s were removed, it was likely added by a mailing list: %q", wouldPass(false), n, where, footer)
+							break Footer
+						}
+					}
+				}
+			}
+
+			// Parts re-encoded to quoted-printable or base64.
+			if !found {
+				for _, s := range dkimDecodedBodies(log, msg) {
+					if verifyBody(s.body, bodySimple) {
+						found = true
+						add(true, "%s if the %s of %s were undone, the message was likely re-encoded by an intermediary, e.g. when relaying to a server without 8BITMIME support.", wouldPass(false), s.encoding, s.part)
+					}
+				}
+			}
+
+			if !found && bodySimple {
+				add(false, "Body is canonicalized with \"simple\", any change in whitespace by an intermediary, like removing trailing whitespace, breaks the signature.")
+			}
+			if !found && r.Sig.Length < 0 {
+				for _, hdr := range hdrs {
+					if hdr.lkey == "list-id" {
+						add(false, "Message passed through mailing list %s, mailing lists often add footers to messages or convert them to multipart.", dkimHeaderValue(hdr))
+						break
+					}
+				}
+			}
+		}
+	}
+	return l, nil
+}
+
+// dkimHeaderValue returns the unfolded value of a header.
+func dkimHeaderValue(h dkimHeader) string {
+	_, v, _ := strings.Cut(h.raw, ":")
+	return strings.TrimSpace(strings.ReplaceAll(v, "\r\n", ""))
+}
+
+// dkimDecodedBody is a message body with a part decoded.
+type dkimDecodedBody struct {
+	part     string // E.g. "the message" or "part 2 (text/plain)".
+	encoding string // E.g. "quoted-printable encoding".
+	body     []byte
+}
+
+// dkimDecodedBodies returns variants of the message body, each with one
+// quoted-printable or base64 encoded (text) part decoded, and its
+// Content-Transfer-Encoding header changed to 8bit, as they may have been before
+// re-encoding by an intermediary.
+func dkimDecodedBodies(log mlog.Log, msg []byte) []dkimDecodedBody {
+	p, err := message.Parse(log.Logger, false, bytes.NewReader(msg))
+	if err != nil {
+		return nil
+	}
+	if err := p.Walk(log.Logger, nil); err != nil {
+		return nil
+	}
+
+	var leafs []message.Part
+	var walk func(p message.Part)
+	walk = func(p message.Part) {
+		if len(p.Parts) == 0 {
+			leafs = append(leafs, p)
+		}
+		for _, pp := range p.Parts {
+			walk(pp)
+		}
+	}
+	walk(p)
+
+	var l []dkimDecodedBody
+	for i, lp := range leafs {
+		cte := strings.ToLower(lp.ContentTransferEncoding)
+		if cte != "quoted-printable" && cte != "base64" || len(l) >= 10 {
+			continue
+		}
+		// Binary content was likely base64 from the start.
+		if cte == "base64" && lp.MediaType != "TEXT" && lp.MediaType != "" {
+			continue
+		}
+		decoded, err := io.ReadAll(lp.Reader())
+		if err != nil {
+			continue
+		}
+		// Text is converted to crlf line endings by the reader, but may not end with one.
+		if len(decoded) > 0 && !bytes.HasSuffix(decoded, []byte("\r\n")) {
+			decoded = append(decoded, "\r\n"...)
+		}
+
+		// Part headers are in the body, except for a non-multipart message.
+		var xbody []byte
+		part := "the message"
+		if lp.HeaderOffset > p.BodyOffset {
+			hdr := dkimReplaceCTE(string(msg[lp.HeaderOffset:lp.BodyOffset]))
+			xbody = append(xbody, msg[p.BodyOffset:lp.HeaderOffset]...)
+			xbody = append(xbody, hdr...)
+			part = fmt.Sprintf("part %d (%s/%s)", i+1, strings.ToLower(lp.MediaType), strings.ToLower(lp.MediaSubType))
+		}
+		xbody = append(xbody, decoded...)
+		end := lp.EndOffset
+		// The crlf before a boundary belongs to the boundary.
+		if bytes.HasSuffix(decoded, []byte("\r\n")) && bytes.HasPrefix(msg[end:], []byte("\r\n")) {
+			end += 2
+		}
+		xbody = append(xbody, msg[end:]...)
+		l = append(l, dkimDecodedBody{part, cte + " encoding", xbody})
+	}
+	return l
+}
+
+// dkimReplaceCTE sets the Content-Transfer-Encoding in a part header to 8bit.
+func dkimReplaceCTE(hdr string) string {
+	lines := strings.SplitAfter(hdr, "\r\n")
+	for i, line := range lines {
+		k, _, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), "content-transfer-encoding") {
+			lines[i] = k + ": 8bit\r\n"
+			// Remove continuation lines.
+			for j := i + 1; j < len(lines) && lines[j] != "" && (lines[j][0] == ' ' || lines[j][0] == '\t'); j++ {
+				lines[j] = ""
+			}
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/mlog"
+)
+
+// DKIMDebugHeader is a header as included in the data hash of a signature.
+type DKIMDebugHeader struct {
+	Name      string // As listed in h= of the signature.
+	Missing   bool   // Not present (anymore), e.g. oversigned to prevent adding the header. Nothing is hashed.
+	Raw       string
+	Canonical string // As hashed, with the header canonicalization of the signature.
+	Other     string // With the other header canonicalization, for comparison.
+}
+
+// DKIMDebugLine is a line of the body that differs between canonicalizations.
+type DKIMDebugLine struct {
+	Line      int // Starting at 1.
+	Canonical string
+	Other     string
+}
+
+// DKIMDebug has the intermediate steps of verifying a DKIM signature.
+type DKIMDebug struct {
+	Result           DKIMResult
+	HeaderCanon      string            // "simple" or "relaxed".
+	BodyCanon        string            // "simple" or "relaxed".
+	Headers          []DKIMDebugHeader // In hashed order, ending with the DKIM-Signature header without b= value.
+	Body             string            // Canonicalized body, truncated to l= if present.
+	BodyLength       int               // Of the canonicalized body, before truncation.
+	Length           int64             // From l=, -1 if absent.
+	BodyHash         string            // As claimed in bh=, base64.
+	BodyHashComputed string            // Over Body.
+	BodyHashOther    string            // Computed with the other body canonicalization, to detect a signer using the wrong canonicalization.
+	BodyLines        []DKIMDebugLine   // Lines that differ with the other body canonicalization, at most 100.
+	Error            string            // If debugging could not be done, e.g. for an unknown hash algorithm.
+}
+
+func (API) DKIMDebug(ctx context.Context, message []byte) []DKIMDebug {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("dkimdebug call")
+
+	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	l, err := dkimDebug(opctx, log, xmessage(message))
+	xcheckuser(err, "verifying dkim signatures in message")
+	return l
+}
+
+// dkimDebug verifies the DKIM signatures in msg, and calculates the canonicalized
+// headers and body like a verifier would.
+func dkimDebug(ctx context.Context, log mlog.Log, msg []byte) ([]DKIMDebug, error) {
+	results, err := dkim.Verify(ctx, log.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(msg), false)
+	if err != nil {
+		return nil, err
+	}
+	hdrs, body := dkimSplitMessage(msg)
+
+	// A result is returned for each DKIM-Signature header, in order.
+	var sigs []dkimHeader
+	for _, h := range hdrs {
+		if h.lkey == "dkim-signature" {
+			sigs = append(sigs, h)
+		}
+	}
+
+	l := make([]DKIMDebug, len(results))
+	for i, r := range dkimResults(results) {
+		l[i] = DKIMDebug{Result: r, Headers: []DKIMDebugHeader{}, BodyLines: []DKIMDebugLine{}, Length: -1}
+		if r.Sig == nil || i >= len(sigs) {
+			continue
+		}
+		d := &l[i]
+		d.Length = r.Sig.Length
+
+		h, headerSimple, bodySimple, err := dkimParams(r.Sig)
+		if err != nil {
+			d.Error = err.Error()
+			continue
+		}
+		d.HeaderCanon, d.BodyCanon = dkimCanonName(headerSimple), dkimCanonName(bodySimple)
+		d.Headers = dkimHashedHeaders(hdrs, r.Sig, sigs[i], headerSimple)
+
+		canon := dkimCanonBody(body, bodySimple)
+		other := dkimTruncate(dkimCanonBody(body, !bodySimple), d.Length)
+		d.BodyLength = len(canon)
+		canon = dkimTruncate(canon, d.Length)
+		d.Body = canon
+		d.BodyHash = base64.StdEncoding.EncodeToString(r.Sig.BodyHash)
+		d.BodyHashComputed = dkimHash(h, canon)
+		d.BodyHashOther = dkimHash(h, other)
+
+		cl := strings.Split(canon, "\r\n")
+		ol := strings.Split(other, "\r\n")
+		for j := 0; j < max(len(cl), len(ol)) && len(d.BodyLines) < 100; j++ {
+			var c, o string
+			if j < len(cl) {
+				c = cl[j]
+			}
+			if j < len(ol) {
+				o = ol[j]
+			}
+			if c != o {
+				d.BodyLines = append(d.BodyLines, DKIMDebugLine{j + 1, c, o})
+			}
+		}
+	}
+	return l, nil
+}
+
+// dkimParams returns the hash algorithm and canonicalization of a signature.
+func dkimParams(sig *dkim.Sig) (h crypto.Hash, headerSimple, bodySimple bool, err error) {
+	switch strings.ToLower(sig.AlgorithmHash) {
+	case "sha1":
+		h = crypto.SHA1
+	case "sha256":
+		h = crypto.SHA256
+	default:
+		return 0, false, false, fmt.Errorf("unknown hash algorithm %q", sig.AlgorithmHash)
+	}
+	hc, bc, _ := strings.Cut(strings.ToLower(sig.Canonicalization), "/")
+	return h, hc != "relaxed", bc != "relaxed", nil
+}
+
+func dkimCanonName(simple bool) string {
+	if simple {
+		return "simple"
+	}
+	return "relaxed"
+}
+
+// dkimHashedHeaders returns the headers of a signature in the order they are
+// hashed. Headers are taken from the bottom up, a name listed again takes the
+// next instance above. The signature header itself is hashed last, without b=
+// value and without trailing crlf.
+func dkimHashedHeaders(hdrs []dkimHeader, sig *dkim.Sig, sigHeader dkimHeader, simple bool) []DKIMDebugHeader {
+	l := []DKIMDebugHeader{}
+	used := map[int]bool{}
+	for _, name := range sig.SignedHeaders {
+		h := DKIMDebugHeader{Name: name, Missing: true}
+		for j := len(hdrs) - 1; j >= 0; j-- {
+			if !used[j] && hdrs[j].lkey == strings.ToLower(name) {
+				used[j] = true
+				h = DKIMDebugHeader{name, false, hdrs[j].raw, dkimCanonHeader(hdrs[j].raw, simple), dkimCanonHeader(hdrs[j].raw, !simple)}
+				break
+			}
+		}
+		l = append(l, h)
+	}
+	s := strings.TrimSuffix(dkimStripSignature(sigHeader.raw), "\r\n")
+	l = append(l, DKIMDebugHeader{sigHeader.key, false, sigHeader.raw, strings.TrimSuffix(dkimCanonHeader(s, simple), "\r\n"), strings.TrimSuffix(dkimCanonHeader(s, !simple), "\r\n")})
+	return l
+}
+
+// dkimTruncate truncates a canonicalized body to l= length, if present.
+func dkimTruncate(canon string, length int64) string {
+	if length >= 0 && length < int64(len(canon)) {
+		return canon[:length]
+	}
+	return canon
+}
+
+func dkimHash(h crypto.Hash, s string) string {
+	hh := h.New()
+	io.WriteString(hh, s)
+	return base64.StdEncoding.EncodeToString(hh.Sum(nil))
+}
+
+// dkimHeader is a header with continuation lines, including the crlf.
+type dkimHeader struct {
+	key  string
+	lkey string
+	raw  string
+}
+
+// dkimSplitMessage returns the headers and the body of a message with crlf line endings.
+func dkimSplitMessage(msg []byte) ([]dkimHeader, []byte) {
+	s := string(msg)
+	var hs, body string
+	if strings.HasPrefix(s, "\r\n") {
+		body = s[2:]
+	} else if i := strings.Index(s, "\r\n\r\n"); i >= 0 {
+		hs, body = s[:i+2], s[i+4:]
+	} else {
+		hs = s
+	}
+
+	var l []dkimHeader
+	for _, line := range strings.SplitAfter(hs, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(l) > 0 {
+			l[len(l)-1].raw += line
+			continue
+		}
+		key, _, _ := strings.Cut(line, ":")
+		key = strings.TrimRight(key, " \t")
+		l = append(l, dkimHeader{key, strings.ToLower(key), line})
+	}
+	return l, []byte(body)
+}
+
+// dkimCanonHeader canonicalizes a header, RFC 6376 section 3.4.1 and 3.4.2.
+func dkimCanonHeader(raw string, simple bool) string {
+	if simple {
+		return raw
+	}
+	k, v, _ := strings.Cut(raw, ":")
+	v = strings.ReplaceAll(v, "\r\n", "")
+	return strings.ToLower(strings.TrimRight(k, " \t")) + ":" + strings.Trim(dkimCollapseWSP(v), " \t") + "\r\n"
+}
+
+// dkimCollapseWSP replaces sequences of spaces and tabs with a single space.
+func dkimCollapseWSP(s string) string {
+	var b strings.Builder
+	var wsp bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\t' {
+			if !wsp {
+				b.WriteByte(' ')
+			}
+			wsp = true
+			continue
+		}
+		wsp = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// dkimCanonBody canonicalizes a body, RFC 6376 section 3.4.3 and 3.4.4.
+func dkimCanonBody(body []byte, simple bool) string {
+	lines := strings.Split(string(body), "\r\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if !simple {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(dkimCollapseWSP(line), " ")
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		if simple {
+			return "\r\n"
+		}
+		return ""
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// dkimStripSignature returns the DKIM-Signature header with the value of the b=
+// tag removed, as it is hashed.
+func dkimStripSignature(raw string) string {
+	t := strings.Split(raw, ";")
+	for i, s := range t {
+		k, _, ok := strings.Cut(s, "=")
+		if ok && strings.TrimSpace(strings.ReplaceAll(k, "\r\n", "")) == "b" {
+			t[i] = s[:len(k)+1]
+			if strings.HasSuffix(s, "\r\n") {
+				t[i] += "\r\n"
+			}
+		}
+	}
+	return strings.Join(t, ";")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+)
+
+// Commonly used DKIM selectors, by email providers, mail servers and services.
+// DKIM selectors cannot be enumerated through DNS, so we can only try.
+var dkimSelectors = []string{
+	"default", "dkim", "dkim1", "dkim2", "mail", "email", "smtp", "mx", "key1", "key2", "sig1",
+	"s1", "s2", "s1024", "s2048", "k1", "k2", "k3", "selector", "selector1", "selector2",
+	"google", "20161025", "20210112", "20230601", // Google Workspace and gmail.com.
+	"fm1", "fm2", "fm3", // Fastmail.
+	"protonmail", "protonmail2", "protonmail3", // Proton.
+	"zoho", "zmail", "mailjet", "mandrill", "mte1", "krs", "pm", "sendgrid", "smtpapi", "mxvault",
+	"everlytickey1", "everlytickey2", "cm", "turbo-smtp", "ovh", "mailo",
+}
+
+// dkimDateSelectors returns selectors as generated by mox quickstart, with the
+// year and a letter, for the past few years.
+func dkimDateSelectors(now time.Time) []string {
+	var l []string
+	for y := now.Year(); y > now.Year()-5; y-- {
+		l = append(l, fmt.Sprintf("%da", y), fmt.Sprintf("%db", y))
+	}
+	return l
+}
+
+// DKIMDiscovered is a DKIM record found for a selector.
+type DKIMDiscovered struct {
+	Selector  string
+	Status    string // Of the lookup, "neutral" if a record was found.
+	TXT       string
+	Record    *dkim.Record
+	KeyType   string   // "rsa" or "ed25519".
+	KeyBits   int      // Size of public key, 0 if revoked.
+	Flags     []string // From t=, "y" for testing mode, "s" for strict identity.
+	Revoked   bool     // Empty p=.
+	Authentic bool
+	Audit     []Finding // Of key strength and fields.
+	Error     string    // E.g. syntax error in record.
+}
+
+type DKIMDiscoverResult struct {
+	DurationMS int
+	Probed     int              // Number of selectors tried.
+	Wildcard   bool             // A random selector has a record too, found records may come from a wildcard.
+	Selectors  []DKIMDiscovered // Only selectors with a record.
+}
+
+func (API) DKIMDiscover(ctx context.Context, domain string) DKIMDiscoverResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	xlimit(ctx, &apiDomainLimiter)
+
+	log.Debug("dkimdiscover call", slog.String("domain", domain))
+
+	dom, err := dns.ParseDomain(domain)
+	xcheckuser(err, "parsing domain")
+
+	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return dkimDiscover(opctx, log, dom)
+}
+
+// dkimDiscover looks up the common selectors for the domain in parallel.
+func dkimDiscover(ctx context.Context, log mlog.Log, dom dns.Domain) (r DKIMDiscoverResult) {
+	start := time.Now()
+	defer func() {
+		r.DurationMS = timeSince(start)
+	}()
+
+	selectors := append(dkimDateSelectors(time.Now()), dkimSelectors...)
+	r.Probed = len(selectors)
+	r.Selectors = []DKIMDiscovered{}
+
+	// Probe a random selector too, to detect wildcard records.
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	randsel := fmt.Sprintf("moxtools-%x", buf)
+	selectors = append(selectors, randsel)
+
+	found := make([]*DKIMDiscovered, len(selectors))
+	var wg sync.WaitGroup
+	for i, s := range selectors {
+		wg.Add(1)
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+
+			sel, err := dns.ParseDomain(s)
+			if err != nil {
+				log.Errorx("parsing dkim selector", err, slog.String("selector", s))
+				return
+			}
+			status, record, txt, authentic, err := dkim.Lookup(ctx, log.Logger, resolver, sel, dom)
+			if errors.Is(err, dkim.ErrNoRecord) {
+				return
+			}
+			d := &DKIMDiscovered{Selector: s, Status: string(status), TXT: txt, Record: record, Authentic: authentic, Error: errmsg(err)}
+			if record != nil {
+				d.KeyType = record.Key
+				if d.KeyType == "" {
+					d.KeyType = "rsa"
+				}
+				d.Flags = record.Flags
+				d.Revoked = len(record.Pubkey) == 0
+				switch pk := record.PublicKey.(type) {
+				case *rsa.PublicKey:
+					d.KeyBits = pk.N.BitLen()
+				case ed25519.PublicKey:
+					d.KeyBits = 8 * len(pk)
+				}
+			}
+			if errors.Is(err, dkim.ErrSyntax) && txt == "" {
+				d.TXT = dkimSyntaxTXT(ctx, sel, dom)
+			}
+			if record != nil || errors.Is(err, dkim.ErrSyntax) {
+				d.Audit = dkimAudit(record, d.TXT, err)
+			}
+			found[i] = d
+		}()
+	}
+	wg.Wait()
+
+	r.Wildcard = found[len(found)-1] != nil
+	for _, d := range found[:len(found)-1] {
+		if d != nil {
+			r.Selectors = append(r.Selectors, *d)
+		}
+	}
+	return
+}
+
+func cmdDKIMDiscover(c *cmd) {
+	args := c.Parse()
+	if len(args) != 1 {
+		c.Usage()
+	}
+
+	dom, err := dns.ParseDomain(args[0])
+	xcmdcheck(err, "parsing domain")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	r := dkimDiscover(ctx, pkglog, dom)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(r)
+	xcmdcheck(err, "write result")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjl-/adns"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/dnsbl"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/spf"
+)
+
+var dnsblZonesList string
+var dnsblResolverAddr string
+
+var dnsblZones []dns.Domain
+var dnsblCustomResolver dns.Resolver // Set with dnsblResolverAddr.
+
+type DNSBLResult struct {
+	Zone   dns.Domain
+	Status string   // "pass" for not listed, "fail" for listed, or "temperror".
+	Codes  []string // IPs from A records for listed IP, e.g. 127.0.0.2, typically indicating the reason for the listing.
+	Reason string   // From TXT records for listed IP.
+	Error  string
+}
+
+type DNSBLIP struct {
+	IP      net.IP
+	Results []DNSBLResult
+}
+
+// Parse the flags for DNSBLs, called at startup.
+func dnsblInit() {
+	for _, s := range strings.Split(dnsblZonesList, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		zone, err := dns.ParseDomain(s)
+		xcheck(err, "parsing dnsbl zone")
+		dnsblZones = append(dnsblZones, zone)
+	}
+
+	// For testing against a local DNS server with stand-in zones.
+	if dnsblResolverAddr != "" {
+		dnsblCustomResolver = dns.StrictResolver{
+			Pkg: "dnsbl",
+			Resolver: &adns.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, dnsblResolverAddr)
+				},
+			},
+		}
+	}
+}
+
+func (API) DNSBLCheck(ctx context.Context, ipstr string) DNSBLIP {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("dnsblcheck call", slog.String("ip", ipstr))
+
+	ip := net.ParseIP(ipstr)
+	if ip == nil {
+		xcheckuser(fmt.Errorf("invalid ip %q", ipstr), "parsing ip")
+	}
+
+	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return dnsblCheck(opctx, log, []net.IP{ip})[0]
+}
+
+// dnsblCheck looks up all IPs in all configured DNSBLs, in parallel.
+func dnsblCheck(ctx context.Context, log mlog.Log, ips []net.IP) []DNSBLIP {
+	l := make([]DNSBLIP, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		l[i] = DNSBLIP{ip, make([]DNSBLResult, len(dnsblZones))}
+		for j, zone := range dnsblZones {
+			wg.Add(1)
+			go func() {
+				defer logPanic(log)
+				defer wg.Done()
+				l[i].Results[j] = dnsblLookup(ctx, log, zone, ip)
+			}()
+		}
+	}
+	wg.Wait()
+	return l
+}
+
+// dnsblResolverFor returns the resolver for dnsbl lookups. Unless a dnsbl
+// resolver is configured, this is the current package resolver, e.g. with zone
+// data for the ci subcommand.
+func dnsblResolverFor() dns.Resolver {
+	if dnsblCustomResolver != nil {
+		return dnsblCustomResolver
+	}
+	return resolver
+}
+
+func dnsblLookup(ctx context.Context, log mlog.Log, zone dns.Domain, ip net.IP) DNSBLResult {
+	dnsblResolver := dnsblResolverFor()
+	status, reason, err := dnsbl.Lookup(ctx, log.Logger, dnsblResolver, zone, ip)
+	r := DNSBLResult{Zone: zone, Status: string(status), Reason: reason, Error: errmsg(err)}
+	if status != dnsbl.StatusFail {
+		return r
+	}
+
+	// The dnsbl package doesn't return the A records, we look them up again, typically
+	// from cache.
+	ips, _, err := dnsblResolver.LookupIP(ctx, "ip4", dnsblName(zone, ip))
+	if err != nil {
+		r.Error = fmt.Sprintf("looking up return codes: %v", err)
+		return r
+	}
+	refused := len(ips) > 0
+	for _, rip := range ips {
+		r.Codes = append(r.Codes, rip.String())
+		refused = refused && strings.HasPrefix(rip.String(), "127.255.255.")
+	}
+	// Spamhaus returns 127.255.255.x for queries it refuses to answer, e.g. through
+	// public resolvers. They don't mean the IP is listed.
+	if refused {
+		r.Status = string(dnsbl.StatusTemperr)
+		r.Error = "dnsbl refused query, e.g. because it was sent through a public dns resolver"
+	}
+	return r
+}
+
+// dnsblName returns the name to look up for ip in zone, with reversed octets for
+// IPv4, and reversed nibbles for IPv6.
+func dnsblName(zone dns.Domain, ip net.IP) string {
+	var l []string
+	if ip4 := ip.To4(); ip4 != nil {
+		for i := len(ip4) - 1; i >= 0; i-- {
+			l = append(l, fmt.Sprintf("%d", ip4[i]))
+		}
+	} else {
+		ip16 := ip.To16()
+		for i := len(ip16) - 1; i >= 0; i-- {
+			l = append(l, fmt.Sprintf("%x", ip16[i]&0xf), fmt.Sprintf("%x", ip16[i]>>4))
+		}
+	}
+	return strings.Join(l, ".") + "." + zone.ASCII + "."
+}
+
+// spfIPs returns the single IPs from ip4 and ip6 mechanisms in an SPF record.
+// Ranges are skipped, listings are for individual IPs.
+func spfIPs(record *spf.Record) []net.IP {
+	var ips []net.IP
+	if record == nil {
+		return ips
+	}
+	for _, d := range record.Directives {
+		if d.Mechanism == "ip4" && (d.IP4CIDRLen == nil || *d.IP4CIDRLen == 32) || d.Mechanism == "ip6" && (d.IP6CIDRLen == nil || *d.IP6CIDRLen == 128) {
+			ips = append(ips, d.IP)
+		}
+	}
+	return ips
+}
+
+func cmdDNSBL(c *cmd) {
+	args := c.Parse()
+	if len(args) == 0 {
+		c.Usage()
+	}
+
+	var ips []net.IP
+	for _, s := range args {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			xcmdcheck(fmt.Errorf("invalid ip %q", s), "parsing ip")
+		}
+		ips = append(ips, ip)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	listed := false
+	for _, ipr := range dnsblCheck(ctx, pkglog, ips) {
+		for _, r := range ipr.Results {
+			fmt.Printf("%s\t%s\t%s", ipr.IP, r.Zone, r.Status)
+			if len(r.Codes) > 0 {
+				fmt.Printf("\t%s", strings.Join(r.Codes, ","))
+			}
+			if r.Reason != "" {
+				fmt.Printf("\t%s", r.Reason)
+			}
+			if r.Error != "" {
+				fmt.Printf("\terror: %s", r.Error)
+			}
+			fmt.Println()
+			listed = listed || r.Status == string(dnsbl.StatusFail)
+		}
+	}
+	if listed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+)
+
+// DSNRecipient is the delivery status of a recipient in a bounce message.
+type DSNRecipient struct {
+	FinalRecipient    string
+	OriginalRecipient string
+	Action            string // "failed", "delayed", "delivered", "relayed" or "expanded".
+	Status            string // Enhanced status code, e.g. "5.1.1".
+	StatusExplanation string
+	DiagnosticCode    string // Typically the SMTP reply of the remote server.
+	RemoteMTA         string
+	LastAttemptDate   string
+	WillRetryUntil    string
+}
+
+// DSN is a parsed delivery status notification, or bounce message.
+type DSN struct {
+	Format          string // "rfc3464" for a multipart/report with delivery-status part, "heuristic" if recipients were parsed from the text.
+	ReportingMTA    string
+	ArrivalDate     string
+	Recipients      []DSNRecipient
+	Text            string       // Human-readable text of the bounce, truncated.
+	OriginalHeaders []MIMEHeader // Of the bounced message, if included.
+}
+
+func (API) DSNParse(ctx context.Context, message []byte) DSN {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("dsnparse call")
+
+	dsn, err := dsnParse(log, xmessage(message))
+	xcheckuser(err, "parsing message")
+	return dsn
+}
+
+var (
+	dsnAddrRegexp  = regexp.MustCompile(`^\s*<?([^\s<>@"]+@[a-zA-Z0-9.-]+\.[a-zA-Z0-9-]+)>?(:|\s*$)`)
+	dsnReplyRegexp = regexp.MustCompile(`\b([245][0-9][0-9])[ :-]\s*(?:#?([245]\.[0-9]{1,3}\.[0-9]{1,3})\b)?`)
+	dsnHostRegexp  = regexp.MustCompile(`\bhost\s+([a-zA-Z0-9.-]+\.[a-zA-Z]+)\s*\[([0-9a-fA-F.:]+)\]`)
+	dsnCodeRegexp  = regexp.MustCompile(`\b([245]\.[0-9]{1,3}\.[0-9]{1,3})\b`)
+)
+
+// Lines in bounces without delivery-status part that precede the original
+// message or its headers. Exim, qmail, Yahoo and Microsoft.
+var dsnOriginalMarkers = []string{
+	"this is a copy of the message, including all the headers",
+	"below this line is a copy of the message",
+	"original message follows",
+	"original message headers",
+}
+
+// Maximum size of the human-readable text in a DSN.
+const dsnMaxText = 16 * 1024
+
+// dsnParse parses a bounce message. Bounces in the RFC 3464 format have a
+// machine-readable delivery-status part. For other bounces, recipients and SMTP
+// replies are searched for in the text.
+func dsnParse(log mlog.Log, msg []byte) (DSN, error) {
+	dsn := DSN{Recipients: []DSNRecipient{}, OriginalHeaders: []MIMEHeader{}}
+
+	p, err := message.Parse(log.Logger, false, bytes.NewReader(msg))
+	if err != nil {
+		return dsn, err
+	}
+	if err := p.Walk(log.Logger, nil); err != nil {
+		// Bounces often include truncated messages, we continue with what was parsed.
+		log.Debugx("walking message", err)
+	}
+
+	// First text/plain part for the human-readable text.
+	var text string
+	var walk func(p *message.Part) bool
+	walk = func(p *message.Part) bool {
+		if (p.MediaType == "" || p.MediaType == "TEXT") && (p.MediaSubType == "" || p.MediaSubType == "PLAIN") {
+			buf, err := io.ReadAll(io.LimitReader(p.ReaderUTF8OrBinary(), 1024*1024))
+			if err == nil {
+				text = strings.ReplaceAll(string(buf), "\r\n", "\n")
+			}
+			return true
+		}
+		for i := range p.Parts {
+			if walk(&p.Parts[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	if p.IsDSN() {
+		walk(&p.Parts[0])
+	} else {
+		walk(&p)
+	}
+	dsn.Text = text
+	if len(dsn.Text) > dsnMaxText {
+		dsn.Text = dsn.Text[:dsnMaxText] + "\n..."
+	}
+
+	if p.IsDSN() {
+		dsn.Format = "rfc3464"
+		buf, err := io.ReadAll(p.Parts[1].ReaderUTF8OrBinary())
+		if err != nil {
+			return dsn, fmt.Errorf("reading delivery-status part: %v", err)
+		}
+		dsnStatus(&dsn, string(buf))
+		if len(p.Parts) >= 3 {
+			dsn.OriginalHeaders = dsnPartHeaders(&p.Parts[2])
+		}
+		return dsn, nil
+	}
+
+	dsn.Format = "heuristic"
+	lines := strings.Split(text, "\n")
+	var r *DSNRecipient
+	for i, line := range lines {
+		if dsnOriginalMarker(line) {
+			lines = lines[i+1:]
+			break
+		}
+		if m := dsnAddrRegexp.FindStringSubmatch(line); m != nil {
+			dsn.Recipients = append(dsn.Recipients, DSNRecipient{FinalRecipient: m[1]})
+			r = &dsn.Recipients[len(dsn.Recipients)-1]
+			line = line[len(m[0]):]
+		}
+		if r == nil {
+			continue
+		}
+		if m := dsnHostRegexp.FindStringSubmatch(line); m != nil && r.RemoteMTA == "" {
+			r.RemoteMTA = m[1] + " [" + m[2] + "]"
+		}
+		if loc := dsnReplyRegexp.FindStringSubmatchIndex(line); loc != nil && r.DiagnosticCode == "" {
+			r.DiagnosticCode = strings.TrimSpace(line[loc[0]:])
+			code := line[loc[2]:loc[3]]
+			if loc[4] >= 0 {
+				r.Status = line[loc[4]:loc[5]]
+			} else {
+				r.Status = code[:1] + ".0.0"
+			}
+			switch code[0] {
+			case '4':
+				r.Action = "delayed"
+			case '5':
+				r.Action = "failed"
+			}
+		}
+	}
+	for i := range dsn.Recipients {
+		dsn.Recipients[i].StatusExplanation = enhancedCodeExplanation(dsn.Recipients[i].Status)
+	}
+
+	// Original message as attachment, or its headers after a marker in the text.
+	var original func(p *message.Part) bool
+	original = func(p *message.Part) bool {
+		if p.MediaType == "MESSAGE" && (p.MediaSubType == "RFC822" || p.MediaSubType == "GLOBAL" || p.MediaSubType == "GLOBAL-HEADERS") || p.MediaType == "TEXT" && p.MediaSubType == "RFC822-HEADERS" {
+			dsn.OriginalHeaders = dsnPartHeaders(p)
+			return true
+		}
+		for i := range p.Parts {
+			if original(&p.Parts[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	if !original(&p) && len(lines) < len(strings.Split(text, "\n")) {
+		for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+			lines = lines[1:]
+		}
+		var hdrs []string
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				break
+			}
+			hdrs = append(hdrs, line)
+		}
+		dsn.OriginalHeaders = dsnHeaders([]byte(strings.Join(hdrs, "\r\n") + "\r\n\r\n"))
+	}
+	return dsn, nil
+}
+
+func dsnOriginalMarker(line string) bool {
+	line = strings.ToLower(line)
+	for _, s := range dsnOriginalMarkers {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dsnStatus parses the fields of a delivery-status part, RFC 3464 section 2.
+// The first group has fields about the message, each following group is for a
+// recipient.
+func dsnStatus(dsn *DSN, s string) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var groups [][][2]string
+	var fields [][2]string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				groups = append(groups, fields)
+				fields = nil
+			}
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1][1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		k, v, _ := strings.Cut(line, ":")
+		fields = append(fields, [2]string{strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)})
+	}
+	if len(fields) > 0 {
+		groups = append(groups, fields)
+	}
+
+	// Value after the type, e.g. "rfc822; user@example.org" or "smtp; 550 ...".
+	typed := func(v string) string {
+		if _, s, ok := strings.Cut(v, ";"); ok {
+			return strings.TrimSpace(s)
+		}
+		return v
+	}
+
+	for i, g := range groups {
+		var r DSNRecipient
+		for _, f := range g {
+			k, v := f[0], f[1]
+			if i == 0 {
+				switch k {
+				case "reporting-mta":
+					dsn.ReportingMTA = typed(v)
+				case "arrival-date":
+					dsn.ArrivalDate = v
+				}
+				continue
+			}
+			switch k {
+			case "final-recipient":
+				r.FinalRecipient = typed(v)
+			case "original-recipient":
+				r.OriginalRecipient = typed(v)
+			case "action":
+				r.Action = strings.ToLower(v)
+			case "status":
+				r.Status, _, _ = strings.Cut(v, " ")
+			case "diagnostic-code":
+				r.DiagnosticCode = typed(v)
+			case "remote-mta":
+				r.RemoteMTA = typed(v)
+			case "last-attempt-date":
+				r.LastAttemptDate = v
+			case "will-retry-until":
+				r.WillRetryUntil = v
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		// Some MTAs only have the precise code in the diagnostic.
+		if m := dsnCodeRegexp.FindStringSubmatch(r.DiagnosticCode); m != nil && (r.Status == "" || strings.HasSuffix(r.Status, ".0.0")) {
+			r.Status = m[1]
+		}
+		r.StatusExplanation = enhancedCodeExplanation(r.Status)
+		dsn.Recipients = append(dsn.Recipients, r)
+	}
+}
+
+// dsnPartHeaders returns the headers of an embedded message, or of a
+// text/rfc822-headers part.
+func dsnPartHeaders(p *message.Part) []MIMEHeader {
+	if p.Message != nil {
+		buf, _ := io.ReadAll(p.Message.HeaderReader())
+		return dsnHeaders(buf)
+	}
+	buf, _ := io.ReadAll(p.Reader())
+	return dsnHeaders(buf)
+}
+
+// dsnHeaders parses a header section, with encoded-words decoded.
+func dsnHeaders(buf []byte) []MIMEHeader {
+	buf = messageCRLF(buf)
+	if !bytes.HasSuffix(buf, []byte("\r\n\r\n")) {
+		buf = append(bytes.TrimRight(buf, "\r\n"), "\r\n\r\n"...)
+	}
+	hdrs, _ := dkimSplitMessage(buf)
+	l := []MIMEHeader{}
+	for _, h := range hdrs {
+		_, v, ok := strings.Cut(h.raw, ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(strings.ReplaceAll(v, "\r\n", ""))
+		mh := MIMEHeader{Name: h.key, Value: v}
+		if s, err := mimeWordDecoder.DecodeHeader(v); err == nil && s != v {
+			mh.Decoded = s
+		}
+		l = append(l, mh)
+	}
+	return l
+}
+
+func cmdDSNParse(c *cmd) {
+	var index int
+	c.flag.IntVar(&index, "message", 0, "message to parse in mbox file, starting at 1")
+	args := c.Parse()
+	if len(args) > 1 {
+		c.Usage()
+	}
+	var file string
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	msgs, err := readMessages(file)
+	xcmdcheck(err, "reading message")
+
+	dsn, err := dsnParse(pkglog, xselectMessage(msgs, index))
+	xcmdcheck(err, "parsing message")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(dsn)
+	xcmdcheck(err, "write result")
+}
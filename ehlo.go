@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SMTPEHLO is a parsed EHLO response.
+type SMTPEHLO struct {
+	Hostname            string // From first line of the response.
+	Extensions          []SMTPExtension
+	Size                int64 // Maximum message size from SIZE, 0 if absent or without limit.
+	Pipelining          bool
+	Chunking            bool
+	DSN                 bool
+	EnhancedStatusCodes bool
+	StartTLS            bool
+	AuthMechanisms      []string // Upper case.
+	LimitRcptMax        int      // From LIMITS, RFC 9422, 0 if absent.
+	LimitMailMax        int
+	LimitRcptDomainMax  int
+}
+
+type SMTPExtension struct {
+	Keyword     string // Upper case.
+	Params      string
+	Explanation string // Empty for unknown extensions.
+}
+
+var smtpExtensionExplanations = map[string]string{
+	"SIZE":                "Maximum message size the server accepts, announced so clients don't send messages that would be rejected after transfer. RFC 1870.",
+	"PIPELINING":          "Client can send multiple commands without waiting for each response, saving round trips. RFC 2920.",
+	"CHUNKING":            "BDAT command for sending messages in chunks with a known size, instead of DATA with dot-stuffing. RFC 3030.",
+	"BINARYMIME":          "Messages with binary content, without line length limits or transfer encoding. Requires CHUNKING. RFC 3030.",
+	"8BITMIME":            "For sending messages that are not ASCII-only. RFC 6152.",
+	"SMTPUTF8":            "For sending messages with UTF-8 in addresses and message headers. Requires 8BITMIME. RFC 6531.",
+	"DSN":                 "Clients can request delivery status notifications for success, delay and failure per recipient. RFC 3461.",
+	"ENHANCEDSTATUSCODES": "Responses include structured status codes like 5.1.1, making errors easier to interpret by software. RFC 2034.",
+	"STARTTLS":            "For adding TLS to a plain text SMTP session. Must not be offered again after TLS is active. RFC 3207.",
+	"REQUIRETLS":          "For sending messages where verified TLS is required along the entire delivery path. RFC 8689.",
+	"AUTH":                "Authentication with SASL mechanisms. Used for submission, typically not needed on port 25. RFC 4954.",
+	"LIMITS":              "Announces limits on the number of recipients and transactions, so clients don't have to discover them through errors. RFC 9422.",
+	"DELIVERBY":           "Clients can request delivery within a time limit. RFC 2852.",
+	"MT-PRIORITY":         "Message transfer priorities. RFC 6710.",
+	"FUTURERELEASE":       "Clients can request delivery at a later time. RFC 4865.",
+	"ETRN":                "Remote request to start the queue for a domain. RFC 1985.",
+	"VRFY":                "Verify an address. Can reveal valid addresses to spammers, servers often respond without confirming. RFC 5321.",
+	"EXPN":                "Expand a mailing list. Can reveal list members to spammers. RFC 5321.",
+	"HELP":                "Help on commands. RFC 5321.",
+}
+
+// smtpEHLOs parses an SMTP trace for the server greeting hostname, and the EHLO
+// responses before and after STARTTLS.
+func smtpEHLOs(trace []Proto) (greeting string, ehlo, ehloTLS *SMTPEHLO) {
+	// Chunks in the trace are as read/written, we need lines.
+	type line struct {
+		client bool
+		text   string
+	}
+	var lines []line
+	for _, p := range trace {
+		for _, s := range strings.Split(p.Text, "\n") {
+			s = strings.TrimSuffix(s, "\r")
+			if s != "" {
+				lines = append(lines, line{p.ClientWrite, s})
+			}
+		}
+	}
+
+	var tls bool
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if !l.client {
+			if greeting == "" && strings.HasPrefix(l.text, "220") && len(l.text) > 4 {
+				if t := strings.Fields(l.text[4:]); len(t) > 0 {
+					greeting = t[0]
+				} else {
+					greeting = "(none)"
+				}
+			}
+			continue
+		}
+		cmd := strings.ToUpper(l.text)
+		if cmd == "STARTTLS" && i+1 < len(lines) && !lines[i+1].client && strings.HasPrefix(lines[i+1].text, "220") {
+			tls = true
+			continue
+		}
+		if !strings.HasPrefix(cmd, "EHLO ") {
+			continue
+		}
+		var resp []string
+		for i+1 < len(lines) && !lines[i+1].client && strings.HasPrefix(lines[i+1].text, "250") && len(lines[i+1].text) >= 4 {
+			i++
+			resp = append(resp, lines[i].text[4:])
+			if lines[i].text[3] == ' ' {
+				break
+			}
+		}
+		if len(resp) == 0 {
+			continue
+		}
+		e := parseEHLO(resp)
+		if tls && ehloTLS == nil {
+			ehloTLS = e
+		} else if !tls && ehlo == nil {
+			ehlo = e
+		}
+	}
+	return
+}
+
+// parseEHLO parses the lines of an EHLO response, without "250-" prefixes.
+func parseEHLO(resp []string) *SMTPEHLO {
+	e := &SMTPEHLO{Extensions: []SMTPExtension{}}
+	if t := strings.Fields(resp[0]); len(t) > 0 {
+		e.Hostname = t[0]
+	}
+	for _, s := range resp[1:] {
+		kw, params, _ := strings.Cut(strings.TrimSpace(s), " ")
+		kw = strings.ToUpper(kw)
+		params = strings.TrimSpace(params)
+		e.Extensions = append(e.Extensions, SMTPExtension{kw, params, smtpExtensionExplanations[kw]})
+		switch kw {
+		case "SIZE":
+			e.Size, _ = strconv.ParseInt(params, 10, 64)
+		case "PIPELINING":
+			e.Pipelining = true
+		case "CHUNKING":
+			e.Chunking = true
+		case "DSN":
+			e.DSN = true
+		case "ENHANCEDSTATUSCODES":
+			e.EnhancedStatusCodes = true
+		case "STARTTLS":
+			e.StartTLS = true
+		case "AUTH":
+			for _, m := range strings.Fields(params) {
+				m = strings.ToUpper(m)
+				if !slices.Contains(e.AuthMechanisms, m) {
+					e.AuthMechanisms = append(e.AuthMechanisms, m)
+				}
+			}
+		case "LIMITS":
+			for _, p := range strings.Fields(params) {
+				k, v, _ := strings.Cut(p, "=")
+				n, _ := strconv.Atoi(v)
+				switch strings.ToUpper(k) {
+				case "RCPTMAX":
+					e.LimitRcptMax = n
+				case "MAILMAX":
+					e.LimitMailMax = n
+				case "RCPTDOMAINMAX":
+					e.LimitRcptDomainMax = n
+				}
+			}
+		}
+	}
+	return e
+}
+
+func (e *SMTPEHLO) has(kw string) bool {
+	return slices.ContainsFunc(e.Extensions, func(x SMTPExtension) bool { return x.Keyword == kw })
+}
+
+// smtpEHLOWarnings returns warnings about risky or inconsistent extensions for an
+// SMTP server on port 25.
+func smtpEHLOWarnings(greeting string, ehlo, ehloTLS *SMTPEHLO) []string {
+	var l []string
+	if ehlo != nil {
+		if len(ehlo.AuthMechanisms) > 0 {
+			l = append(l, fmt.Sprintf("AUTH offered before STARTTLS (%s), clients could send credentials in plain text. MX hosts typically don't need AUTH, submission should be on port 465 or 587.", strings.Join(ehlo.AuthMechanisms, " ")))
+		}
+		if !ehlo.StartTLS {
+			l = append(l, "STARTTLS not offered, messages are delivered without encryption.")
+		}
+		if greeting != "" && ehlo.Hostname != "" && !strings.EqualFold(greeting, ehlo.Hostname) {
+			l = append(l, fmt.Sprintf("Hostname in greeting %q differs from hostname in EHLO response %q.", greeting, ehlo.Hostname))
+		}
+	}
+	if ehloTLS != nil {
+		if ehloTLS.StartTLS {
+			l = append(l, "STARTTLS offered again after TLS was established, not allowed by RFC 3207.")
+		}
+		if ehlo != nil && !strings.EqualFold(ehlo.Hostname, ehloTLS.Hostname) {
+			l = append(l, fmt.Sprintf("Hostname in EHLO response changed after STARTTLS, from %q to %q, possibly a middlebox intercepting TLS.", ehlo.Hostname, ehloTLS.Hostname))
+		}
+	}
+
+	// Remaining checks are for the extensions that apply to message delivery.
+	e := ehloTLS
+	if e == nil {
+		e = ehlo
+	}
+	if e == nil {
+		return l
+	}
+	if e.Size > 0 && e.Size < 10*1024*1024 {
+		l = append(l, fmt.Sprintf("SIZE limit of %.1f MB is low, larger messages, e.g. with attachments, are rejected.", float64(e.Size)/(1024*1024)))
+	}
+	if e.has("SMTPUTF8") && !e.has("8BITMIME") {
+		l = append(l, "SMTPUTF8 offered without 8BITMIME, required by RFC 6531.")
+	}
+	if e.has("BINARYMIME") && !e.Chunking {
+		l = append(l, "BINARYMIME offered without CHUNKING, required by RFC 3030.")
+	}
+	if e.LimitRcptMax > 0 && e.LimitRcptMax < 20 {
+		l = append(l, fmt.Sprintf("LIMITS RCPTMAX of %d is low, messages with more recipients need multiple transactions.", e.LimitRcptMax))
+	}
+	if e.has("EXPN") {
+		l = append(l, "EXPN offered, can reveal mailing list members.")
+	}
+	return l
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+)
+
+// Expectations is the expected state of a domain, e.g. for compliance checks.
+// Empty fields are not checked. In files, it is stored as JSON or YAML, with the
+// same field names.
+type Expectations struct {
+	Domain          string
+	DMARCPolicy     string   // E.g. "reject".
+	SPFAll          string   // The all mechanism in the SPF record, e.g. "-all" or "~all".
+	MX              []string // Exactly these MX hosts, in any order.
+	DANERequired    bool     // All MX hosts must have TLSA records.
+	MTASTSMode      string   // E.g. "enforce".
+	MTASTSMinMaxAge int      // Minimum max_age of the MTA-STS policy in seconds, e.g. 604800 for a week.
+	TLSRPT          bool     // A TLSRPT record must be present.
+	MinScore        int      // Minimum score of the domain grade.
+	DKIM            []ExpectedDKIM
+}
+
+type ExpectedDKIM struct {
+	Selector string
+	KeyType  string // "rsa" or "ed25519". Optional.
+}
+
+// ExpectationCheck is the evaluation of a single expectation.
+type ExpectationCheck struct {
+	Name     string
+	Expected string
+	Actual   string
+	OK       bool
+}
+
+type ExpectationsResult struct {
+	DurationMS int
+	Domain     dns.Domain
+	Checks     []ExpectationCheck
+	Violations int
+	Result     DomainResult
+}
+
+func (API) DomainExpect(ctx context.Context, exp Expectations) ExpectationsResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	xlimit(ctx, &apiDomainLimiter)
+
+	log.Debug("domainexpect call", slog.String("domain", exp.Domain))
+
+	dom, err := dns.ParseDomain(exp.Domain)
+	xcheckuser(err, "parsing domain")
+	var selectors []dns.Domain
+	for _, d := range exp.DKIM {
+		sel, err := dns.ParseDomain(d.Selector)
+		xcheckuser(err, "parsing dkim selector")
+		selectors = append(selectors, sel)
+	}
+
+	return domainExpect(ctx, log, dom, exp, selectors, false)
+}
+
+// domainExpect does a domain check and looks up the DKIM selectors, and compares
+// the results against the expectations.
+func domainExpect(ctx context.Context, log mlog.Log, dom dns.Domain, exp Expectations, selectors []dns.Domain, all bool) (r ExpectationsResult) {
+	start := time.Now()
+	r.Domain = dom
+	r.Checks = []ExpectationCheck{}
+
+	dkimActual := make([]string, len(selectors))
+	var wg sync.WaitGroup
+	for i, sel := range selectors {
+		wg.Add(1)
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+
+			opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+			defer cancel()
+
+			status, record, _, _, err := dkim.Lookup(opctx, log.Logger, resolver, sel, dom)
+			if err != nil {
+				dkimActual[i] = fmt.Sprintf("%s: %v", status, err)
+			} else if len(record.Pubkey) == 0 {
+				dkimActual[i] = "revoked"
+			} else if record.Key == "" {
+				dkimActual[i] = "rsa"
+			} else {
+				dkimActual[i] = record.Key
+			}
+		}()
+	}
+	r.Result = domainCheck(ctx, log, dom, all)
+	wg.Wait()
+	dr := r.Result
+
+	check := func(name, expected, actual string, ok bool) {
+		r.Checks = append(r.Checks, ExpectationCheck{name, expected, actual, ok})
+		if !ok {
+			r.Violations++
+		}
+	}
+
+	if exp.DMARCPolicy != "" {
+		policy := "no record"
+		if dr.DMARC.Record != nil {
+			policy = string(dr.DMARC.Record.Policy)
+		}
+		check("DMARC policy", exp.DMARCPolicy, policy, strings.EqualFold(policy, exp.DMARCPolicy))
+	}
+	if exp.SPFAll != "" {
+		all := "no record"
+		if dr.SPF.Record != nil {
+			all = "no all mechanism"
+			for _, d := range dr.SPF.Record.Directives {
+				if d.Mechanism == "all" {
+					all = d.Qualifier + "all"
+				}
+			}
+		}
+		check("SPF all", exp.SPFAll, all, all == exp.SPFAll || all == "all" && exp.SPFAll == "+all")
+	}
+	if exp.MX != nil {
+		var expected, actual []string
+		// Compared in ASCII form, expectations can have Unicode or IDNA names.
+		for _, s := range exp.MX {
+			s = strings.TrimSuffix(s, ".")
+			if d, err := dns.ParseDomain(s); err == nil {
+				s = d.ASCII
+			}
+			expected = append(expected, strings.ToLower(s))
+		}
+		for _, mx := range dr.MXHosts {
+			actual = append(actual, strings.ToLower(mx.Host.Domain.ASCII))
+		}
+		slices.Sort(expected)
+		slices.Sort(actual)
+		check("MX hosts", strings.Join(expected, ", "), strings.Join(actual, ", "), slices.Equal(expected, actual))
+	}
+	if exp.DANERequired {
+		var missing []string
+		for _, mx := range dr.MXHosts {
+			if !mx.DANE.Required {
+				missing = append(missing, mx.Host.String())
+			}
+		}
+		actual := "all mx hosts"
+		if len(dr.MXHosts) == 0 {
+			actual = "no mx hosts"
+		} else if len(missing) > 0 {
+			actual = "missing for " + strings.Join(missing, ", ")
+		}
+		check("DANE required", "all mx hosts", actual, len(dr.MXHosts) > 0 && len(missing) == 0)
+	}
+	if exp.MTASTSMode != "" {
+		mode := "no policy"
+		if dr.MTASTS.Policy != nil {
+			mode = string(dr.MTASTS.Policy.Mode)
+		}
+		check("MTA-STS mode", exp.MTASTSMode, mode, strings.EqualFold(mode, exp.MTASTSMode))
+	}
+	if exp.MTASTSMinMaxAge > 0 {
+		actual := "no policy"
+		var ok bool
+		if dr.MTASTS.Policy != nil {
+			actual = fmt.Sprintf("%ds", dr.MTASTS.Policy.MaxAgeSeconds)
+			ok = dr.MTASTS.Policy.MaxAgeSeconds >= exp.MTASTSMinMaxAge
+		}
+		check("MTA-STS max_age", fmt.Sprintf(">= %ds", exp.MTASTSMinMaxAge), actual, ok)
+	}
+	if exp.TLSRPT {
+		actual := "present"
+		if dr.TLSRPT.Error != "" {
+			actual = dr.TLSRPT.Error
+		} else if dr.TLSRPT.Record == nil {
+			actual = "no record"
+		}
+		check("TLSRPT record", "present", actual, dr.TLSRPT.Record != nil)
+	}
+	if exp.MinScore > 0 {
+		check("Score", fmt.Sprintf(">= %d", exp.MinScore), fmt.Sprintf("%d (%s)", dr.Grade.Score, dr.Grade.Grade), dr.Grade.Score >= exp.MinScore)
+	}
+	for i, d := range exp.DKIM {
+		expected := "present"
+		ok := dkimActual[i] == "rsa" || dkimActual[i] == "ed25519"
+		if d.KeyType != "" {
+			expected = d.KeyType
+			ok = strings.EqualFold(dkimActual[i], d.KeyType)
+		}
+		check("DKIM selector "+d.Selector, expected, dkimActual[i], ok)
+	}
+
+	r.DurationMS = timeSince(start)
+	return
+}
+
+// parseExpectations parses JSON, or YAML for files ending in .yaml or .yml.
+// YAML is converted to JSON first, so the same field names and checks for
+// unknown fields apply.
+func parseExpectations(file string, buf []byte) (exp Expectations, err error) {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		var v any
+		if err := yaml.Unmarshal(buf, &v); err != nil {
+			return exp, err
+		}
+		buf, err = json.Marshal(v)
+		if err != nil {
+			return exp, fmt.Errorf("converting yaml to json: %v", err)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&exp)
+	return
+}
+
+func cmdExpect(c *cmd) {
+	var all, jsonOutput bool
+	c.flag.BoolVar(&all, "all", false, "connect to all mx hosts, and to each of their ips separately")
+	c.flag.BoolVar(&jsonOutput, "json", false, "print results as json, including the full domain check results")
+	args := c.Parse()
+	if len(args) == 0 {
+		c.Usage()
+	}
+
+	// Each file has the expectations for a domain.
+	var results []ExpectationsResult
+	violations := 0
+	for _, file := range args {
+		buf, err := os.ReadFile(file)
+		xcmdcheck(err, "read expectations file")
+		exp, err := parseExpectations(file, buf)
+		xcmdcheck(err, "parsing expectations file "+file)
+
+		dom, err := dns.ParseDomain(exp.Domain)
+		xcmdcheck(err, "parsing domain in "+file)
+		var selectors []dns.Domain
+		for _, d := range exp.DKIM {
+			sel, err := dns.ParseDomain(d.Selector)
+			xcmdcheck(err, "parsing dkim selector in "+file)
+			selectors = append(selectors, sel)
+		}
+
+		r := domainExpect(context.Background(), pkglog, dom, exp, selectors, all)
+		violations += r.Violations
+		results = append(results, r)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		err := enc.Encode(results)
+		xcmdcheck(err, "write results")
+	} else {
+		for _, r := range results {
+			for _, c := range r.Checks {
+				status := "ok"
+				if !c.OK {
+					status = "violation"
+				}
+				fmt.Printf("%s\t%s\t%s\texpected %s\tactual %s\n", r.Domain.Name(), status, c.Name, c.Expected, c.Actual)
+			}
+		}
+	}
+	if violations > 0 {
+		os.Exit(1)
+	}
+}
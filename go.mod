@@ -12,6 +12,7 @@ require (
 	github.com/mjl-/sherpaprom v0.0.2
 	github.com/mjl-/sherpats v0.0.6
 	github.com/prometheus/client_golang v1.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
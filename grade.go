@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mjl-/mox/dmarc"
+	"github.com/mjl-/mox/mtasts"
+)
+
+// DomainGrade is a summary of the domain check, with a score and the findings
+// that lowered it.
+type DomainGrade struct {
+	Score    int    // 0-100.
+	Grade    string // "A" (90 and higher) to "F" (below 60).
+	Findings []Finding
+}
+
+type Finding struct {
+	Check       string // ID of the check, e.g. "spf.all" or "mx[0].starttls".
+	Severity    string // "error", "warning" or "info".
+	Title       string
+	Explanation string
+	Remediation string
+	Points      int // Deducted from the score.
+}
+
+// domainGrade calculates the score for a domain check result. Each finding
+// deducts points, the total is not an exact science, but gives an indication of
+// how well a domain is protected, and what to do about it.
+func domainGrade(dr DomainResult) DomainGrade {
+	g := DomainGrade{Findings: []Finding{}}
+	add := func(check, severity string, points int, title, explanation, remediation string) {
+		g.Findings = append(g.Findings, Finding{check, severity, title, explanation, remediation, points})
+	}
+
+	// SPF.
+	if dr.SPF.Record == nil && dr.SPF.Status == "none" {
+		add("spf.record-valid", "error", 15, "No SPF record", "Without SPF, receivers cannot check which IPs are allowed to send email for the domain, and DMARC can only rely on DKIM.", "Add a TXT record starting with v=spf1 listing the IPs/hosts that send email for the domain, ending with -all or ~all.")
+	} else if dr.SPF.Record == nil {
+		add("spf.record-valid", "error", 15, "SPF lookup failed", "The SPF record could not be evaluated: "+dr.SPF.Error, "Fix the SPF record, there must be exactly one valid TXT record starting with v=spf1.")
+	} else {
+		var all string
+		for _, d := range dr.SPF.Record.Directives {
+			if d.Mechanism == "all" {
+				all = d.Qualifier
+				if all == "" {
+					all = "+"
+				}
+			}
+		}
+		switch {
+		case all == "+":
+			add("spf.all", "error", 25, "SPF allows all IPs", "The SPF record ends with +all, any IP is allowed to send email for the domain.", "Replace +all with -all or ~all.")
+		case all == "?":
+			add("spf.all", "warning", 10, "SPF is neutral for other IPs", "The SPF record ends with ?all, the result for unlisted IPs is neutral, which provides no protection.", "Replace ?all with -all or ~all.")
+		case all == "~":
+			add("spf.all", "info", 2, "SPF softfail for other IPs", "The SPF record ends with ~all, unlisted IPs get a softfail. Messages are typically still accepted, DMARC policy decides.", "Once all sending IPs are listed, consider -all.")
+		case all == "" && dr.SPF.Record.Redirect == "":
+			add("spf.all", "warning", 10, "SPF record without all", "The SPF record has no all mechanism and no redirect, the result for unlisted IPs is neutral.", "End the SPF record with -all or ~all.")
+		}
+	}
+	if dr.SPF.Record != nil && dr.SPF.Lookups > spfLookupLimit {
+		add("spf.lookup-limit", "error", 15, "SPF record exceeds DNS lookup limit", fmt.Sprintf("Evaluating the SPF record needs at least %d DNS lookups, for include, a, mx, ptr, exists and redirect, including nested records. The maximum is %d, receivers evaluate to permerror, failing SPF for all messages.", dr.SPF.Lookups, spfLookupLimit), "Replace include, a and mx mechanisms with ip4 and ip6 mechanisms, or remove includes of services no longer used.")
+	}
+	if dr.SPF.LookupsErr != "" {
+		add("spf.includes-valid", "error", 10, "SPF record includes invalid record", "A record referenced through include or redirect cannot be used: "+dr.SPF.LookupsErr+". Receivers evaluate to permerror when they reach it.", "Fix or remove the include or redirect.")
+	}
+
+	// DMARC.
+	if dr.DMARC.Record == nil && dr.DMARC.Status == string(dmarc.StatusNone) {
+		add("dmarc.record-valid", "error", 20, "No DMARC record", "Without DMARC, receivers have no policy for messages that fail SPF and DKIM, making it easy to spoof the domain in the From header.", "Add a TXT record at _dmarc.<domain>, start with v=DMARC1; p=none; rua=mailto:... to receive reports, and move to p=quarantine or p=reject.")
+	} else if dr.DMARC.Record == nil {
+		add("dmarc.record-valid", "error", 20, "DMARC lookup failed", "The DMARC record could not be evaluated: "+dr.DMARC.Error, "Fix the DMARC record at _dmarc.<domain>.")
+	} else {
+		r := dr.DMARC.Record
+		switch r.Policy {
+		case dmarc.PolicyNone:
+			add("dmarc.policy", "warning", 15, "DMARC policy none", "With p=none, receivers don't reject or quarantine messages that fail DMARC, the record is only used for reporting.", "After checking the aggregate reports for legitimate sources, move to p=quarantine and then p=reject.")
+		case dmarc.PolicyQuarantine:
+			add("dmarc.policy", "info", 3, "DMARC policy quarantine", "With p=quarantine, messages that fail DMARC typically end up in the spam folder.", "Consider p=reject.")
+		}
+		if r.Policy != dmarc.PolicyNone && r.Percentage < 100 {
+			add("dmarc.pct", "warning", 5, "DMARC policy applies partially", fmt.Sprintf("With pct=%d, the policy is only applied to part of the failing messages.", r.Percentage), "Remove pct, or set it to 100.")
+		}
+		if len(r.AggregateReportAddresses) == 0 {
+			add("dmarc.rua", "info", 2, "No DMARC aggregate reports", "Without rua, you don't learn which sources send email for the domain, and whether they pass DMARC.", "Add rua=mailto:... to the DMARC record.")
+		}
+	}
+
+	// MTA-STS.
+	if !dr.MTASTS.Implemented {
+		add("mtasts.record-valid", "warning", 10, "No MTA-STS", "Without MTA-STS (or DANE), delivery to the domain is not protected against attackers that intercept or downgrade TLS.", "Add a TXT record at _mta-sts.<domain> and serve a policy at https://mta-sts.<domain>/.well-known/mta-sts.txt.")
+	} else if dr.MTASTS.Policy == nil {
+		add("mtasts.record-valid", "error", 10, "MTA-STS broken", "The MTA-STS record or policy could not be used: "+dr.MTASTS.Error, "Fix the MTA-STS record and policy.")
+	} else if dr.MTASTS.Policy.Mode == mtasts.ModeTesting {
+		add("mtasts.mode", "warning", 5, "MTA-STS in testing mode", "In testing mode, senders only report TLS failures, they still deliver without verified TLS.", "After checking TLS reports, switch the policy to mode: enforce.")
+	} else if dr.MTASTS.Policy.Mode == mtasts.ModeNone {
+		add("mtasts.mode", "warning", 10, "MTA-STS mode none", "The MTA-STS policy has mode none, it is disabled.", "Switch the policy to mode: testing, and then mode: enforce.")
+	}
+
+	// TLSRPT.
+	if dr.TLSRPT.Record == nil {
+		add("tlsrpt.record-valid", "info", 3, "No TLSRPT", "Without TLSRPT, you don't get reports about TLS failures when others deliver to your MX hosts, e.g. expired certificates.", "Add a TXT record at _smtp._tls.<domain> with v=TLSRPTv1; rua=mailto:....")
+	}
+
+	// MX and DANE.
+	if dr.MX.Error != "" {
+		add("mx.lookup", "error", 30, "MX lookup failed", "The MX records could not be looked up: "+dr.MX.Error, "Fix the MX records in DNS.")
+	}
+	if dr.MX.Error == "" && !dr.MX.OrigNextHopAuthentic {
+		add("mx.dnssec", "warning", 5, "No DNSSEC", "The MX records are not protected with DNSSEC, so DANE cannot be used to protect delivery.", "Enable DNSSEC for the domain, and add TLSA records for the MX hosts.")
+	}
+	for i, mx := range dr.MXHosts {
+		host := mx.Host.String()
+		mxcheck := func(check string) string {
+			return fmt.Sprintf("mx[%d].%s", i, check)
+		}
+		if mx.MTASTSError != "" {
+			add(mxcheck("mtasts-match"), "error", 15, "MX host "+host+" not in MTA-STS policy", "Senders that enforce MTA-STS will not deliver to this MX host.", "Add the MX host to the mx lines of the MTA-STS policy, or fix the MX records.")
+		}
+		if mx.DANE.WithoutDNSSEC {
+			add(mxcheck("dane-dnssec"), "warning", 5, "DANE without DNSSEC for MX host "+host, "The MX host has TLSA records, but the MX records or the IPs of the MX host are not protected with DNSSEC. Senders ignore the TLSA records, delivery is not protected with DANE.", "Enable DNSSEC for the domain and the zone of the MX host, or remove the TLSA records.")
+		}
+		if dr.MX.OrigNextHopAuthentic && mx.IP.Authentic && !mx.DANE.Required && mx.DANE.Error == "" {
+			add(mxcheck("dane"), "info", 3, "No DANE for MX host "+host, "The MX records are protected with DNSSEC, but the MX host has no TLSA records.", "Add TLSA records at _25._tcp.<mxhost>, e.g. of type 3 1 1 for the public key of the certificate.")
+		}
+		for _, ipr := range mx.DNSBL {
+			for _, r := range ipr.Results {
+				if r.Status == "fail" {
+					add(mxcheck("dnsbl"), "error", 10, fmt.Sprintf("MX host %s IP %s listed in %s", host, ipr.IP, r.Zone), "Listed IPs often have their outgoing messages rejected. "+r.Reason, "Find out why the IP was listed, fix the cause, and request delisting.")
+				}
+			}
+		}
+		for _, r := range mx.IPRev {
+			if r.Status != "pass" && r.Status != "temperror" {
+				add(mxcheck("iprev"), "warning", 3, fmt.Sprintf("No reverse DNS for MX host %s IP %s", host, r.IP), "The IP has no PTR record that resolves back to it. Receivers penalize messages from such IPs, if the MX host also sends.", "Add a PTR record for the IP, pointing to a name that resolves to the IP.")
+			}
+		}
+
+		// With all IPs checked, each IP has its own connection. A check deducts points
+		// once per MX host, for the first IP that fails it.
+		conns := []DomainMXIP{{mx.Dial.IP, mx.Dial, mx.SMTP, mx.DANE.VerifiedRecord}}
+		if len(mx.IPResults) > 0 {
+			conns = mx.IPResults
+		}
+		added := map[string]bool{}
+		for _, c := range conns {
+			var prefix string
+			if len(mx.IPResults) > 0 {
+				prefix = fmt.Sprintf("IP %s: ", c.IP)
+			}
+			addMX := func(check, severity string, points int, title, explanation, remediation string) {
+				if !added[check] {
+					added[check] = true
+					add(mxcheck(check), severity, points, title, prefix+explanation, remediation)
+				}
+			}
+			if c.Dial.IP == nil || c.Dial.Error != "" {
+				if c.Dial.Error != "" {
+					addMX("dial", "error", 15, "Cannot connect to MX host "+host, c.Dial.Error, "Make sure the MX host accepts connections on port 25 on all its IPs.")
+				}
+				continue
+			}
+			if c.SMTP.Error != "" {
+				addMX("smtp", "error", 15, "SMTP session with MX host "+host+" failed", c.SMTP.Error, "Fix the SMTP server, including its TLS configuration.")
+				continue
+			}
+			if mx.DANE.Required && len(c.DANEVerifiedRecord.CertAssoc) == 0 {
+				addMX("dane-verified", "error", 20, "DANE verification failed for MX host "+host, "The TLS certificate did not match the TLSA records, senders that implement DANE will not deliver.", "Update the TLSA records for the current certificate, publishing records for the new key before rolling over.")
+			}
+			if !c.SMTP.SupportsSTARTTLS {
+				addMX("starttls", "error", 20, "No STARTTLS for MX host "+host, "Messages are delivered to this MX host without encryption.", "Configure a TLS certificate for the SMTP server.")
+			} else if cs := c.SMTP.TLSConnectionState; cs != nil && (cs.Version == "TLS 1.0" || cs.Version == "TLS 1.1") {
+				addMX("tls-version", "warning", 10, "Legacy TLS for MX host "+host, "The connection used "+cs.Version+", which is deprecated.", "Enable TLS 1.2 and TLS 1.3 in the SMTP server.")
+			}
+			for _, w := range c.SMTP.Warnings {
+				if !added["ehlo:"+w] {
+					added["ehlo:"+w] = true
+					add(mxcheck("ehlo"), "info", 0, "MX host "+host+" EHLO", prefix+w, "")
+				}
+			}
+		}
+		if mx.Parity != nil && len(mx.Parity.Differences) > 0 {
+			add(mxcheck("parity"), "warning", 5, "IPv4 and IPv6 differ for MX host "+host, fmt.Sprintf("%d differences between the IPv4 and IPv6 SMTP sessions.", len(mx.Parity.Differences)), "Configure the servers for both address families the same.")
+		}
+	}
+
+	g.Score = 100
+	for _, f := range g.Findings {
+		g.Score -= f.Points
+	}
+	g.Score = max(g.Score, 0)
+	switch {
+	case g.Score >= 90:
+		g.Grade = "A"
+	case g.Score >= 80:
+		g.Grade = "B"
+	case g.Score >= 70:
+		g.Grade = "C"
+	case g.Score >= 60:
+		g.Grade = "D"
+	default:
+		g.Grade = "F"
+	}
+	return g
+}
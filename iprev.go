@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/iprev"
+)
+
+type IPRevResult struct {
+	DurationMS int
+	IP         net.IP
+	Status     string   // "pass", "fail" (PTR names don't resolve back to IP), "temperror" or "permerror" (e.g. no PTR record).
+	Name       string   // First name from PTR records that resolves back to the IP.
+	Names      []string // All names from PTR records.
+	Authentic  bool
+	EHLO       string // Hostname from EHLO, if known.
+	EHLOMatch  bool   // Whether EHLO is the same as the forward-confirmed name.
+	Error      string
+}
+
+func (API) IPRevCheck(ctx context.Context, ipstr string) IPRevResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("iprevcheck call", slog.String("ip", ipstr))
+
+	ip := net.ParseIP(ipstr)
+	if ip == nil {
+		xcheckuser(fmt.Errorf("invalid ip %q", ipstr), "parsing ip")
+	}
+
+	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return iprevCheck(opctx, ip, "")
+}
+
+// iprevCheck looks up the PTR records for ip, and checks which of the names
+// resolve back to ip. If ehlo is not empty, it is compared with the
+// forward-confirmed name.
+func iprevCheck(ctx context.Context, ip net.IP, ehlo string) IPRevResult {
+	t0 := time.Now()
+	status, name, names, authentic, err := iprev.Lookup(ctx, resolver, ip)
+	r := IPRevResult{timeSince(t0), ip, string(status), name, names, authentic, "", false, errmsg(err)}
+	iprevEHLO(&r, ehlo)
+	return r
+}
+
+func iprevEHLO(r *IPRevResult, ehlo string) {
+	r.EHLO = ehlo
+	r.EHLOMatch = ehlo != "" && r.Name != "" && strings.EqualFold(strings.TrimSuffix(ehlo, "."), strings.TrimSuffix(r.Name, "."))
+}
@@ -3,9 +3,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"embed"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -21,6 +24,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -83,7 +87,7 @@ var smtpDialLimiter = ratelimit.Limiter{
 //go:embed s/*
 var files embed.FS
 
-var resolver = dns.StrictResolver{}
+var resolver dns.Resolver = dns.StrictResolver{}
 
 func xcheck(err error, msg string) {
 	if err != nil {
@@ -120,19 +124,39 @@ func main() {
 	flag.StringVar(&listen, "listen", ":8080", "address for serve http")
 	flag.StringVar(&listenMetrics, "listen-metrics", ":8081", "address for serving prometheus metrics over http")
 	flag.StringVar(&hostname, "hostname", hostname, "hostname to use when dialing smtp server")
+	flag.BoolVar(&authTest, "authtest", false, "enable api for testing smtp authentication with username/password at submission servers")
+	flag.BoolVar(&testDelivery, "testdelivery", false, "enable api for delivering test messages to addresses")
+	flag.StringVar(&testDeliveryFrom, "testdelivery-from", "", "address to send test messages from, default moxtools@<hostname>")
+	flag.StringVar(&testDeliveryDKIMSelector, "dkim-selector", "", "dkim selector for signing test messages, for the domain of the from address")
+	flag.StringVar(&testDeliveryDKIMKey, "dkim-key", "", "file with pem-encoded private key for signing test messages")
+	flag.StringVar(&reflectorListen, "reflector-listen", "", "if set, address to accept smtp connections for messages to check-<token>@<hostname>, e.g. :25")
+	flag.StringVar(&reflectorCertFile, "reflector-cert", "", "file with pem-encoded certificate for starttls in reflector smtp server")
+	flag.StringVar(&reflectorKeyFile, "reflector-key", "", "file with pem-encoded private key for starttls in reflector smtp server")
+	flag.StringVar(&dnsblZonesList, "dnsbl", "zen.spamhaus.org,b.barracudacentral.org,bl.spamcop.net", "comma-separated dns blocklist zones to check ips against")
+	flag.BoolVar(&addressProbe, "addressprobe", false, "enable api for probing addresses with rcpt to at mx hosts, without delivering messages")
+	flag.BoolVar(&tlsScan, "tlsscan", false, "enable api for scanning tls versions and cipher suites of mx hosts, making many connections")
+	flag.StringVar(&dnsblResolverAddr, "dnsbl-resolver", "", "if set, address of dns server (ip:port) to use for dnsbl lookups instead of the system resolver, e.g. a local stand-in for testing")
 	flag.Usage = func() {
 		fmt.Println("usage: moxtools [flags]")
+		for _, c := range cmds {
+			fmt.Printf("       moxtools [flags] %s %s\n", c.name, c.params)
+		}
 		flag.PrintDefaults()
 		os.Exit(2)
 	}
 	flag.Parse()
 	args := flag.Args()
-	if len(args) != 0 {
-		flag.Usage()
-	}
 
 	dnsHostname, err = dns.ParseDomain(hostname)
 	xcheck(err, "parsing hostname")
+	testDeliveryInit()
+	reflectorInit()
+	dnsblInit()
+
+	if len(args) != 0 {
+		runCmd(args)
+		return
+	}
 
 	var docs sherpadoc.Section
 	f, err := files.Open("s/api.json")
@@ -150,7 +174,8 @@ func main() {
 		GoVersion string
 		GoOs      string
 		GoArch    string
-	}{version, goversion, runtime.GOOS, runtime.GOARCH}
+		Reflector bool
+	}{version, goversion, runtime.GOOS, runtime.GOARCH, reflectorListen != ""}
 	metaBuf, err = json.Marshal(meta)
 	xcheck(err, "marshal meta")
 
@@ -220,6 +245,12 @@ func main() {
 			}
 		}
 		r = r.WithContext(context.WithValue(r.Context(), keyIP, ip))
+		// Room for base64-encoded messages, only for functions that take one.
+		maxSize := int64(maxAPIRequestSize)
+		if apiMessageFunctions[strings.TrimPrefix(r.URL.Path, "/api/")] {
+			maxSize = 2 * maxMessageSize
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
 		apiHandler.ServeHTTP(w, r)
 	})
 
@@ -238,12 +269,17 @@ func main() {
 	pkglog.Print("serving",
 		slog.String("listen", listen),
 		slog.String("listenmetrics", listenMetrics),
+		slog.String("reflectorlisten", reflectorListen),
 		slog.Any("hostname", dnsHostname),
 		slog.String("version", version),
 		slog.String("goversion", goversion),
 		slog.String("goos", runtime.GOOS),
 		slog.String("goarch", runtime.GOARCH))
 
+	if reflectorListen != "" {
+		go reflectorServe()
+	}
+
 	if listenMetrics != "" {
 		go func() {
 			metrics := http.NewServeMux()
@@ -304,13 +340,24 @@ type ctxKey string
 var keyIP ctxKey = "ip"
 
 func xlimit(ctx context.Context, r *ratelimit.Limiter) {
-	if !ratelimiter {
-		return
+	if limited(ctx, r) {
+		xcheckuser(errors.New("too many requests from ip or subnet in window, try again soon"), "rate limiter", slog.Any("ip", ctx.Value(keyIP)))
 	}
-	ip := ctx.Value(keyIP).(net.IP)
-	if ip == nil || !r.Add(ip, time.Now(), 1) {
-		xcheckuser(errors.New("too many requests from ip or subnet in window, try again soon"), "rate limiter", slog.Any("ip", ip))
+}
+
+// limited returns whether the request exceeds the rate limit, adding it to the
+// limiter if not.
+func limited(ctx context.Context, r *ratelimit.Limiter) bool {
+	return limitedN(ctx, r, 1)
+}
+
+// limitedN is like limited, for n requests at once.
+func limitedN(ctx context.Context, r *ratelimit.Limiter, n int64) bool {
+	if !ratelimiter {
+		return false
 	}
+	ip, _ := ctx.Value(keyIP).(net.IP)
+	return ip == nil || !r.Add(ip, time.Now(), n)
 }
 
 type SPFReceived struct {
@@ -349,7 +396,8 @@ func (API) SPFCheck(ctx context.Context, domain, ipstr string) (received SPFRece
 
 type DKIMStatus string
 
-func (API) DKIMLookup(ctx context.Context, selector, domain string) (status DKIMStatus, record *dkim.Record, txt string, authentic bool) {
+// DKIMLookup looks up a DKIM record, and audits the key and fields.
+func (API) DKIMLookup(ctx context.Context, selector, domain string) (status DKIMStatus, record *dkim.Record, txt string, authentic bool, audit []Finding) {
 	log := newLog()
 
 	xlimit(ctx, &apiLimiter)
@@ -366,8 +414,14 @@ func (API) DKIMLookup(ctx context.Context, selector, domain string) (status DKIM
 
 	var xstatus dkim.Status
 	xstatus, record, txt, authentic, err = dkim.Lookup(opctx, log.Logger, resolver, sel, dom)
-	xcheckuser(err, "looking up dkim record")
+	if !errors.Is(err, dkim.ErrSyntax) {
+		xcheckuser(err, "looking up dkim record")
+	} else if txt == "" {
+		txt = dkimSyntaxTXT(opctx, sel, dom)
+	}
 	status = DKIMStatus(string(xstatus))
+	// Syntax errors are part of the audit, e.g. caused by incorrectly splitting the TXT record.
+	audit = dkimAudit(record, txt, err)
 
 	return
 }
@@ -380,7 +434,7 @@ type DKIMResult struct {
 	Error           string       // If Status is not StatusPass, this error holds the details and can be checked using errors.Is.
 }
 
-func (API) DKIMVerify(ctx context.Context, message string) []DKIMResult {
+func (API) DKIMVerify(ctx context.Context, message []byte) []DKIMResult {
 	log := newLog()
 
 	xlimit(ctx, &apiLimiter)
@@ -390,27 +444,65 @@ func (API) DKIMVerify(ctx context.Context, message string) []DKIMResult {
 	opctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	message = strings.ReplaceAll(message, "\n", "\r\n")
-	results, err := dkim.Verify(opctx, log.Logger, resolver, true, dkim.DefaultPolicy, strings.NewReader(message), false)
+	message = xmessage(message)
+	results, err := dkim.Verify(opctx, log.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(message), false)
 	xcheckuser(err, "verifying dkim signatures in message")
+	return dkimResults(results)
+}
 
+func dkimResults(results []dkim.Result) []DKIMResult {
 	l := make([]DKIMResult, len(results))
 	for i, r := range results {
-		var errmsg string
-		if r.Err != nil {
-			errmsg = r.Err.Error()
-		}
 		l[i] = DKIMResult{
 			Status:          DKIMStatus(string(r.Status)),
 			Sig:             r.Sig,
 			Record:          r.Record,
 			RecordAuthentic: r.RecordAuthentic,
-			Error:           errmsg,
+			Error:           errmsg(r.Err),
 		}
 	}
 	return l
 }
 
+func cmdDKIMVerify(c *cmd) {
+	var debug, explain bool
+	var index int
+	c.flag.BoolVar(&debug, "debug", false, "show canonicalized headers and body, and computed body hashes")
+	c.flag.BoolVar(&explain, "explain", false, "try to explain failed signatures by reversing common changes by intermediaries")
+	c.flag.IntVar(&index, "message", 0, "message to verify in mbox file, starting at 1")
+	args := c.Parse()
+	if len(args) > 1 || debug && explain {
+		c.Usage()
+	}
+	var file string
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	msgs, err := readMessages(file)
+	xcmdcheck(err, "reading message")
+	msg := xselectMessage(msgs, index)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var r any
+	if debug {
+		r, err = dkimDebug(ctx, pkglog, msg)
+	} else if explain {
+		r, err = dkimBreakage(ctx, pkglog, msg)
+	} else {
+		var results []dkim.Result
+		results, err = dkim.Verify(ctx, pkglog.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(msg), false)
+		r = dkimResults(results)
+	}
+	xcmdcheck(err, "verifying dkim signatures in message")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(r)
+	xcmdcheck(err, "write result")
+}
+
 func logPanic(log mlog.Log) {
 	x := recover()
 	if x == nil {
@@ -431,6 +523,9 @@ type DomainSPF struct {
 	Record     *SPFRecord
 	Authentic  bool
 	Error      string
+	DNSBL      []DNSBLIP // For IPs in ip4 and ip6 mechanisms.
+	Lookups    int       // DNS lookups needed for evaluation, following include and redirect. At most 10 are allowed.
+	LookupsErr string    // E.g. for an include of a domain without SPF record.
 }
 
 type DMARCRecord struct {
@@ -492,6 +587,7 @@ type DomainDANE struct {
 	TLSABaseDomain dns.Domain
 	Error          string
 	VerifiedRecord TLSARecord
+	WithoutDNSSEC  bool // TLSA records exist, but the MX records or MX host IPs aren't DNSSEC-protected, so senders ignore them.
 }
 
 type Proto struct {
@@ -504,6 +600,7 @@ type TLSConnectionState struct {
 	CipherSuite        string
 	NegotiatedProtocol string
 	ServerName         string
+	CertFingerprint    string // Hex SHA-256 of the leaf certificate.
 }
 
 type DomainSMTP struct {
@@ -516,6 +613,10 @@ type DomainSMTP struct {
 	TLSConnectionState    *TLSConnectionState
 	RecipientDomainResult *TLSRPTResult
 	HostResult            *TLSRPTResult
+	GreetingHostname      string    // From 220 greeting.
+	EHLO                  *SMTPEHLO // Before STARTTLS.
+	EHLOTLS               *SMTPEHLO // After STARTTLS.
+	Warnings              []string  // About extensions.
 
 	Trace []Proto
 }
@@ -593,20 +694,34 @@ type DomainMXHost struct {
 	Host        dns.IPDomain
 	MTASTSError string
 	IP          DomainIP
+	DNSBL       []DNSBLIP
+	IPRev       []IPRevResult // EHLO is compared against the hostname from the SMTP trace.
 	DANE        DomainDANE
 	Dial        DomainDial
 	SMTP        DomainSMTP
+	IPResults   []DomainMXIP  // When checking all IPs, instead of Dial and SMTP.
+	Parity      *DomainParity // For MX hosts with both IPv4 and IPv6 addresses.
+}
+
+// DomainMXIP is the result of connecting to a single IP of an MX host.
+type DomainMXIP struct {
+	IP                 net.IP
+	Dial               DomainDial
+	SMTP               DomainSMTP
+	DANEVerifiedRecord TLSARecord
 }
 
 type DomainResult struct {
 	DurationMS int
 	Domain     dns.Domain
 	SPF        DomainSPF
+	DKIM       DKIMDiscoverResult // Records for common selectors.
 	DMARC      DomainDMARC
 	TLSRPT     DomainTLSRPT
 	MTASTS     DomainMTASTS
 	MX         DomainMX
 	MXHosts    []DomainMXHost
+	Grade      DomainGrade
 }
 
 func errmsg(err error) string {
@@ -629,8 +744,15 @@ func (API) DomainCheck(ctx context.Context, domain string) (dr DomainResult) {
 	log.Debug("domaincheck call", slog.String("domain", domain))
 
 	dom, err := dns.ParseDomain(domain)
-	xcheck(err, "parsing domain")
+	xcheckuser(err, "parsing domain")
 
+	return domainCheck(ctx, log, dom, false)
+}
+
+// domainCheck checks the DNS records and MX hosts of a domain. Normally only the
+// first two MX hosts are dialed, at the first IP that accepts a connection. With
+// all, all MX hosts are dialed, at each of their IPs.
+func domainCheck(ctx context.Context, log mlog.Log, dom dns.Domain, all bool) (dr DomainResult) {
 	start := time.Now()
 
 	dr.Domain = dom
@@ -649,10 +771,22 @@ func (API) DomainCheck(ctx context.Context, domain string) (dr DomainResult) {
 		t0 := time.Now()
 		status, txt, record, authentic, err := spf.Lookup(opctx, log.Logger, resolver, dom)
 		var spfRecord *SPFRecord
+		var lookups int
+		var lookupsErr error
 		if record != nil {
 			spfRecord = &SPFRecord{*record}
+			lookups, lookupsErr = spfLookups(opctx, log, record)
 		}
-		dr.SPF = DomainSPF{timeSince(t0), string(status), txt, spfRecord, authentic, errmsg(err)}
+		dr.SPF = DomainSPF{timeSince(t0), string(status), txt, spfRecord, authentic, errmsg(err), dnsblCheck(opctx, log, spfIPs(record)), lookups, errmsg(lookupsErr)}
+	}()
+
+	// DKIM.
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		dr.DKIM = dkimDiscover(opctx, log, dom)
 	}()
 
 	// DMARC.
@@ -706,6 +840,42 @@ func (API) DomainCheck(ctx context.Context, domain string) (dr DomainResult) {
 				return
 			}
 
+			wg.Add(1)
+			go func() {
+				defer logPanic(log)
+				defer wg.Done()
+
+				mx.DNSBL = dnsblCheck(opctx, log, ips)
+			}()
+
+			// Zone data doesn't have reverse DNS for the IPs.
+			var iprevwg sync.WaitGroup
+			if !offline {
+				mx.IPRev = make([]IPRevResult, len(ips))
+				for i, ip := range ips {
+					iprevwg.Add(1)
+					go func() {
+						defer logPanic(log)
+						defer iprevwg.Done()
+
+						mx.IPRev[i] = iprevCheck(opctx, ip, "")
+					}()
+				}
+			}
+			// Compare with EHLO hostname when the SMTP session is done.
+			defer func() {
+				iprevwg.Wait()
+				for i := range mx.IPRev {
+					smtp := mx.SMTP
+					if all && i < len(mx.IPResults) {
+						smtp = mx.IPResults[i].SMTP
+					}
+					if smtp.EHLO != nil {
+						iprevEHLO(&mx.IPRev[i], smtp.EHLO.Hostname)
+					}
+				}
+			}()
+
 			var daneRecords []adns.TLSA
 			var daneMoreHostnames []dns.Domain
 			if dr.MX.OrigNextHopAuthentic && dr.MX.ExpandedNextHopAuthentic && authentic {
@@ -720,67 +890,71 @@ func (API) DomainCheck(ctx context.Context, domain string) (dr DomainResult) {
 				for i, r := range daneRecords {
 					tlsarecords[i] = TLSARecord{r}
 				}
-				mx.DANE = DomainDANE{timeSince(t0dane), daneRequired, tlsarecords, tlsaBaseDomain, errmsg(err), TLSARecord{}}
+				mx.DANE = DomainDANE{timeSince(t0dane), daneRequired, tlsarecords, tlsaBaseDomain, errmsg(err), TLSARecord{}, false}
+			} else {
+				// Senders don't use TLSA records without DNSSEC, but their existence
+				// indicates DANE was intended.
+				t0dane := time.Now()
+				l, _, err := resolver.LookupTLSA(opctx, 25, "tcp", mx.Host.Domain.ASCII+".")
+				mx.DANE = DomainDANE{DurationMS: timeSince(t0dane), WithoutDNSSEC: err == nil && len(l) > 0}
 			}
 
-			if !dial {
+			if !dial || offline {
 				return
 			}
 
-			t0dial := time.Now()
-			dialer := &limitDialer{}
-			conn, ip, err := smtpclient.Dial(opctx, log.Logger, dialer, mx.Host, mx.IP.IPs, 25, dialedIPs, nil)
-			mx.Dial = DomainDial{timeSince(t0dial), ip, errmsg(err)}
-			if err != nil {
-				return
-			}
-			defer conn.Close()
+			if !all {
+				mx.Dial, mx.SMTP, mx.DANE.VerifiedRecord = mxConnect(opctx, log, mx.Host, ips, dialedIPs, pkix, mx.DANE.Required, daneRecords, daneMoreHostnames)
+				if !mx.IP.DualStack || mx.Dial.IP == nil {
+					return
+				}
 
-			tlsMode := smtpclient.TLSOpportunistic
-			if pkix || mx.DANE.Required {
-				tlsMode = smtpclient.TLSRequiredStartTLS
+				// Connect to an IP of the other family to compare.
+				r := DomainMXIP{mx.Dial.IP, mx.Dial, mx.SMTP, mx.DANE.VerifiedRecord}
+				v6 := mx.Dial.IP.To4() == nil
+				var other DomainMXIP
+				for _, ip := range ips {
+					if (ip.To4() == nil) != v6 {
+						other.IP = ip
+						other.Dial, other.SMTP, other.DANEVerifiedRecord = mxConnect(opctx, log, mx.Host, []net.IP{ip}, map[string][]net.IP{}, pkix, mx.DANE.Required, daneRecords, daneMoreHostnames)
+						break
+					}
+				}
+				if v6 {
+					mx.Parity = mxParity(other, r, mx.DANE.Required)
+				} else {
+					mx.Parity = mxParity(r, other, mx.DANE.Required)
+				}
+				return
 			}
 
-			t0smtp := time.Now()
-			var daneVerifiedRecord adns.TLSA
-			var tlsrptRecipientDomainResult tlsrpt.Result
-			var tlsrptHostResult tlsrpt.Result
-			opts := smtpclient.Opts{
-				DANERecords:           daneRecords,
-				DANEMoreHostnames:     daneMoreHostnames,
-				DANEVerifiedRecord:    &daneVerifiedRecord,
-				IgnoreTLSVerifyErrors: true, // note: not generally safe
-				RecipientDomainResult: &tlsrptRecipientDomainResult,
-				HostResult:            &tlsrptHostResult,
+			// Connect to each IP separately, e.g. to find a broken server behind a load balancer.
+			mx.IPResults = make([]DomainMXIP, len(ips))
+			var ipwg sync.WaitGroup
+			for i, ip := range ips {
+				ipwg.Add(1)
+				go func() {
+					defer logPanic(log)
+					defer ipwg.Done()
+
+					r := &mx.IPResults[i]
+					r.IP = ip
+					r.Dial, r.SMTP, r.DANEVerifiedRecord = mxConnect(opctx, log, mx.Host, []net.IP{ip}, map[string][]net.IP{}, pkix, mx.DANE.Required, daneRecords, daneMoreHostnames)
+				}()
 			}
-			th := traceHandler{Trace: []Proto{}}
-			tracelog := slog.New(&th)
-			client, err := smtpclient.New(opctx, tracelog, conn, tlsMode, pkix, dnsHostname, mx.Host.Domain, opts)
-			mx.SMTP.Error = errmsg(err)
-			if err != nil {
-				return
-			}
-			cs := client.TLSConnectionState()
-			client.Close()
-			mx.SMTP.DurationMS = timeSince(t0smtp)
-
-			mx.DANE.VerifiedRecord = TLSARecord{daneVerifiedRecord}
-
-			mx.SMTP.Supports8bitMIME = client.Supports8BITMIME()
-			mx.SMTP.SupportsRequireTLS = client.SupportsRequireTLS()
-			mx.SMTP.SupportsSMTPUTF8 = client.SupportsSMTPUTF8()
-			mx.SMTP.SupportsSTARTTLS = client.SupportsStartTLS()
-			if cs != nil {
-				mx.SMTP.TLSConnectionState = &TLSConnectionState{
-					Version:            tlsVersionName(cs.Version),
-					CipherSuite:        tls.CipherSuiteName(cs.CipherSuite),
-					NegotiatedProtocol: cs.NegotiatedProtocol,
-					ServerName:         cs.ServerName,
+			ipwg.Wait()
+
+			if mx.IP.DualStack {
+				var v4, v6 *DomainMXIP
+				for i, r := range mx.IPResults {
+					if r.IP.To4() != nil && v4 == nil {
+						v4 = &mx.IPResults[i]
+					} else if r.IP.To4() == nil && v6 == nil {
+						v6 = &mx.IPResults[i]
+					}
 				}
+				mx.Parity = mxParity(*v4, *v6, mx.DANE.Required)
 			}
-			mx.SMTP.RecipientDomainResult = tlsrptResult(tlsrptRecipientDomainResult)
-			mx.SMTP.HostResult = tlsrptResult(tlsrptHostResult)
-			mx.SMTP.Trace = th.Trace
 		}()
 	}
 
@@ -829,15 +1003,88 @@ func (API) DomainCheck(ctx context.Context, domain string) (dr DomainResult) {
 					dr.MXHosts[i].MTASTSError = "MX target does not match MTA-STS policy"
 				}
 			}
-			checkMX(&dr.MXHosts[i], i < 2, pkix)
+			checkMX(&dr.MXHosts[i], all || i < 2, pkix)
 		}
 	}()
 
 	wg.Wait()
+	dr.Grade = domainGrade(dr)
 	dr.DurationMS = timeSince(start)
 	return
 }
 
+func cmdDomaincheck(c *cmd) {
+	var all bool
+	c.flag.BoolVar(&all, "all", false, "connect to all mx hosts, and to each of their ips separately")
+	args := c.Parse()
+	if len(args) != 1 {
+		c.Usage()
+	}
+
+	dom, err := dns.ParseDomain(args[0])
+	xcmdcheck(err, "parsing domain")
+
+	dr := domainCheck(context.Background(), pkglog, dom, all)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(dr)
+	xcmdcheck(err, "write result")
+}
+
+// mxConnect dials one of ips of an MX host and does an SMTP session with STARTTLS.
+func mxConnect(ctx context.Context, log mlog.Log, host dns.IPDomain, ips []net.IP, dialedIPs map[string][]net.IP, pkix, daneRequired bool, daneRecords []adns.TLSA, daneMoreHostnames []dns.Domain) (dial DomainDial, smtp DomainSMTP, daneVerified TLSARecord) {
+	t0dial := time.Now()
+	dialer := &limitDialer{}
+	conn, ip, err := smtpclient.Dial(ctx, log.Logger, dialer, host, ips, 25, dialedIPs, nil)
+	dial = DomainDial{timeSince(t0dial), ip, errmsg(err)}
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tlsMode := smtpclient.TLSOpportunistic
+	if pkix || daneRequired {
+		tlsMode = smtpclient.TLSRequiredStartTLS
+	}
+
+	t0smtp := time.Now()
+	var daneVerifiedRecord adns.TLSA
+	var tlsrptRecipientDomainResult tlsrpt.Result
+	var tlsrptHostResult tlsrpt.Result
+	opts := smtpclient.Opts{
+		DANERecords:           daneRecords,
+		DANEMoreHostnames:     daneMoreHostnames,
+		DANEVerifiedRecord:    &daneVerifiedRecord,
+		IgnoreTLSVerifyErrors: true, // note: not generally safe
+		RecipientDomainResult: &tlsrptRecipientDomainResult,
+		HostResult:            &tlsrptHostResult,
+	}
+	th := traceHandler{Trace: []Proto{}}
+	tracelog := slog.New(&th)
+	client, err := smtpclient.New(ctx, tracelog, conn, tlsMode, pkix, dnsHostname, host.Domain, opts)
+	smtp.Error = errmsg(err)
+	if err != nil {
+		return
+	}
+	cs := client.TLSConnectionState()
+	client.Close()
+	smtp.DurationMS = timeSince(t0smtp)
+
+	daneVerified = TLSARecord{daneVerifiedRecord}
+
+	smtp.Supports8bitMIME = client.Supports8BITMIME()
+	smtp.SupportsRequireTLS = client.SupportsRequireTLS()
+	smtp.SupportsSMTPUTF8 = client.SupportsSMTPUTF8()
+	smtp.SupportsSTARTTLS = client.SupportsStartTLS()
+	smtp.TLSConnectionState = tlsConnectionState(cs)
+	smtp.RecipientDomainResult = tlsrptResult(tlsrptRecipientDomainResult)
+	smtp.HostResult = tlsrptResult(tlsrptHostResult)
+	smtp.Trace = th.Trace
+	smtp.GreetingHostname, smtp.EHLO, smtp.EHLOTLS = smtpEHLOs(th.Trace)
+	smtp.Warnings = smtpEHLOWarnings(smtp.GreetingHostname, smtp.EHLO, smtp.EHLOTLS)
+	return
+}
+
 type traceHandler struct {
 	Attrs []slog.Attr
 	Trace []Proto
@@ -850,6 +1097,12 @@ func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
 func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
 	if r.Level <= mlog.LevelTrace {
 		p := Proto{strings.HasPrefix(r.Message, "LC: "), strings.TrimPrefix(strings.TrimPrefix(r.Message, "LC: "), "RS: ")}
+		// Like mox, don't keep credentials or message data.
+		if r.Level == mlog.LevelTraceauth {
+			p.Text = "***"
+		} else if r.Level == mlog.LevelTracedata {
+			p.Text = "..."
+		}
 		h.Trace = append(h.Trace, p)
 		return nil
 	}
@@ -867,7 +1120,10 @@ func (h *traceHandler) WithGroup(name string) slog.Handler {
 	return h
 }
 
+var errDialRateLimited = errors.New("rate limited: reached max number of smtp connections to ip in window, try again soon")
+
 type limitDialer struct {
+	Control func(network, address string, c syscall.RawConn) error // Optional, passed to net.Dialer.
 }
 
 func (d *limitDialer) DialContext(ctx context.Context, network, addr string) (c net.Conn, err error) {
@@ -880,9 +1136,9 @@ func (d *limitDialer) DialContext(ctx context.Context, network, addr string) (c
 		return nil, fmt.Errorf("address not an ip: %q", host)
 	}
 	if ratelimiter && !smtpDialLimiter.Add(ip, time.Now(), 1) {
-		return nil, fmt.Errorf("rate limited: reached max number of smtp connections to ip in window, try again soon")
+		return nil, errDialRateLimited
 	}
-	nd := &net.Dialer{}
+	nd := &net.Dialer{Control: d.Control}
 	return nd.DialContext(ctx, network, addr)
 }
 
@@ -894,6 +1150,24 @@ var tlsVersions = map[uint16]string{
 	tls.VersionTLS13: "TLS 1.3",
 }
 
+func tlsConnectionState(cs *tls.ConnectionState) *TLSConnectionState {
+	if cs == nil {
+		return nil
+	}
+	var fp string
+	if len(cs.PeerCertificates) > 0 {
+		sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		fp = hex.EncodeToString(sum[:])
+	}
+	return &TLSConnectionState{
+		Version:            tlsVersionName(cs.Version),
+		CipherSuite:        tls.CipherSuiteName(cs.CipherSuite),
+		NegotiatedProtocol: cs.NegotiatedProtocol,
+		ServerName:         cs.ServerName,
+		CertFingerprint:    fp,
+	}
+}
+
 // tls.VersionName was introduced in go1.21
 func tlsVersionName(version uint16) string {
 	s, ok := tlsVersions[version]
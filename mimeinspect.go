@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+)
+
+// MIMEHeader is a header of a message or part.
+type MIMEHeader struct {
+	Name    string
+	Value   string // Unfolded.
+	Decoded string // With RFC 2047 encoded-words decoded, empty if the same as Value.
+}
+
+// MIMEPart is a part in the MIME tree of a message.
+type MIMEPart struct {
+	Path                    string // Part number as in IMAP, e.g. "1.2", empty for the message.
+	ContentType             string // Lower case, e.g. "text/plain". Empty if absent, which means text/plain.
+	Params                  map[string]string
+	Charset                 string
+	ContentTransferEncoding string // Lower case, empty if absent, which means 7bit.
+	Disposition             string // E.g. "inline" or "attachment".
+	Filename                string // From Content-Disposition, or the "name" Content-Type parameter.
+	ContentID               string
+	Headers                 []MIMEHeader
+	Envelope                *message.Envelope // For the message and embedded messages.
+	Size                    int64             // Of the raw body, -1 if the part could not be parsed completely.
+	DecodedSize             int64
+	Lines                   int64
+	Parts                   []MIMEPart
+	Message                 *MIMEPart // For message/rfc822 and message/global parts.
+}
+
+// MIMEInspection is the MIME structure of a message, with problems found.
+type MIMEInspection struct {
+	Size     int
+	Part     *MIMEPart // Nil if the message header could not be parsed.
+	Findings []Finding
+}
+
+var mimeWordDecoder = mime.WordDecoder{
+	CharsetReader: func(charset string, r io.Reader) (io.Reader, error) {
+		return message.DecodeReader(charset, r), nil
+	},
+}
+
+// RFC 5322 limit, excluding crlf.
+const maxLineLength = 998
+
+// MIMEInspect parses a message, and shows the MIME structure and problems.
+func (API) MIMEInspect(ctx context.Context, message []byte) MIMEInspection {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("mimeinspect call")
+
+	// Not converted to crlf line endings, bare newlines are reported.
+	xmessageSize(message)
+	return mimeInspect(log, message)
+}
+
+// mimeInspect parses msg and returns its MIME tree. Line endings are checked
+// on msg as is, the MIME structure is parsed after converting bare newlines to
+// crlf.
+func mimeInspect(log mlog.Log, msg []byte) (mi MIMEInspection) {
+	mi = MIMEInspection{Size: len(msg), Findings: []Finding{}}
+	add := func(check, severity, title, explanation, remediation string) {
+		mi.Findings = append(mi.Findings, Finding{check, severity, title, explanation, remediation, 0})
+	}
+
+	var crlf, lf, cr, long, firstLF, firstLong, maxLong int
+	for i, line := range bytes.SplitAfter(msg, []byte("\n")) {
+		n := len(line)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			crlf++
+			n -= 2
+		} else if bytes.HasSuffix(line, []byte("\n")) {
+			lf++
+			n--
+			if firstLF == 0 {
+				firstLF = i + 1
+			}
+		}
+		cr += bytes.Count(line[:n], []byte("\r"))
+		if n > maxLineLength {
+			long++
+			if firstLong == 0 {
+				firstLong = i + 1
+			}
+			maxLong = max(maxLong, n)
+		}
+	}
+	if lf > 0 && crlf == 0 {
+		add("mime.bare-lf", "info", "Bare newlines only", "All lines end with a bare newline (LF) instead of CRLF. This is common for messages stored in files, mail software converts the line endings when sending. If this is how the message is sent over SMTP, servers can reject it. The structure is analyzed after converting to CRLF.", "")
+	} else if lf > 0 {
+		add("mime.bare-lf", "warning", fmt.Sprintf("Bare newlines: %d lines, first at line %d", lf, firstLF), "Some lines end with a bare newline (LF) instead of CRLF. Since the SMTP smuggling vulnerabilities, servers increasingly reject messages with bare newlines. Others convert them, invalidating DKIM signatures. The structure is analyzed after converting to CRLF.", "Generate the message with CRLF line endings only.")
+	}
+	if cr > 0 {
+		add("mime.bare-cr", "warning", fmt.Sprintf("Bare carriage returns: %d", cr), "The message contains carriage returns (CR) not followed by a newline. These are not allowed in messages, servers can reject the message or change it.", "Remove bare carriage returns, or use quoted-printable or base64 encoding for content that contains them.")
+	}
+	if long > 0 {
+		add("mime.line-length", "error", fmt.Sprintf("Lines over %d characters: %d lines, first at line %d, longest %d", maxLineLength, long, firstLong, maxLong), fmt.Sprintf("RFC 5322 limits lines to %d characters, excluding CRLF. Servers can reject the message, or break long lines, which changes the content and invalidates DKIM signatures. Often seen with HTML generated on a single line.", maxLineLength), "Use quoted-printable or base64 encoding for content with long lines, or add line breaks.")
+	}
+
+	if lf > 0 {
+		msg = bytes.ReplaceAll(bytes.ReplaceAll(msg, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	}
+	p, err := message.Parse(log.Logger, false, bytes.NewReader(msg))
+	if err == nil {
+		err = p.Walk(log.Logger, nil)
+	}
+	if err != nil {
+		if problems := mimeBoundaryProblems(msg, "", 0); len(problems) > 0 {
+			add("mime.boundary", "error", "Missing multipart boundary", "The multipart structure could not be parsed: "+strings.Join(problems, ", ")+". Each multipart part needs a boundary parameter in its Content-Type header, lines with the boundary between its parts, and a closing boundary line. Mail clients can show such messages incorrectly, e.g. with parts missing or as raw text.", "Fix the code generating the message, preferably by using a library for composing MIME messages.")
+		} else {
+			add("mime.structure", "error", "Invalid MIME structure", "The message could not be parsed completely: "+err.Error()+".", "Fix the code generating the message, preferably by using a library for composing MIME messages.")
+		}
+	}
+	if p.BodyOffset == 0 {
+		// Header could not be parsed.
+		return
+	}
+
+	var smtputf8 []string
+	var walk func(p *message.Part, path string) MIMEPart
+	walk = func(p *message.Part, path string) MIMEPart {
+		label := "message"
+		if path != "" {
+			label = "part " + path
+		}
+		mp := MIMEPart{
+			Path:                    path,
+			Params:                  map[string]string{},
+			Charset:                 p.ContentTypeParams["charset"],
+			ContentTransferEncoding: strings.ToLower(p.ContentTransferEncoding),
+			ContentID:               p.ContentID,
+			Headers:                 []MIMEHeader{},
+			Envelope:                p.Envelope,
+			Size:                    -1,
+			DecodedSize:             p.DecodedSize,
+			Lines:                   p.RawLineCount,
+			Parts:                   []MIMEPart{},
+		}
+		for k, v := range p.ContentTypeParams {
+			mp.Params[k] = v
+		}
+		if p.MediaType != "" {
+			mp.ContentType = strings.ToLower(p.MediaType + "/" + p.MediaSubType)
+		}
+		if p.EndOffset >= 0 {
+			mp.Size = p.EndOffset - p.BodyOffset
+		}
+
+		hbuf, err := io.ReadAll(p.HeaderReader())
+		if err != nil {
+			add("mime.structure", "error", "Reading header of "+label, err.Error(), "")
+		}
+		if bytes.ContainsFunc(hbuf, func(r rune) bool { return r >= 0x80 }) {
+			smtputf8 = append(smtputf8, label)
+		}
+		hdrs, _ := dkimSplitMessage(hbuf)
+		for _, h := range hdrs {
+			_, v, _ := strings.Cut(h.raw, ":")
+			v = strings.TrimSpace(strings.ReplaceAll(v, "\r\n", ""))
+			mh := MIMEHeader{Name: h.key, Value: v}
+			if strings.Contains(v, "=?") {
+				if s, err := mimeWordDecoder.DecodeHeader(v); err != nil {
+					add("mime.encoded-word", "warning", fmt.Sprintf("Invalid encoded-word in %s header of %s", h.key, label), "The header has an RFC 2047 encoded-word that could not be decoded: "+err.Error()+". Mail clients may show the raw encoded text.", "Encode non-ASCII header text as encoded-words like =?utf-8?q?...?=, with at most 75 characters each.")
+				} else if s != v {
+					mh.Decoded = s
+				}
+			}
+			mp.Headers = append(mp.Headers, mh)
+
+			if h.lkey == "content-disposition" {
+				disp, params, err := mime.ParseMediaType(v)
+				if err != nil {
+					add("mime.disposition", "warning", "Invalid Content-Disposition header in "+label, err.Error(), "")
+				}
+				mp.Disposition = disp
+				mp.Filename = params["filename"]
+			}
+		}
+		if mp.Filename == "" {
+			mp.Filename = p.ContentTypeParams["name"]
+		}
+		if s, err := mimeWordDecoder.DecodeHeader(mp.Filename); err == nil {
+			// Encoded-words are not allowed in parameters, but commonly used.
+			mp.Filename = s
+		}
+
+		switch mp.ContentTransferEncoding {
+		case "", "7bit", "8bit", "binary", "quoted-printable", "base64":
+		default:
+			add("mime.transfer-encoding", "error", fmt.Sprintf("Unknown Content-Transfer-Encoding %q in %s", p.ContentTransferEncoding, label), "Mail clients cannot decode the content.", "Use 7bit, 8bit, quoted-printable or base64.")
+		}
+		if p.MediaType == "MULTIPART" || p.MediaType == "MESSAGE" {
+			switch mp.ContentTransferEncoding {
+			case "quoted-printable", "base64":
+				if p.MediaType == "MULTIPART" || p.MediaSubType == "RFC822" {
+					add("mime.transfer-encoding", "error", fmt.Sprintf("Content-Transfer-Encoding %s for %s in %s", mp.ContentTransferEncoding, mp.ContentType, label), "Multipart parts and message/rfc822 parts must not be encoded (RFC 2045 section 6.4), their subparts are encoded instead.", "Use 7bit or 8bit, and encode the subparts.")
+				}
+			}
+		}
+
+		if p.MediaType == "MULTIPART" {
+			for i := range p.Parts {
+				sub := fmt.Sprintf("%d", i+1)
+				if path != "" {
+					sub = path + "." + sub
+				}
+				mp.Parts = append(mp.Parts, walk(&p.Parts[i], sub))
+			}
+			return mp
+		}
+		if p.Message != nil {
+			mp.Message = new(MIMEPart)
+			*mp.Message = walk(p.Message, path)
+			return mp
+		}
+		if p.EndOffset < 0 {
+			return mp
+		}
+
+		raw, err := io.ReadAll(p.RawReader())
+		if err != nil {
+			return mp
+		}
+		if (mp.ContentTransferEncoding == "" || mp.ContentTransferEncoding == "7bit") && bytes.ContainsFunc(raw, func(r rune) bool { return r >= 0x80 }) {
+			add("mime.8bit", "warning", "8-bit content declared as 7bit in "+label, "The content has non-ASCII bytes, but the Content-Transfer-Encoding is 7bit (the default if absent). Servers without the 8BITMIME extension can reject or change the message.", "Set Content-Transfer-Encoding to 8bit, or encode the content with quoted-printable or base64.")
+		}
+		if (p.MediaType == "" || p.MediaType == "TEXT") && mp.Charset == "" {
+			if decoded, err := io.ReadAll(p.Reader()); err == nil && bytes.ContainsFunc(decoded, func(r rune) bool { return r >= 0x80 }) {
+				add("mime.charset", "warning", "Non-ASCII text without charset in "+label, "The text has non-ASCII characters, but the Content-Type has no charset parameter, so it is us-ascii. Mail clients guess the character set, and may show garbled text.", `Add a charset parameter, e.g. Content-Type: text/html; charset="utf-8".`)
+			}
+		}
+		return mp
+	}
+	root := walk(&p, "")
+	mi.Part = &root
+
+	if len(smtputf8) > 0 {
+		add("mime.smtputf8", "warning", "Non-ASCII in headers of "+strings.Join(smtputf8, ", "), "Headers contain non-ASCII characters. This requires the SMTPUTF8 extension (RFC 6531) from all servers delivering the message, otherwise the message is rejected or changed. Non-ASCII in headers of parts is not allowed without SMTPUTF8 either.", "Encode non-ASCII header text as RFC 2047 encoded-words, e.g. =?utf-8?q?...?=, and use IDNA (punycode) for domains in addresses. Only use non-ASCII in localparts of addresses with SMTPUTF8.")
+	}
+	return
+}
+
+// mimeBoundaryProblems looks for multipart parts without boundary parameter, or
+// without closing boundary line, in msg with crlf line endings. It works on the
+// raw message, the parser stops at the first problem without returning the part.
+func mimeBoundaryProblems(msg []byte, path string, depth int) (problems []string) {
+	if depth > 20 {
+		return nil
+	}
+	label := "message"
+	if path != "" {
+		label = "part " + path
+	}
+	hdrs, body := dkimSplitMessage(msg)
+	var ct, cte string
+	for _, h := range hdrs {
+		switch h.lkey {
+		case "content-type":
+			ct = dkimHeaderValue(h)
+		case "content-transfer-encoding":
+			cte = strings.ToLower(dkimHeaderValue(h))
+		}
+	}
+	mt, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil
+	}
+	if mt == "message/rfc822" || mt == "message/global" {
+		if cte == "base64" || cte == "quoted-printable" {
+			return nil
+		}
+		return mimeBoundaryProblems(body, path, depth+1)
+	} else if !strings.HasPrefix(mt, "multipart/") {
+		return nil
+	}
+
+	boundary := params["boundary"]
+	if boundary == "" {
+		return []string{fmt.Sprintf("%s (%s) has no boundary parameter", label, mt)}
+	}
+	delim := []byte("--" + boundary)
+
+	// Check the parts between the boundary lines.
+	var n int
+	start := -1
+	part := func(end int) {
+		if start < 0 {
+			return
+		}
+		n++
+		sub := fmt.Sprintf("%d", n)
+		if path != "" {
+			sub = path + "." + sub
+		}
+		problems = append(problems, mimeBoundaryProblems(body[start:end], sub, depth+1)...)
+	}
+	var closed bool
+	var o int
+	for _, line := range bytes.SplitAfter(body, []byte("\r\n")) {
+		rest, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r\n"), delim)
+		if ok && bytes.HasPrefix(rest, []byte("--")) {
+			part(o)
+			closed = true
+			break
+		} else if ok && (len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t') {
+			part(o)
+			start = o + len(line)
+		}
+		o += len(line)
+	}
+	if !closed {
+		part(len(body))
+		problems = append(problems, fmt.Sprintf("%s (%s) has no closing boundary line %s--", label, mt, delim))
+	}
+	return problems
+}
+
+func cmdMIMEInspect(c *cmd) {
+	var index int
+	c.flag.IntVar(&index, "message", 0, "message to inspect in mbox file, starting at 1")
+	args := c.Parse()
+	if len(args) > 1 {
+		c.Usage()
+	}
+	var file string
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	// Messages from mbox files are converted to crlf line endings, a message file is
+	// inspected as is.
+	buf, err := readFile(file)
+	xcmdcheck(err, "reading message")
+	msgs := [][]byte{buf}
+	if isMbox(buf) {
+		msgs = mboxMessages(buf)
+	}
+
+	mi := mimeInspect(pkglog, xselectMessage(msgs, index))
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(mi)
+	xcmdcheck(err, "write result")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Maximum size of a message in API calls. The HTTP request can be larger, the
+// message is base64-encoded.
+const maxMessageSize = 20 * 1024 * 1024
+
+// API functions that take a message, with room in the HTTP request for a
+// base64-encoded message. Other functions get a smaller limit.
+var apiMessageFunctions = map[string]bool{
+	"DKIMVerify":   true,
+	"DKIMDebug":    true,
+	"DKIMBreakage": true,
+	"DSNParse":     true,
+	"MIMEInspect":  true,
+}
+
+// Maximum size of HTTP requests for other API functions, room for a domain
+// check result for DomainReport.
+const maxAPIRequestSize = 5 * 1024 * 1024
+
+// xmessage checks the size of a message from an API call and ensures it has crlf
+// line endings.
+func xmessage(message []byte) []byte {
+	xmessageSize(message)
+	return messageCRLF(message)
+}
+
+func xmessageSize(message []byte) {
+	if len(message) > maxMessageSize {
+		xcheckuser(fmt.Errorf("message is %d bytes, maximum is %d bytes", len(message), maxMessageSize), "message too large")
+	}
+}
+
+// messageCRLF returns the message with crlf line endings. Messages from files
+// often have bare newlines, possibly only on some lines. If all lines already end
+// with crlf, the message is returned as is.
+func messageCRLF(buf []byte) []byte {
+	bare := false
+	for i, c := range buf {
+		if c == '\n' && (i == 0 || buf[i-1] != '\r') {
+			bare = true
+			break
+		}
+	}
+	if !bare {
+		return buf
+	}
+	var r []byte
+	for len(buf) > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			r = append(r, buf...)
+			break
+		}
+		r = append(r, buf[:i]...)
+		if i == 0 || buf[i-1] != '\r' {
+			r = append(r, '\r')
+		}
+		r = append(r, '\n')
+		buf = buf[i+1:]
+	}
+	return r
+}
+
+// isMbox returns whether buf looks like an mbox file, starting with a "From "
+// line.
+func isMbox(buf []byte) bool {
+	return bytes.HasPrefix(buf, []byte("From "))
+}
+
+// mboxMessages splits an mbox file into its messages. A "From " line only starts
+// a new message at the start or after an empty line. Lines escaped with ">" (as
+// in mboxrd) are unescaped.
+func mboxMessages(buf []byte) [][]byte {
+	var l [][]byte
+	var cur []byte
+	var started, empty bool
+	end := func() {
+		// The empty line before the next "From " line is not part of the message.
+		if bytes.HasSuffix(cur, []byte("\r\n\r\n")) {
+			cur = cur[:len(cur)-2]
+		} else if bytes.HasSuffix(cur, []byte("\n\n")) {
+			cur = cur[:len(cur)-1]
+		}
+		l = append(l, messageCRLF(cur))
+	}
+	for len(buf) > 0 {
+		line := buf
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line = buf[:i+1]
+		}
+		buf = buf[len(line):]
+
+		if bytes.HasPrefix(line, []byte("From ")) && (!started || empty) {
+			if started {
+				end()
+			}
+			cur = nil
+			started = true
+			empty = false
+			continue
+		}
+		empty = len(bytes.TrimRight(line, "\r\n")) == 0
+		if !started {
+			continue
+		}
+		if t := bytes.TrimLeft(line, ">"); len(t) < len(line) && bytes.HasPrefix(t, []byte("From ")) {
+			line = line[1:]
+		}
+		cur = append(cur, line...)
+	}
+	if started {
+		end()
+	}
+	return l
+}
+
+// xselectMessage returns message index, starting at 1, from msgs read from a file
+// for a command. Index 0 selects the only message.
+func xselectMessage(msgs [][]byte, index int) []byte {
+	if len(msgs) > 1 && index == 0 {
+		xcmdcheck(fmt.Errorf("mbox file has %d messages, select one with -message", len(msgs)), "reading message")
+	} else if index > len(msgs) || index < 0 {
+		xcmdcheck(fmt.Errorf("no message %d, file has %d messages", index, len(msgs)), "reading message")
+	} else if index == 0 {
+		index = 1
+	}
+	return msgs[index-1]
+}
+
+// readFile reads file, or stdin if file is empty or "-".
+func readFile(file string) ([]byte, error) {
+	if file == "" || file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+// readMessages reads a message or mbox file, or stdin if file is empty or "-".
+// Messages are returned with crlf line endings.
+func readMessages(file string) ([][]byte, error) {
+	buf, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if isMbox(buf) {
+		return mboxMessages(buf), nil
+	}
+	return [][]byte{messageCRLF(buf)}, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DomainParity compares connections to an MX host over IPv4 and IPv6. Servers
+// for the two address families are often configured separately, e.g. behind
+// different load balancers, and get out of sync.
+type DomainParity struct {
+	IPv4        DomainMXIP
+	IPv6        DomainMXIP
+	Differences []string
+}
+
+func mxParity(v4, v6 DomainMXIP, daneRequired bool) *DomainParity {
+	p := &DomainParity{v4, v6, []string{}}
+	diff := func(format string, args ...any) {
+		p.Differences = append(p.Differences, fmt.Sprintf(format, args...))
+	}
+	works := func(err string) string {
+		if err == "" {
+			return "works"
+		}
+		return "fails: " + err
+	}
+
+	if (v4.Dial.Error == "") != (v6.Dial.Error == "") {
+		diff("Connecting over IPv4 %s, over IPv6 %s.", works(v4.Dial.Error), works(v6.Dial.Error))
+		return p
+	} else if v4.Dial.Error != "" {
+		return p
+	}
+	if (v4.SMTP.Error == "") != (v6.SMTP.Error == "") {
+		diff("SMTP session over IPv4 %s, over IPv6 %s.", works(v4.SMTP.Error), works(v6.SMTP.Error))
+		return p
+	} else if v4.SMTP.Error != "" {
+		return p
+	}
+
+	a, b := v4.SMTP, v6.SMTP
+	if !strings.EqualFold(a.GreetingHostname, b.GreetingHostname) {
+		diff("Hostname in greeting is %q over IPv4, %q over IPv6.", a.GreetingHostname, b.GreetingHostname)
+	}
+	if a.EHLO != nil && b.EHLO != nil && !strings.EqualFold(a.EHLO.Hostname, b.EHLO.Hostname) {
+		diff("Hostname in EHLO response is %q over IPv4, %q over IPv6.", a.EHLO.Hostname, b.EHLO.Hostname)
+	}
+	ehloDiff("before STARTTLS", a.EHLO, b.EHLO, diff)
+	ehloDiff("after STARTTLS", a.EHLOTLS, b.EHLOTLS, diff)
+	if a.SupportsSTARTTLS != b.SupportsSTARTTLS {
+		diff("STARTTLS supported over IPv4: %v, over IPv6: %v.", a.SupportsSTARTTLS, b.SupportsSTARTTLS)
+	}
+	if a.TLSConnectionState != nil && b.TLSConnectionState != nil {
+		if a.TLSConnectionState.CertFingerprint != b.TLSConnectionState.CertFingerprint {
+			diff("Different TLS certificate over IPv4 (sha256 %s) and IPv6 (sha256 %s).", a.TLSConnectionState.CertFingerprint, b.TLSConnectionState.CertFingerprint)
+		}
+		if a.TLSConnectionState.Version != b.TLSConnectionState.Version {
+			diff("TLS version is %s over IPv4, %s over IPv6.", a.TLSConnectionState.Version, b.TLSConnectionState.Version)
+		}
+	}
+	if daneRequired {
+		verified4 := len(v4.DANEVerifiedRecord.CertAssoc) > 0
+		verified6 := len(v6.DANEVerifiedRecord.CertAssoc) > 0
+		if verified4 != verified6 {
+			diff("DANE verification over IPv4: %v, over IPv6: %v.", verified4, verified6)
+		}
+	}
+	return p
+}
+
+// ehloDiff adds a difference for extensions (with parameters) that are only
+// offered over one of the address families.
+func ehloDiff(when string, a, b *SMTPEHLO, diff func(format string, args ...any)) {
+	if a == nil && b == nil {
+		return
+	} else if a == nil {
+		diff("EHLO %s only seen over IPv6.", when)
+		return
+	} else if b == nil {
+		diff("EHLO %s only seen over IPv4.", when)
+		return
+	}
+	exts := func(e *SMTPEHLO) []string {
+		var l []string
+		for _, x := range e.Extensions {
+			l = append(l, strings.TrimSpace(x.Keyword+" "+x.Params))
+		}
+		return l
+	}
+	la, lb := exts(a), exts(b)
+	var only4, only6 []string
+	for _, s := range la {
+		if !slices.Contains(lb, s) {
+			only4 = append(only4, s)
+		}
+	}
+	for _, s := range lb {
+		if !slices.Contains(la, s) {
+			only6 = append(only6, s)
+		}
+	}
+	if len(only4) > 0 {
+		diff("EHLO extensions %s only over IPv4: %s.", when, strings.Join(only4, ", "))
+	}
+	if len(only6) > 0 {
+		diff("EHLO extensions %s only over IPv6: %s.", when, strings.Join(only6, ", "))
+	}
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjl-/mox/dkim"
+	"github.com/mjl-/mox/dmarc"
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/message"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/ratelimit"
+	"github.com/mjl-/mox/smtp"
+	"github.com/mjl-/mox/spf"
+)
+
+// The reflector is an SMTP server accepting messages for check-<token>@<hostname>,
+// and shows how they arrived, with SPF/DKIM/DMARC results.
+
+var reflectorListen string
+var reflectorCertFile string
+var reflectorKeyFile string
+var reflectorTLSConfig *tls.Config
+
+const reflectorTokenExpiry = time.Hour
+const reflectorMaxMessages = 10
+const reflectorMaxSize = 1024 * 1024
+const reflectorMaxConns = 100
+
+var reflectorLimiter = ratelimit.Limiter{
+	WindowLimits: []ratelimit.WindowLimit{
+		{Window: time.Minute, Limits: [...]int64{10, 20, 30}},
+		{Window: time.Hour, Limits: [...]int64{100, 200, 300}},
+		{Window: 24 * time.Hour, Limits: [...]int64{500, 1000, 1500}},
+	},
+}
+
+type reflectorToken struct {
+	Expires  time.Time
+	Messages []ReflectorMessage
+}
+
+var reflector = struct {
+	sync.Mutex
+	tokens map[string]*reflectorToken
+}{tokens: map[string]*reflectorToken{}}
+
+type ReflectorResult struct {
+	Address  string
+	Expires  time.Time
+	Messages []ReflectorMessage
+}
+
+type ReflectorMessage struct {
+	Received           time.Time
+	DurationMS         int // Of analysis.
+	RemoteIP           net.IP
+	Hello              string // As sent with EHLO or HELO.
+	EHLO               bool
+	TLSConnectionState *TLSConnectionState
+	MailFrom           string // Empty for null reverse path.
+	Size               int
+	IPRev              IPRevResult // Compared against Hello.
+	From               string      // Address in message From header.
+	Subject            string
+	SPF                ReflectorSPF
+	DKIM               []DKIMResult
+	DMARC              ReflectorDMARC
+	Error              string
+}
+
+type ReflectorSPF struct {
+	Status      string
+	Mechanism   string
+	Identity    string // "mailfrom" or "helo".
+	Domain      dns.Domain
+	Explanation string
+	Authentic   bool
+	Error       string
+}
+
+type ReflectorDMARC struct {
+	Status          string
+	Domain          dns.Domain
+	Record          *DMARCRecord
+	RecordAuthentic bool
+	AlignedSPFPass  bool
+	AlignedDKIMPass bool
+	Reject          bool
+	Error           string
+}
+
+func reflectorAddress(token string) string {
+	return smtp.NewAddress(smtp.Localpart("check-"+token), dnsHostname).String()
+}
+
+// ReflectorStart returns a new token and the address to send a message to.
+func (API) ReflectorStart(ctx context.Context) (token, address string) {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	if reflectorListen == "" {
+		xcheckuser(errors.New("not enabled on this instance"), "reflector")
+	}
+
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	xcheckuser(err, "generating token")
+	token = hex.EncodeToString(buf)
+
+	reflector.Lock()
+	defer reflector.Unlock()
+	reflectorCleanup()
+	reflector.tokens[token] = &reflectorToken{Expires: time.Now().Add(reflectorTokenExpiry), Messages: []ReflectorMessage{}}
+
+	log.Debug("reflectorstart call", slog.String("token", token))
+
+	return token, reflectorAddress(token)
+}
+
+// ReflectorResults returns the messages received for the token.
+func (API) ReflectorResults(ctx context.Context, token string) ReflectorResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("reflectorresults call", slog.String("token", token))
+
+	token = strings.ToLower(token)
+	reflector.Lock()
+	defer reflector.Unlock()
+	reflectorCleanup()
+	t, ok := reflector.tokens[token]
+	if !ok {
+		xcheckuser(errors.New("unknown or expired token"), "looking up token")
+	}
+	return ReflectorResult{reflectorAddress(token), t.Expires, append([]ReflectorMessage{}, t.Messages...)}
+}
+
+// Must be called with lock held.
+func reflectorCleanup() {
+	now := time.Now()
+	for k, t := range reflector.tokens {
+		if now.After(t.Expires) {
+			delete(reflector.tokens, k)
+		}
+	}
+}
+
+// Parse the flags for the reflector, called at startup.
+func reflectorInit() {
+	if reflectorCertFile == "" && reflectorKeyFile == "" {
+		return
+	}
+	cert, err := tls.LoadX509KeyPair(reflectorCertFile, reflectorKeyFile)
+	xcheck(err, "loading reflector tls certificate and key")
+	reflectorTLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+}
+
+func reflectorServe() {
+	ln, err := net.Listen("tcp", reflectorListen)
+	xcheck(err, "listen for reflector smtp")
+
+	conns := make(chan struct{}, reflectorMaxConns)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			pkglog.Errorx("accepting reflector smtp connection", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		select {
+		case conns <- struct{}{}:
+		default:
+			fmt.Fprintf(conn, "421 too many connections, try again later\r\n")
+			conn.Close()
+			continue
+		}
+		go func() {
+			log := newLog()
+			defer logPanic(log)
+			defer func() {
+				<-conns
+			}()
+			reflectorConn(log, conn)
+		}()
+	}
+}
+
+type reflectorSession struct {
+	log      mlog.Log
+	conn     net.Conn
+	remoteIP net.IP
+	localIP  net.IP
+	br       *bufio.Reader
+	bw       *bufio.Writer
+
+	hello    string
+	ehlo     bool
+	tls      *tls.ConnectionState
+	mailFrom *smtp.Path
+	tokens   []string
+}
+
+var errReflectorQuit = errors.New("quit")
+
+func reflectorConn(log mlog.Log, conn net.Conn) {
+	defer conn.Close()
+
+	s := &reflectorSession{
+		log:      log,
+		conn:     conn,
+		remoteIP: addrIP(conn.RemoteAddr()),
+		localIP:  addrIP(conn.LocalAddr()),
+		br:       bufio.NewReader(conn),
+		bw:       bufio.NewWriter(conn),
+	}
+	log.Debug("new reflector connection", slog.Any("remoteip", s.remoteIP))
+
+	if ratelimiter && !reflectorLimiter.Add(s.remoteIP, time.Now(), 1) {
+		s.writeline("421 too many connections from your ip, try again later")
+		return
+	}
+
+	s.writeline("220 " + dnsHostname.ASCII + " moxtools reflector")
+	for {
+		conn.SetDeadline(time.Now().Add(5 * time.Minute))
+		line, err := s.br.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			s.writeline("500 line too long")
+			return
+		} else if err != nil {
+			log.Debugx("reading command", err)
+			return
+		}
+		err = s.command(strings.TrimRight(string(line), "\r\n"))
+		if err != nil {
+			if !errors.Is(err, errReflectorQuit) {
+				log.Debugx("reflector connection", err)
+			}
+			return
+		}
+	}
+}
+
+func addrIP(a net.Addr) net.IP {
+	if ta, ok := a.(*net.TCPAddr); ok {
+		return ta.IP
+	}
+	return nil
+}
+
+func (s *reflectorSession) writeline(line string) error {
+	s.bw.WriteString(line + "\r\n")
+	return s.bw.Flush()
+}
+
+func (s *reflectorSession) reset() {
+	s.mailFrom = nil
+	s.tokens = nil
+}
+
+func (s *reflectorSession) command(line string) error {
+	cmd, arg, _ := strings.Cut(line, " ")
+	switch strings.ToUpper(cmd) {
+	case "EHLO", "HELO":
+		if arg == "" {
+			return s.writeline("501 missing hostname")
+		}
+		s.reset()
+		s.hello = arg
+		s.ehlo = strings.EqualFold(cmd, "EHLO")
+		if !s.ehlo {
+			return s.writeline("250 " + dnsHostname.ASCII)
+		}
+		l := []string{dnsHostname.ASCII, "8BITMIME", "SMTPUTF8", fmt.Sprintf("SIZE %d", reflectorMaxSize)}
+		if reflectorTLSConfig != nil && s.tls == nil {
+			l = append(l, "STARTTLS")
+		}
+		for i, v := range l {
+			sep := "-"
+			if i == len(l)-1 {
+				sep = " "
+			}
+			s.bw.WriteString("250" + sep + v + "\r\n")
+		}
+		return s.bw.Flush()
+
+	case "STARTTLS":
+		if reflectorTLSConfig == nil || s.tls != nil {
+			return s.writeline("502 starttls not available")
+		}
+		// Data pipelined after STARTTLS was sent in plaintext, it must not end up in
+		// the TLS session (CVE-2011-0411).
+		if s.br.Buffered() > 0 {
+			return s.writeline("501 no data allowed after starttls command")
+		}
+		if err := s.writeline("220 go ahead"); err != nil {
+			return err
+		}
+		tlsConn := tls.Server(s.conn, reflectorTLSConfig)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("tls handshake: %v", err)
+		}
+		cs := tlsConn.ConnectionState()
+		s.tls = &cs
+		s.conn = tlsConn
+		s.br = bufio.NewReader(tlsConn)
+		s.bw = bufio.NewWriter(tlsConn)
+		s.hello = ""
+		s.reset()
+		return nil
+
+	case "MAIL":
+		if s.hello == "" {
+			return s.writeline("503 send ehlo first")
+		} else if s.mailFrom != nil {
+			return s.writeline("503 already have mail from")
+		}
+		addr, ok := reflectorPath(arg, "FROM:")
+		if !ok {
+			return s.writeline("501 bad mail from")
+		}
+		var path smtp.Path
+		if addr != "" {
+			a, err := smtp.ParseAddress(addr)
+			if err != nil {
+				return s.writeline("501 bad mail from address: " + err.Error())
+			}
+			path = a.Path()
+		}
+		s.mailFrom = &path
+		return s.writeline("250 ok")
+
+	case "RCPT":
+		if s.mailFrom == nil {
+			return s.writeline("503 send mail from first")
+		} else if len(s.tokens) >= 5 {
+			return s.writeline("452 too many recipients")
+		}
+		addr, ok := reflectorPath(arg, "TO:")
+		if !ok {
+			return s.writeline("501 bad rcpt to")
+		}
+		a, err := smtp.ParseAddress(addr)
+		if err != nil {
+			return s.writeline("501 bad rcpt to address: " + err.Error())
+		}
+		token, ok := strings.CutPrefix(strings.ToLower(string(a.Localpart)), "check-")
+		if !ok || a.Domain != dnsHostname {
+			return s.writeline("550 no such user, only check-<token>@" + dnsHostname.ASCII)
+		}
+		reflector.Lock()
+		t, ok := reflector.tokens[token]
+		ok = ok && time.Now().Before(t.Expires)
+		reflector.Unlock()
+		if !ok {
+			return s.writeline("550 unknown or expired token")
+		}
+		s.tokens = append(s.tokens, token)
+		return s.writeline("250 ok")
+
+	case "DATA":
+		if len(s.tokens) == 0 {
+			return s.writeline("503 send rcpt to first")
+		}
+		if err := s.writeline("354 go ahead"); err != nil {
+			return err
+		}
+		return s.data()
+
+	case "RSET":
+		s.reset()
+		return s.writeline("250 ok")
+
+	case "NOOP":
+		return s.writeline("250 ok")
+
+	case "QUIT":
+		s.writeline("221 bye")
+		return errReflectorQuit
+	}
+	return s.writeline("500 unknown command")
+}
+
+// reflectorPath parses "FROM:<address> params" or "TO:<address> params", returning
+// the address.
+func reflectorPath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	arg = strings.TrimLeft(arg[len(prefix):], " ")
+	if !strings.HasPrefix(arg, "<") {
+		return "", false
+	}
+	addr, _, ok := strings.Cut(arg[1:], ">")
+	return addr, ok
+}
+
+func (s *reflectorSession) data() error {
+	dr := smtp.NewDataReader(s.br)
+	buf, err := io.ReadAll(io.LimitReader(dr, reflectorMaxSize+1))
+	if err == nil && len(buf) > reflectorMaxSize {
+		// Read the remainder so the SMTP session stays in sync.
+		_, err = io.Copy(io.Discard, dr)
+		if err == nil {
+			s.reset()
+			return s.writeline("552 message too large")
+		}
+	}
+	if errors.Is(err, smtp.ErrCRLF) {
+		s.reset()
+		return s.writeline("500 bad line endings, only crlf allowed")
+	} else if err != nil {
+		return fmt.Errorf("reading message data: %v", err)
+	}
+
+	m := reflectorAnalyze(s.log, s, reflectorFixLineEndings(buf))
+	reflector.Lock()
+	for _, token := range s.tokens {
+		if t, ok := reflector.tokens[token]; ok && len(t.Messages) < reflectorMaxMessages {
+			t.Messages = append(t.Messages, m)
+		}
+	}
+	reflector.Unlock()
+	s.reset()
+	return s.writeline("250 ok, message analyzed, see results on the moxtools web page")
+}
+
+// reflectorFixLineEndings adds missing carriage returns before bare newlines, like
+// mail servers do.
+func reflectorFixLineEndings(buf []byte) []byte {
+	if !bytes.Contains(buf, []byte("\n")) || bytes.Count(buf, []byte("\n")) == bytes.Count(buf, []byte("\r\n")) {
+		return buf
+	}
+	var nbuf []byte
+	for i, c := range buf {
+		if c == '\n' && (i == 0 || buf[i-1] != '\r') {
+			nbuf = append(nbuf, '\r')
+		}
+		nbuf = append(nbuf, c)
+	}
+	return nbuf
+}
+
+func reflectorAnalyze(log mlog.Log, s *reflectorSession, msg []byte) (m ReflectorMessage) {
+	start := time.Now()
+	defer func() {
+		m.DurationMS = timeSince(start)
+	}()
+
+	m.Received = start
+	m.RemoteIP = s.remoteIP
+	m.Hello = s.hello
+	m.EHLO = s.ehlo
+	m.TLSConnectionState = tlsConnectionState(s.tls)
+	if !s.mailFrom.IsZero() {
+		m.MailFrom = s.mailFrom.String()
+	}
+	m.Size = len(msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var hello dns.IPDomain
+	if ip, ok := strings.CutPrefix(s.hello, "["); ok && strings.HasSuffix(ip, "]") {
+		ip = strings.TrimPrefix(strings.TrimSuffix(ip, "]"), "IPv6:")
+		hello.IP = net.ParseIP(ip)
+	} else if d, err := dns.ParseDomain(s.hello); err == nil {
+		hello.Domain = d
+	}
+
+	// IPRev, SPF and DKIM in parallel, the latter two are needed for DMARC.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		m.IPRev = iprevCheck(ctx, s.remoteIP, s.hello)
+	}()
+	var spfStatus spf.Status
+	var spfIdentity *dns.Domain
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		args := spf.Args{
+			RemoteIP:          s.remoteIP,
+			MailFromLocalpart: s.mailFrom.Localpart,
+			MailFromDomain:    s.mailFrom.IPDomain.Domain,
+			HelloDomain:       hello,
+			LocalIP:           s.localIP,
+			LocalHostname:     dnsHostname,
+		}
+		received, dom, explanation, authentic, err := spf.Verify(ctx, log.Logger, resolver, args)
+		m.SPF = ReflectorSPF{string(received.Result), received.Mechanism, string(received.Identity), dom, explanation, authentic, errmsg(err)}
+		spfStatus = received.Result
+		// Only the mail from domain is used for DMARC alignment.
+		if received.Identity == spf.ReceivedMailFrom {
+			spfIdentity = &dom
+		}
+	}()
+
+	var dkimResults0 []dkim.Result
+	wg.Add(1)
+	go func() {
+		defer logPanic(log)
+		defer wg.Done()
+
+		var err error
+		dkimResults0, err = dkim.Verify(ctx, log.Logger, resolver, true, dkim.DefaultPolicy, bytes.NewReader(msg), false)
+		m.DKIM = dkimResults(dkimResults0)
+		if err != nil {
+			m.Error = fmt.Sprintf("verifying dkim: %v", err)
+		}
+	}()
+
+	from, envelope, _, err := message.From(log.Logger, false, bytes.NewReader(msg), nil)
+	wg.Wait()
+	if err != nil {
+		m.DMARC.Error = fmt.Sprintf("parsing from address: %v", err)
+		return
+	}
+	m.From = from.String()
+	if envelope != nil {
+		m.Subject = envelope.Subject
+	}
+
+	_, result := dmarc.Verify(ctx, log.Logger, resolver, from.Domain, dkimResults0, spfStatus, spfIdentity, false)
+	var record *DMARCRecord
+	if result.Record != nil {
+		record = &DMARCRecord{*result.Record}
+	}
+	m.DMARC = ReflectorDMARC{string(result.Status), result.Domain, record, result.RecordAuthentic, result.AlignedSPFPass, result.AlignedDKIMPass, result.Reject, errmsg(result.Err)}
+	return
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+)
+
+// Report formats for domain check results.
+var reportFormats = []string{"html", "markdown", "json"}
+
+// DomainReport renders a domain check result as a self-contained document, for
+// attaching to tickets. The result is passed in, so the domain doesn't have to be
+// checked again.
+func (API) DomainReport(ctx context.Context, dr DomainResult, format string) string {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("domainreport call", slog.String("domain", dr.Domain.ASCII), slog.String("format", format))
+
+	s, err := domainReport(dr, format, time.Now())
+	xcheckuser(err, "rendering report")
+	return s
+}
+
+// reportSection is a part of a report, with name/value items and optionally
+// verbatim text like records, policies or an SMTP trace.
+type reportSection struct {
+	Title string
+	Items []reportItem
+	Text  string
+}
+
+type reportItem struct {
+	Name  string
+	Value string
+}
+
+func domainReport(dr DomainResult, format string, now time.Time) (string, error) {
+	switch format {
+	case "json":
+		// Canonical: indented, with fields in struct order.
+		buf, err := json.MarshalIndent(dr, "", "\t")
+		if err != nil {
+			return "", err
+		}
+		return string(buf) + "\n", nil
+	case "markdown":
+		return reportMarkdown(dr, reportSections(dr), now), nil
+	case "html":
+		return reportHTML(dr, reportSections(dr), now)
+	}
+	return "", fmt.Errorf("unknown format %q, must be one of %s", format, strings.Join(reportFormats, ", "))
+}
+
+func reportSections(dr DomainResult) []reportSection {
+	yesno := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+	var l []reportSection
+	add := func(title, text string, items ...reportItem) {
+		l = append(l, reportSection{title, items, text})
+	}
+	item := func(name, value string) reportItem {
+		return reportItem{name, value}
+	}
+	errItem := func(items []reportItem, err string) []reportItem {
+		if err != "" {
+			items = append(items, item("Error", err))
+		}
+		return items
+	}
+
+	var findings []reportItem
+	for _, f := range dr.Grade.Findings {
+		s := f.Explanation
+		if f.Remediation != "" {
+			s += " Remediation: " + f.Remediation
+		}
+		if f.Points > 0 {
+			s += fmt.Sprintf(" (-%d)", f.Points)
+		}
+		findings = append(findings, item(f.Severity+": "+f.Title, s))
+	}
+	if len(findings) == 0 {
+		findings = append(findings, item("Findings", "none"))
+	}
+	add("Findings", "", findings...)
+
+	spfItems := []reportItem{item("Status", dr.SPF.Status), item("DNSSEC", yesno(dr.SPF.Authentic))}
+	if dr.SPF.Record != nil {
+		spfItems = append(spfItems, item("DNS lookups", fmt.Sprintf("%d/%d", dr.SPF.Lookups, spfLookupLimit)))
+	}
+	if dr.SPF.LookupsErr != "" {
+		spfItems = append(spfItems, item("Lookups error", dr.SPF.LookupsErr))
+	}
+	for _, ipr := range dr.SPF.DNSBL {
+		spfItems = append(spfItems, item("DNSBL "+ipr.IP.String(), reportDNSBL(ipr)))
+	}
+	add("SPF", dr.SPF.TXT, errItem(spfItems, dr.SPF.Error)...)
+
+	var dkimItems []reportItem
+	for _, d := range dr.DKIM.Selectors {
+		dkimItems = append(dkimItems, item("Selector "+d.Selector, reportDKIM(d)))
+	}
+	if len(dkimItems) == 0 {
+		dkimItems = append(dkimItems, item("Selectors", fmt.Sprintf("none found of %d common selectors", dr.DKIM.Probed)))
+	}
+	if dr.DKIM.Wildcard {
+		dkimItems = append(dkimItems, item("Wildcard", "yes, a random selector has a record too"))
+	}
+	add("DKIM", "", dkimItems...)
+
+	dmarcItems := []reportItem{item("Status", dr.DMARC.Status), item("Domain", dr.DMARC.Domain.Name()), item("DNSSEC", yesno(dr.DMARC.Authentic))}
+	add("DMARC", dr.DMARC.TXT, errItem(dmarcItems, dr.DMARC.Error)...)
+
+	add("TLSRPT", dr.TLSRPT.TXT, errItem([]reportItem{item("Record", yesno(dr.TLSRPT.Record != nil))}, dr.TLSRPT.Error)...)
+
+	var mtastsText string
+	if dr.MTASTS.Record != nil {
+		mtastsText = dr.MTASTS.Record.String() + "\n\n"
+	}
+	mtastsText += dr.MTASTS.PolicyText
+	add("MTA-STS", mtastsText, errItem([]reportItem{item("Implemented", yesno(dr.MTASTS.Implemented))}, dr.MTASTS.Error)...)
+
+	mxItems := []reportItem{item("MX records", yesno(dr.MX.Have)), item("DNSSEC", yesno(dr.MX.OrigNextHopAuthentic))}
+	if dr.MX.ExpandedNextHop.ASCII != "" {
+		mxItems = append(mxItems, item("Expanded (CNAME)", dr.MX.ExpandedNextHop.Name()))
+	}
+	var hosts []string
+	for _, mx := range dr.MXHosts {
+		hosts = append(hosts, mx.Host.String())
+	}
+	mxItems = append(mxItems, item("Hosts", strings.Join(hosts, ", ")))
+	add("MX", "", errItem(mxItems, dr.MX.Error)...)
+
+	smtpItems := func(items []reportItem, dial DomainDial, smtp DomainSMTP) []reportItem {
+		if dial.IP == nil && dial.Error == "" {
+			return append(items, item("Connection", "not attempted"))
+		}
+		if dial.IP != nil {
+			items = append(items, item("Connected to", dial.IP.String()))
+		}
+		if dial.Error != "" {
+			return append(items, item("Dial error", dial.Error))
+		}
+		items = append(items, item("Greeting hostname", smtp.GreetingHostname), item("STARTTLS", yesno(smtp.SupportsSTARTTLS)))
+		if cs := smtp.TLSConnectionState; cs != nil {
+			items = append(items, item("TLS", cs.Version+", "+cs.CipherSuite), item("Certificate SHA-256", cs.CertFingerprint))
+		}
+		items = append(items, item("REQUIRETLS", yesno(smtp.SupportsRequireTLS)), item("SMTPUTF8", yesno(smtp.SupportsSMTPUTF8)))
+		for _, w := range smtp.Warnings {
+			items = append(items, item("Warning", w))
+		}
+		return errItem(items, smtp.Error)
+	}
+	for _, mx := range dr.MXHosts {
+		host := mx.Host.String()
+		var ips []string
+		for _, ip := range mx.IP.IPs {
+			ips = append(ips, ip.String())
+		}
+		items := []reportItem{item("IPs", strings.Join(ips, ", ")), item("DNSSEC", yesno(mx.IP.Authentic))}
+		if mx.MTASTSError != "" {
+			items = append(items, item("MTA-STS", mx.MTASTSError))
+		}
+		items = append(items, item("DANE", yesno(mx.DANE.Required)))
+		for _, r := range mx.DANE.Records {
+			items = append(items, item("TLSA", r.Record()))
+		}
+		if len(mx.DANE.VerifiedRecord.CertAssoc) > 0 {
+			items = append(items, item("TLSA verified", mx.DANE.VerifiedRecord.Record()))
+		}
+		if mx.DANE.Error != "" {
+			items = append(items, item("DANE error", mx.DANE.Error))
+		}
+		for _, r := range mx.IPRev {
+			s := r.Status
+			if r.Name != "" {
+				s += ", " + r.Name
+			}
+			if r.Error != "" {
+				s += ", " + r.Error
+			}
+			items = append(items, item("Reverse DNS "+r.IP.String(), s))
+		}
+		for _, ipr := range mx.DNSBL {
+			items = append(items, item("DNSBL "+ipr.IP.String(), reportDNSBL(ipr)))
+		}
+		if mx.Parity != nil {
+			for _, d := range mx.Parity.Differences {
+				items = append(items, item("IPv4/IPv6 difference", d))
+			}
+		}
+		if len(mx.IPResults) == 0 {
+			add("MX host "+host, reportTrace(mx.SMTP.Trace), smtpItems(items, mx.Dial, mx.SMTP)...)
+			continue
+		}
+		add("MX host "+host, "", items...)
+		for _, ipr := range mx.IPResults {
+			add("MX host "+host+" at "+ipr.IP.String(), reportTrace(ipr.SMTP.Trace), smtpItems(nil, ipr.Dial, ipr.SMTP)...)
+		}
+	}
+	return l
+}
+
+func reportDKIM(d DKIMDiscovered) string {
+	var s string
+	if d.Error != "" {
+		s = d.Error
+	} else if d.Revoked {
+		s = "revoked"
+	} else {
+		s = fmt.Sprintf("%s %d bits", d.KeyType, d.KeyBits)
+		if len(d.Flags) > 0 {
+			s += ", t=" + strings.Join(d.Flags, ":")
+		}
+		if d.Authentic {
+			s += ", dnssec"
+		}
+	}
+	for _, f := range d.Audit {
+		if f.Severity != "info" && f.Check != "dkim.syntax" && f.Check != "dkim.revoked" {
+			s += ", " + f.Severity + ": " + f.Title
+		}
+	}
+	return s
+}
+
+func reportDNSBL(ipr DNSBLIP) string {
+	var l []string
+	for _, r := range ipr.Results {
+		s := r.Zone.Name() + ": " + r.Status
+		if r.Reason != "" {
+			s += " (" + r.Reason + ")"
+		}
+		l = append(l, s)
+	}
+	return strings.Join(l, ", ")
+}
+
+// reportTrace returns the SMTP trace with each line prefixed by "C: " or "S: ".
+func reportTrace(trace []Proto) string {
+	var b strings.Builder
+	for _, p := range trace {
+		prefix := "S: "
+		if p.ClientWrite {
+			prefix = "C: "
+		}
+		for _, line := range strings.SplitAfter(p.Text, "\n") {
+			if line != "" {
+				b.WriteString(prefix + strings.TrimRight(line, "\r\n") + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func reportMarkdown(dr DomainResult, sections []reportSection, now time.Time) string {
+	// Escape characters with meaning in markdown table cells and text.
+	esc := strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "\n", " ")
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Domain check report for %s\n\n", esc.Replace(dr.Domain.Name()))
+	fmt.Fprintf(&b, "Grade **%s**, score %d/100. Checked in %dms, report generated %s.\n", dr.Grade.Grade, dr.Grade.Score, dr.DurationMS, now.UTC().Format(time.RFC3339))
+	for _, s := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", esc.Replace(s.Title))
+		if len(s.Items) > 0 {
+			b.WriteString("| | |\n|---|---|\n")
+			for _, it := range s.Items {
+				fmt.Fprintf(&b, "| %s | %s |\n", esc.Replace(it.Name), esc.Replace(it.Value))
+			}
+		}
+		if s.Text != "" {
+			// Fence must be longer than any backtick sequence in the text.
+			fence := "```"
+			for strings.Contains(s.Text, fence) {
+				fence += "`"
+			}
+			if len(s.Items) > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s\n%s\n%s\n", fence, strings.TrimRight(s.Text, "\n"), fence)
+		}
+	}
+	return b.String()
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!doctype html>
+<html>
+	<head>
+		<meta charset="utf-8" />
+		<title>Domain check report for {{ .Domain }}</title>
+		<style>
+body { font-family: sans-serif; font-size: 14px; line-height: 1.4; margin: 1em 2em; }
+table { border-collapse: collapse; }
+td { border: 1px solid #ddd; padding: .2em .5em; vertical-align: top; }
+td:first-child { font-weight: bold; white-space: nowrap; }
+pre { background: #f4f4f4; padding: .5em; overflow-x: auto; }
+.grade { font-size: 2em; font-weight: bold; }
+		</style>
+	</head>
+	<body>
+		<h1>Domain check report for {{ .Domain }}</h1>
+		<p><span class="grade">{{ .Grade }}</span> {{ .Score }}/100. Checked in {{ .DurationMS }}ms, report generated {{ .Generated }}.</p>
+{{- range .Sections }}
+		<h2>{{ .Title }}</h2>
+		{{- if .Items }}
+		<table>
+			{{- range .Items }}
+			<tr><td>{{ .Name }}</td><td>{{ .Value }}</td></tr>
+			{{- end }}
+		</table>
+		{{- end }}
+		{{- if .Text }}
+		<pre>{{ .Text }}</pre>
+		{{- end }}
+{{- end }}
+	</body>
+</html>
+`))
+
+func reportHTML(dr DomainResult, sections []reportSection, now time.Time) (string, error) {
+	args := struct {
+		Domain     string
+		Grade      string
+		Score      int
+		DurationMS int
+		Generated  string
+		Sections   []reportSection
+	}{dr.Domain.Name(), dr.Grade.Grade, dr.Grade.Score, dr.DurationMS, now.UTC().Format(time.RFC3339), sections}
+	var b bytes.Buffer
+	err := reportTemplate.Execute(&b, args)
+	return b.String(), err
+}
+
+func cmdDomainreport(c *cmd) {
+	var all bool
+	var format string
+	var input string
+	c.flag.BoolVar(&all, "all", false, "connect to all mx hosts, and to each of their ips separately")
+	c.flag.StringVar(&format, "format", "markdown", "report format: "+strings.Join(reportFormats, ", "))
+	c.flag.StringVar(&input, "input", "", "file with json result from domaincheck to render instead of checking the domain, - for stdin")
+	args := c.Parse()
+	if input == "" && len(args) != 1 || input != "" && len(args) != 0 {
+		c.Usage()
+	}
+
+	var dr DomainResult
+	if input != "" {
+		f := os.Stdin
+		if input != "-" {
+			var err error
+			f, err = os.Open(input)
+			xcmdcheck(err, "open input")
+			defer f.Close()
+		}
+		err := json.NewDecoder(f).Decode(&dr)
+		xcmdcheck(err, "parsing domain check result")
+	} else {
+		dom, err := dns.ParseDomain(args[0])
+		xcmdcheck(err, "parsing domain")
+		dr = domainCheck(context.Background(), pkglog, dom, all)
+	}
+
+	s, err := domainReport(dr, format, time.Now())
+	xcmdcheck(err, "rendering report")
+	_, err = os.Stdout.WriteString(s)
+	xcmdcheck(err, "write report")
+}
@@ -271,21 +271,28 @@ var api;
 		Mode["ModeTesting"] = "testing";
 		Mode["ModeNone"] = "none";
 	})(Mode = api.Mode || (api.Mode = {}));
-	api.structTypes = { "DKIMResult": true, "DMARCRecord": true, "Directive": true, "Domain": true, "DomainDANE": true, "DomainDMARC": true, "DomainDial": true, "DomainIP": true, "DomainMTASTS": true, "DomainMX": true, "DomainMXHost": true, "DomainResult": true, "DomainSMTP": true, "DomainSPF": true, "DomainTLSRPT": true, "Extension": true, "IPDomain": true, "Identity": true, "MTASTSRecord": true, "MX": true, "Modifier": true, "Pair": true, "Policy": true, "Proto": true, "Record": true, "SPFReceived": true, "SPFRecord": true, "Sig": true, "TLSARecord": true, "TLSConnectionState": true, "TLSRPTFailureDetails": true, "TLSRPTRecord": true, "TLSRPTResult": true, "TLSRPTResultPolicy": true, "TLSRPTSummary": true, "URI": true };
+	api.structTypes = { "Address": true, "AddressCheckResult": true, "AddressProbe": true, "ClientConfigAutoconfig": true, "ClientConfigAutodiscover": true, "ClientConfigEndpoint": true, "ClientConfigResult": true, "ClientConfigSRV": true, "ClientConfigServer": true, "DKIMBreakage": true, "DKIMDebug": true, "DKIMDebugHeader": true, "DKIMDebugLine": true, "DKIMDiscoverResult": true, "DKIMDiscovered": true, "DKIMHypothesis": true, "DKIMResult": true, "DMARCRecord": true, "DNSBLIP": true, "DNSBLResult": true, "DSN": true, "DSNRecipient": true, "Directive": true, "Domain": true, "DomainBatchResult": true, "DomainDANE": true, "DomainDMARC": true, "DomainDial": true, "DomainGrade": true, "DomainIP": true, "DomainMTASTS": true, "DomainMX": true, "DomainMXHost": true, "DomainMXIP": true, "DomainParity": true, "DomainResult": true, "DomainSMTP": true, "DomainSPF": true, "DomainSummary": true, "DomainTLSRPT": true, "Envelope": true, "ExpectationCheck": true, "Expectations": true, "ExpectationsResult": true, "ExpectedDKIM": true, "Extension": true, "Finding": true, "IPDomain": true, "IPRevResult": true, "Identity": true, "MIMEHeader": true, "MIMEInspection": true, "MIMEPart": true, "MTASTSRecord": true, "MX": true, "Modifier": true, "Pair": true, "Policy": true, "Proto": true, "Record": true, "ReflectorDMARC": true, "ReflectorMessage": true, "ReflectorResult": true, "ReflectorSPF": true, "SMTPAuthResult": true, "SMTPEHLO": true, "SMTPExtension": true, "SMTPReply": true, "SMTPReplyTag": true, "SPFReceived": true, "SPFRecord": true, "SRVRecord": true, "Sig": true, "TLSARecord": true, "TLSConnectionState": true, "TLSRPTFailureDetails": true, "TLSRPTRecord": true, "TLSRPTResult": true, "TLSRPTResultPolicy": true, "TLSRPTSummary": true, "TLSScanCipherSuite": true, "TLSScanResult": true, "TLSScanVersion": true, "TestDeliveryResult": true, "URI": true };
 	api.stringsTypes = { "Align": true, "DKIMStatus": true, "DMARCPolicy": true, "IP": true, "Localpart": true, "Mode": true, "RUA": true };
 	api.intsTypes = { "TLSAMatchType": true, "TLSASelector": true, "TLSAUsage": true };
 	api.types = {
-		"SPFReceived": { "Name": "SPFReceived", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Mechanism", "Docs": "", "Typewords": ["string"] }] },
+		"AddressCheckResult": { "Name": "AddressCheckResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Address", "Docs": "", "Typewords": ["string"] }, { "Name": "Valid", "Docs": "", "Typewords": ["bool"] }, { "Name": "SyntaxError", "Docs": "", "Typewords": ["string"] }, { "Name": "Localpart", "Docs": "", "Typewords": ["string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Normalized", "Docs": "", "Typewords": ["string"] }, { "Name": "SMTPUTF8", "Docs": "", "Typewords": ["bool"] }, { "Name": "Findings", "Docs": "", "Typewords": ["[]", "Finding"] }, { "Name": "HaveMX", "Docs": "", "Typewords": ["bool"] }, { "Name": "NullMX", "Docs": "", "Typewords": ["bool"] }, { "Name": "ExpandedDomain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Hosts", "Docs": "", "Typewords": ["[]", "IPDomain"] }, { "Name": "DestinationsError", "Docs": "", "Typewords": ["string"] }, { "Name": "Deliverable", "Docs": "", "Typewords": ["bool"] }, { "Name": "Probe", "Docs": "", "Typewords": ["nullable", "AddressProbe"] }] },
 		"Domain": { "Name": "Domain", "Docs": "", "Fields": [{ "Name": "ASCII", "Docs": "", "Typewords": ["string"] }, { "Name": "Unicode", "Docs": "", "Typewords": ["string"] }] },
-		"Record": { "Name": "Record", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Hashes", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Key", "Docs": "", "Typewords": ["string"] }, { "Name": "Notes", "Docs": "", "Typewords": ["string"] }, { "Name": "Pubkey", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "Services", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Flags", "Docs": "", "Typewords": ["[]", "string"] }] },
-		"DKIMResult": { "Name": "DKIMResult", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["DKIMStatus"] }, { "Name": "Sig", "Docs": "", "Typewords": ["nullable", "Sig"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "Record"] }, { "Name": "RecordAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
-		"Sig": { "Name": "Sig", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["int32"] }, { "Name": "AlgorithmSign", "Docs": "", "Typewords": ["string"] }, { "Name": "AlgorithmHash", "Docs": "", "Typewords": ["string"] }, { "Name": "Signature", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "BodyHash", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "SignedHeaders", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Selector", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Canonicalization", "Docs": "", "Typewords": ["string"] }, { "Name": "Length", "Docs": "", "Typewords": ["int64"] }, { "Name": "Identity", "Docs": "", "Typewords": ["nullable", "Identity"] }, { "Name": "QueryMethods", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "SignTime", "Docs": "", "Typewords": ["int64"] }, { "Name": "ExpireTime", "Docs": "", "Typewords": ["int64"] }, { "Name": "CopiedHeaders", "Docs": "", "Typewords": ["[]", "string"] }] },
-		"Identity": { "Name": "Identity", "Docs": "", "Fields": [{ "Name": "Localpart", "Docs": "", "Typewords": ["nullable", "Localpart"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }] },
-		"DomainResult": { "Name": "DomainResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "SPF", "Docs": "", "Typewords": ["DomainSPF"] }, { "Name": "DMARC", "Docs": "", "Typewords": ["DomainDMARC"] }, { "Name": "TLSRPT", "Docs": "", "Typewords": ["DomainTLSRPT"] }, { "Name": "MTASTS", "Docs": "", "Typewords": ["DomainMTASTS"] }, { "Name": "MX", "Docs": "", "Typewords": ["DomainMX"] }, { "Name": "MXHosts", "Docs": "", "Typewords": ["[]", "DomainMXHost"] }] },
-		"DomainSPF": { "Name": "DomainSPF", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "TXT", "Docs": "", "Typewords": ["string"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "SPFRecord"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"Finding": { "Name": "Finding", "Docs": "", "Fields": [{ "Name": "Check", "Docs": "", "Typewords": ["string"] }, { "Name": "Severity", "Docs": "", "Typewords": ["string"] }, { "Name": "Title", "Docs": "", "Typewords": ["string"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }, { "Name": "Remediation", "Docs": "", "Typewords": ["string"] }, { "Name": "Points", "Docs": "", "Typewords": ["int32"] }] },
+		"IPDomain": { "Name": "IPDomain", "Docs": "", "Fields": [{ "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }] },
+		"AddressProbe": { "Name": "AddressProbe", "Docs": "", "Fields": [{ "Name": "Host", "Docs": "", "Typewords": ["IPDomain"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Result", "Docs": "", "Typewords": ["string"] }, { "Name": "Response", "Docs": "", "Typewords": ["string"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }, { "Name": "RandomAddress", "Docs": "", "Typewords": ["string"] }, { "Name": "RandomResponse", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Trace", "Docs": "", "Typewords": ["[]", "Proto"] }] },
+		"Proto": { "Name": "Proto", "Docs": "", "Fields": [{ "Name": "ClientWrite", "Docs": "", "Typewords": ["bool"] }, { "Name": "Text", "Docs": "", "Typewords": ["string"] }] },
+		"DomainBatchResult": { "Name": "DomainBatchResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Summaries", "Docs": "", "Typewords": ["[]", "DomainSummary"] }, { "Name": "Results", "Docs": "", "Typewords": ["[]", "nullable", "DomainResult"] }] },
+		"DomainSummary": { "Name": "DomainSummary", "Docs": "", "Fields": [{ "Name": "Domain", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Grade", "Docs": "", "Typewords": ["string"] }, { "Name": "Score", "Docs": "", "Typewords": ["int32"] }, { "Name": "SPF", "Docs": "", "Typewords": ["string"] }, { "Name": "DMARC", "Docs": "", "Typewords": ["string"] }, { "Name": "MTASTS", "Docs": "", "Typewords": ["string"] }, { "Name": "TLSRPT", "Docs": "", "Typewords": ["bool"] }, { "Name": "DNSSEC", "Docs": "", "Typewords": ["bool"] }, { "Name": "DANE", "Docs": "", "Typewords": ["bool"] }, { "Name": "STARTTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "MXHosts", "Docs": "", "Typewords": ["int32"] }, { "Name": "Findings", "Docs": "", "Typewords": ["int32"] }] },
+		"DomainResult": { "Name": "DomainResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "SPF", "Docs": "", "Typewords": ["DomainSPF"] }, { "Name": "DKIM", "Docs": "", "Typewords": ["DKIMDiscoverResult"] }, { "Name": "DMARC", "Docs": "", "Typewords": ["DomainDMARC"] }, { "Name": "TLSRPT", "Docs": "", "Typewords": ["DomainTLSRPT"] }, { "Name": "MTASTS", "Docs": "", "Typewords": ["DomainMTASTS"] }, { "Name": "MX", "Docs": "", "Typewords": ["DomainMX"] }, { "Name": "MXHosts", "Docs": "", "Typewords": ["[]", "DomainMXHost"] }, { "Name": "Grade", "Docs": "", "Typewords": ["DomainGrade"] }] },
+		"DomainSPF": { "Name": "DomainSPF", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "TXT", "Docs": "", "Typewords": ["string"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "SPFRecord"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "DNSBL", "Docs": "", "Typewords": ["[]", "DNSBLIP"] }, { "Name": "Lookups", "Docs": "", "Typewords": ["int32"] }, { "Name": "LookupsErr", "Docs": "", "Typewords": ["string"] }] },
 		"SPFRecord": { "Name": "SPFRecord", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Directives", "Docs": "", "Typewords": ["[]", "Directive"] }, { "Name": "Redirect", "Docs": "", "Typewords": ["string"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }, { "Name": "Other", "Docs": "", "Typewords": ["[]", "Modifier"] }] },
 		"Directive": { "Name": "Directive", "Docs": "", "Fields": [{ "Name": "Qualifier", "Docs": "", "Typewords": ["string"] }, { "Name": "Mechanism", "Docs": "", "Typewords": ["string"] }, { "Name": "DomainSpec", "Docs": "", "Typewords": ["string"] }, { "Name": "IPstr", "Docs": "", "Typewords": ["string"] }, { "Name": "IP4CIDRLen", "Docs": "", "Typewords": ["nullable", "int32"] }, { "Name": "IP6CIDRLen", "Docs": "", "Typewords": ["nullable", "int32"] }] },
 		"Modifier": { "Name": "Modifier", "Docs": "", "Fields": [{ "Name": "Key", "Docs": "", "Typewords": ["string"] }, { "Name": "Value", "Docs": "", "Typewords": ["string"] }] },
+		"DNSBLIP": { "Name": "DNSBLIP", "Docs": "", "Fields": [{ "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Results", "Docs": "", "Typewords": ["[]", "DNSBLResult"] }] },
+		"DNSBLResult": { "Name": "DNSBLResult", "Docs": "", "Fields": [{ "Name": "Zone", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Codes", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Reason", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"DKIMDiscoverResult": { "Name": "DKIMDiscoverResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Probed", "Docs": "", "Typewords": ["int32"] }, { "Name": "Wildcard", "Docs": "", "Typewords": ["bool"] }, { "Name": "Selectors", "Docs": "", "Typewords": ["[]", "DKIMDiscovered"] }] },
+		"DKIMDiscovered": { "Name": "DKIMDiscovered", "Docs": "", "Fields": [{ "Name": "Selector", "Docs": "", "Typewords": ["string"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "TXT", "Docs": "", "Typewords": ["string"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "Record"] }, { "Name": "KeyType", "Docs": "", "Typewords": ["string"] }, { "Name": "KeyBits", "Docs": "", "Typewords": ["int32"] }, { "Name": "Flags", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Revoked", "Docs": "", "Typewords": ["bool"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Audit", "Docs": "", "Typewords": ["[]", "Finding"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"Record": { "Name": "Record", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Hashes", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Key", "Docs": "", "Typewords": ["string"] }, { "Name": "Notes", "Docs": "", "Typewords": ["string"] }, { "Name": "Pubkey", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "Services", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Flags", "Docs": "", "Typewords": ["[]", "string"] }] },
 		"DomainDMARC": { "Name": "DomainDMARC", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "DMARCRecord"] }, { "Name": "TXT", "Docs": "", "Typewords": ["string"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
 		"DMARCRecord": { "Name": "DMARCRecord", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Policy", "Docs": "", "Typewords": ["DMARCPolicy"] }, { "Name": "SubdomainPolicy", "Docs": "", "Typewords": ["DMARCPolicy"] }, { "Name": "AggregateReportAddresses", "Docs": "", "Typewords": ["[]", "URI"] }, { "Name": "FailureReportAddresses", "Docs": "", "Typewords": ["[]", "URI"] }, { "Name": "ADKIM", "Docs": "", "Typewords": ["Align"] }, { "Name": "ASPF", "Docs": "", "Typewords": ["Align"] }, { "Name": "AggregateReportingInterval", "Docs": "", "Typewords": ["int32"] }, { "Name": "FailureReportingOptions", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "ReportingFormat", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Percentage", "Docs": "", "Typewords": ["int32"] }] },
 		"URI": { "Name": "URI", "Docs": "", "Fields": [{ "Name": "Address", "Docs": "", "Typewords": ["string"] }, { "Name": "MaxSize", "Docs": "", "Typewords": ["uint64"] }, { "Name": "Unit", "Docs": "", "Typewords": ["string"] }] },
@@ -298,42 +305,91 @@ var api;
 		"Policy": { "Name": "Policy", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Mode", "Docs": "", "Typewords": ["Mode"] }, { "Name": "MX", "Docs": "", "Typewords": ["[]", "MX"] }, { "Name": "MaxAgeSeconds", "Docs": "", "Typewords": ["int32"] }, { "Name": "Extensions", "Docs": "", "Typewords": ["[]", "Pair"] }] },
 		"MX": { "Name": "MX", "Docs": "", "Fields": [{ "Name": "Wildcard", "Docs": "", "Typewords": ["bool"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }] },
 		"DomainMX": { "Name": "DomainMX", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Have", "Docs": "", "Typewords": ["bool"] }, { "Name": "OrigNextHopAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "ExpandedNextHopAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "ExpandedNextHop", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Permanent", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
-		"DomainMXHost": { "Name": "DomainMXHost", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Host", "Docs": "", "Typewords": ["IPDomain"] }, { "Name": "MTASTSError", "Docs": "", "Typewords": ["string"] }, { "Name": "IP", "Docs": "", "Typewords": ["DomainIP"] }, { "Name": "DANE", "Docs": "", "Typewords": ["DomainDANE"] }, { "Name": "Dial", "Docs": "", "Typewords": ["DomainDial"] }, { "Name": "SMTP", "Docs": "", "Typewords": ["DomainSMTP"] }] },
-		"IPDomain": { "Name": "IPDomain", "Docs": "", "Fields": [{ "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }] },
+		"DomainMXHost": { "Name": "DomainMXHost", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Host", "Docs": "", "Typewords": ["IPDomain"] }, { "Name": "MTASTSError", "Docs": "", "Typewords": ["string"] }, { "Name": "IP", "Docs": "", "Typewords": ["DomainIP"] }, { "Name": "DNSBL", "Docs": "", "Typewords": ["[]", "DNSBLIP"] }, { "Name": "IPRev", "Docs": "", "Typewords": ["[]", "IPRevResult"] }, { "Name": "DANE", "Docs": "", "Typewords": ["DomainDANE"] }, { "Name": "Dial", "Docs": "", "Typewords": ["DomainDial"] }, { "Name": "SMTP", "Docs": "", "Typewords": ["DomainSMTP"] }, { "Name": "IPResults", "Docs": "", "Typewords": ["[]", "DomainMXIP"] }, { "Name": "Parity", "Docs": "", "Typewords": ["nullable", "DomainParity"] }] },
 		"DomainIP": { "Name": "DomainIP", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "ExpandedAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "ExpandedHost", "Docs": "", "Typewords": ["Domain"] }, { "Name": "IPs", "Docs": "", "Typewords": ["[]", "IP"] }, { "Name": "DualStack", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
-		"DomainDANE": { "Name": "DomainDANE", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Required", "Docs": "", "Typewords": ["bool"] }, { "Name": "Records", "Docs": "", "Typewords": ["[]", "TLSARecord"] }, { "Name": "TLSABaseDomain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "VerifiedRecord", "Docs": "", "Typewords": ["TLSARecord"] }] },
+		"IPRevResult": { "Name": "IPRevResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "Names", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "EHLO", "Docs": "", "Typewords": ["string"] }, { "Name": "EHLOMatch", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"DomainDANE": { "Name": "DomainDANE", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Required", "Docs": "", "Typewords": ["bool"] }, { "Name": "Records", "Docs": "", "Typewords": ["[]", "TLSARecord"] }, { "Name": "TLSABaseDomain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "VerifiedRecord", "Docs": "", "Typewords": ["TLSARecord"] }, { "Name": "WithoutDNSSEC", "Docs": "", "Typewords": ["bool"] }] },
 		"TLSARecord": { "Name": "TLSARecord", "Docs": "", "Fields": [{ "Name": "Usage", "Docs": "", "Typewords": ["TLSAUsage"] }, { "Name": "Selector", "Docs": "", "Typewords": ["TLSASelector"] }, { "Name": "MatchType", "Docs": "", "Typewords": ["TLSAMatchType"] }, { "Name": "CertAssoc", "Docs": "", "Typewords": ["nullable", "string"] }] },
 		"DomainDial": { "Name": "DomainDial", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
-		"DomainSMTP": { "Name": "DomainSMTP", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Supports8bitMIME", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsRequireTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsSMTPUTF8", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsSTARTTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "RecipientDomainResult", "Docs": "", "Typewords": ["nullable", "TLSRPTResult"] }, { "Name": "HostResult", "Docs": "", "Typewords": ["nullable", "TLSRPTResult"] }, { "Name": "Trace", "Docs": "", "Typewords": ["[]", "Proto"] }] },
-		"TLSConnectionState": { "Name": "TLSConnectionState", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "CipherSuite", "Docs": "", "Typewords": ["string"] }, { "Name": "NegotiatedProtocol", "Docs": "", "Typewords": ["string"] }, { "Name": "ServerName", "Docs": "", "Typewords": ["string"] }] },
+		"DomainSMTP": { "Name": "DomainSMTP", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Supports8bitMIME", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsRequireTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsSMTPUTF8", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsSTARTTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "RecipientDomainResult", "Docs": "", "Typewords": ["nullable", "TLSRPTResult"] }, { "Name": "HostResult", "Docs": "", "Typewords": ["nullable", "TLSRPTResult"] }, { "Name": "GreetingHostname", "Docs": "", "Typewords": ["string"] }, { "Name": "EHLO", "Docs": "", "Typewords": ["nullable", "SMTPEHLO"] }, { "Name": "EHLOTLS", "Docs": "", "Typewords": ["nullable", "SMTPEHLO"] }, { "Name": "Warnings", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Trace", "Docs": "", "Typewords": ["[]", "Proto"] }] },
+		"TLSConnectionState": { "Name": "TLSConnectionState", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "CipherSuite", "Docs": "", "Typewords": ["string"] }, { "Name": "NegotiatedProtocol", "Docs": "", "Typewords": ["string"] }, { "Name": "ServerName", "Docs": "", "Typewords": ["string"] }, { "Name": "CertFingerprint", "Docs": "", "Typewords": ["string"] }] },
 		"TLSRPTResult": { "Name": "TLSRPTResult", "Docs": "", "Fields": [{ "Name": "Policy", "Docs": "", "Typewords": ["TLSRPTResultPolicy"] }, { "Name": "Summary", "Docs": "", "Typewords": ["TLSRPTSummary"] }, { "Name": "FailureDetails", "Docs": "", "Typewords": ["[]", "TLSRPTFailureDetails"] }] },
 		"TLSRPTResultPolicy": { "Name": "TLSRPTResultPolicy", "Docs": "", "Fields": [{ "Name": "Type", "Docs": "", "Typewords": ["string"] }, { "Name": "String", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["string"] }, { "Name": "MXHost", "Docs": "", "Typewords": ["[]", "string"] }] },
 		"TLSRPTSummary": { "Name": "TLSRPTSummary", "Docs": "", "Fields": [{ "Name": "TotalSuccessfulSessionCount", "Docs": "", "Typewords": ["int64"] }, { "Name": "TotalFailureSessionCount", "Docs": "", "Typewords": ["int64"] }] },
 		"TLSRPTFailureDetails": { "Name": "TLSRPTFailureDetails", "Docs": "", "Fields": [{ "Name": "ResultType", "Docs": "", "Typewords": ["string"] }, { "Name": "SendingMTAIP", "Docs": "", "Typewords": ["string"] }, { "Name": "ReceivingMXHostname", "Docs": "", "Typewords": ["string"] }, { "Name": "ReceivingMXHelo", "Docs": "", "Typewords": ["string"] }, { "Name": "ReceivingIP", "Docs": "", "Typewords": ["string"] }, { "Name": "FailedSessionCount", "Docs": "", "Typewords": ["int64"] }, { "Name": "AdditionalInformation", "Docs": "", "Typewords": ["string"] }, { "Name": "FailureReasonCode", "Docs": "", "Typewords": ["string"] }] },
-		"Proto": { "Name": "Proto", "Docs": "", "Fields": [{ "Name": "ClientWrite", "Docs": "", "Typewords": ["bool"] }, { "Name": "Text", "Docs": "", "Typewords": ["string"] }] },
+		"SMTPEHLO": { "Name": "SMTPEHLO", "Docs": "", "Fields": [{ "Name": "Hostname", "Docs": "", "Typewords": ["string"] }, { "Name": "Extensions", "Docs": "", "Typewords": ["[]", "SMTPExtension"] }, { "Name": "Size", "Docs": "", "Typewords": ["int64"] }, { "Name": "Pipelining", "Docs": "", "Typewords": ["bool"] }, { "Name": "Chunking", "Docs": "", "Typewords": ["bool"] }, { "Name": "DSN", "Docs": "", "Typewords": ["bool"] }, { "Name": "EnhancedStatusCodes", "Docs": "", "Typewords": ["bool"] }, { "Name": "StartTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "AuthMechanisms", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "LimitRcptMax", "Docs": "", "Typewords": ["int32"] }, { "Name": "LimitMailMax", "Docs": "", "Typewords": ["int32"] }, { "Name": "LimitRcptDomainMax", "Docs": "", "Typewords": ["int32"] }] },
+		"SMTPExtension": { "Name": "SMTPExtension", "Docs": "", "Fields": [{ "Name": "Keyword", "Docs": "", "Typewords": ["string"] }, { "Name": "Params", "Docs": "", "Typewords": ["string"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }] },
+		"DomainMXIP": { "Name": "DomainMXIP", "Docs": "", "Fields": [{ "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Dial", "Docs": "", "Typewords": ["DomainDial"] }, { "Name": "SMTP", "Docs": "", "Typewords": ["DomainSMTP"] }, { "Name": "DANEVerifiedRecord", "Docs": "", "Typewords": ["TLSARecord"] }] },
+		"DomainParity": { "Name": "DomainParity", "Docs": "", "Fields": [{ "Name": "IPv4", "Docs": "", "Typewords": ["DomainMXIP"] }, { "Name": "IPv6", "Docs": "", "Typewords": ["DomainMXIP"] }, { "Name": "Differences", "Docs": "", "Typewords": ["[]", "string"] }] },
+		"DomainGrade": { "Name": "DomainGrade", "Docs": "", "Fields": [{ "Name": "Score", "Docs": "", "Typewords": ["int32"] }, { "Name": "Grade", "Docs": "", "Typewords": ["string"] }, { "Name": "Findings", "Docs": "", "Typewords": ["[]", "Finding"] }] },
+		"ClientConfigResult": { "Name": "ClientConfigResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "SRV", "Docs": "", "Typewords": ["[]", "ClientConfigSRV"] }, { "Name": "Autoconfig", "Docs": "", "Typewords": ["ClientConfigAutoconfig"] }, { "Name": "Autodiscover", "Docs": "", "Typewords": ["ClientConfigAutodiscover"] }, { "Name": "Endpoints", "Docs": "", "Typewords": ["[]", "ClientConfigEndpoint"] }, { "Name": "Mismatches", "Docs": "", "Typewords": ["[]", "string"] }] },
+		"ClientConfigSRV": { "Name": "ClientConfigSRV", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Service", "Docs": "", "Typewords": ["string"] }, { "Name": "Protocol", "Docs": "", "Typewords": ["string"] }, { "Name": "Security", "Docs": "", "Typewords": ["string"] }, { "Name": "Records", "Docs": "", "Typewords": ["[]", "SRVRecord"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"SRVRecord": { "Name": "SRVRecord", "Docs": "", "Fields": [{ "Name": "Target", "Docs": "", "Typewords": ["string"] }, { "Name": "Port", "Docs": "", "Typewords": ["int32"] }, { "Name": "Priority", "Docs": "", "Typewords": ["int32"] }, { "Name": "Weight", "Docs": "", "Typewords": ["int32"] }] },
+		"ClientConfigAutoconfig": { "Name": "ClientConfigAutoconfig", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "URL", "Docs": "", "Typewords": ["string"] }, { "Name": "Servers", "Docs": "", "Typewords": ["[]", "ClientConfigServer"] }, { "Name": "XML", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"ClientConfigServer": { "Name": "ClientConfigServer", "Docs": "", "Fields": [{ "Name": "Protocol", "Docs": "", "Typewords": ["string"] }, { "Name": "Host", "Docs": "", "Typewords": ["string"] }, { "Name": "Port", "Docs": "", "Typewords": ["int32"] }, { "Name": "Security", "Docs": "", "Typewords": ["string"] }, { "Name": "Username", "Docs": "", "Typewords": ["string"] }, { "Name": "Authentication", "Docs": "", "Typewords": ["string"] }] },
+		"ClientConfigAutodiscover": { "Name": "ClientConfigAutodiscover", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "URL", "Docs": "", "Typewords": ["string"] }, { "Name": "Servers", "Docs": "", "Typewords": ["[]", "ClientConfigServer"] }, { "Name": "XML", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"ClientConfigEndpoint": { "Name": "ClientConfigEndpoint", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Protocol", "Docs": "", "Typewords": ["string"] }, { "Name": "Host", "Docs": "", "Typewords": ["string"] }, { "Name": "Port", "Docs": "", "Typewords": ["int32"] }, { "Name": "Security", "Docs": "", "Typewords": ["string"] }, { "Name": "Sources", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Greeting", "Docs": "", "Typewords": ["string"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"TestDeliveryResult": { "Name": "TestDeliveryResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "MailFrom", "Docs": "", "Typewords": ["string"] }, { "Name": "RcptTo", "Docs": "", "Typewords": ["string"] }, { "Name": "MessageID", "Docs": "", "Typewords": ["string"] }, { "Name": "DKIMSigned", "Docs": "", "Typewords": ["bool"] }, { "Name": "Host", "Docs": "", "Typewords": ["IPDomain"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "Supports8bitMIME", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsRequireTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "SupportsSMTPUTF8", "Docs": "", "Typewords": ["bool"] }, { "Name": "Need8bitMIME", "Docs": "", "Typewords": ["bool"] }, { "Name": "NeedSMTPUTF8", "Docs": "", "Typewords": ["bool"] }, { "Name": "NeedRequireTLS", "Docs": "", "Typewords": ["bool"] }, { "Name": "Response", "Docs": "", "Typewords": ["string"] }, { "Name": "QueueID", "Docs": "", "Typewords": ["string"] }, { "Name": "Success", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Trace", "Docs": "", "Typewords": ["[]", "Proto"] }] },
+		"DKIMBreakage": { "Name": "DKIMBreakage", "Docs": "", "Fields": [{ "Name": "Result", "Docs": "", "Typewords": ["DKIMResult"] }, { "Name": "HeadersOK", "Docs": "", "Typewords": ["bool"] }, { "Name": "BodyOK", "Docs": "", "Typewords": ["bool"] }, { "Name": "Hypotheses", "Docs": "", "Typewords": ["[]", "DKIMHypothesis"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"DKIMResult": { "Name": "DKIMResult", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["DKIMStatus"] }, { "Name": "Sig", "Docs": "", "Typewords": ["nullable", "Sig"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "Record"] }, { "Name": "RecordAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"Sig": { "Name": "Sig", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["int32"] }, { "Name": "AlgorithmSign", "Docs": "", "Typewords": ["string"] }, { "Name": "AlgorithmHash", "Docs": "", "Typewords": ["string"] }, { "Name": "Signature", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "BodyHash", "Docs": "", "Typewords": ["nullable", "string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "SignedHeaders", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Selector", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Canonicalization", "Docs": "", "Typewords": ["string"] }, { "Name": "Length", "Docs": "", "Typewords": ["int64"] }, { "Name": "Identity", "Docs": "", "Typewords": ["nullable", "Identity"] }, { "Name": "QueryMethods", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "SignTime", "Docs": "", "Typewords": ["int64"] }, { "Name": "ExpireTime", "Docs": "", "Typewords": ["int64"] }, { "Name": "CopiedHeaders", "Docs": "", "Typewords": ["[]", "string"] }] },
+		"Identity": { "Name": "Identity", "Docs": "", "Fields": [{ "Name": "Localpart", "Docs": "", "Typewords": ["nullable", "Localpart"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }] },
+		"DKIMHypothesis": { "Name": "DKIMHypothesis", "Docs": "", "Fields": [{ "Name": "Text", "Docs": "", "Typewords": ["string"] }, { "Name": "Confirmed", "Docs": "", "Typewords": ["bool"] }] },
+		"DKIMDebug": { "Name": "DKIMDebug", "Docs": "", "Fields": [{ "Name": "Result", "Docs": "", "Typewords": ["DKIMResult"] }, { "Name": "HeaderCanon", "Docs": "", "Typewords": ["string"] }, { "Name": "BodyCanon", "Docs": "", "Typewords": ["string"] }, { "Name": "Headers", "Docs": "", "Typewords": ["[]", "DKIMDebugHeader"] }, { "Name": "Body", "Docs": "", "Typewords": ["string"] }, { "Name": "BodyLength", "Docs": "", "Typewords": ["int32"] }, { "Name": "Length", "Docs": "", "Typewords": ["int64"] }, { "Name": "BodyHash", "Docs": "", "Typewords": ["string"] }, { "Name": "BodyHashComputed", "Docs": "", "Typewords": ["string"] }, { "Name": "BodyHashOther", "Docs": "", "Typewords": ["string"] }, { "Name": "BodyLines", "Docs": "", "Typewords": ["[]", "DKIMDebugLine"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"DKIMDebugHeader": { "Name": "DKIMDebugHeader", "Docs": "", "Fields": [{ "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "Missing", "Docs": "", "Typewords": ["bool"] }, { "Name": "Raw", "Docs": "", "Typewords": ["string"] }, { "Name": "Canonical", "Docs": "", "Typewords": ["string"] }, { "Name": "Other", "Docs": "", "Typewords": ["string"] }] },
+		"DKIMDebugLine": { "Name": "DKIMDebugLine", "Docs": "", "Fields": [{ "Name": "Line", "Docs": "", "Typewords": ["int32"] }, { "Name": "Canonical", "Docs": "", "Typewords": ["string"] }, { "Name": "Other", "Docs": "", "Typewords": ["string"] }] },
+		"DSN": { "Name": "DSN", "Docs": "", "Fields": [{ "Name": "Format", "Docs": "", "Typewords": ["string"] }, { "Name": "ReportingMTA", "Docs": "", "Typewords": ["string"] }, { "Name": "ArrivalDate", "Docs": "", "Typewords": ["string"] }, { "Name": "Recipients", "Docs": "", "Typewords": ["[]", "DSNRecipient"] }, { "Name": "Text", "Docs": "", "Typewords": ["string"] }, { "Name": "OriginalHeaders", "Docs": "", "Typewords": ["[]", "MIMEHeader"] }] },
+		"DSNRecipient": { "Name": "DSNRecipient", "Docs": "", "Fields": [{ "Name": "FinalRecipient", "Docs": "", "Typewords": ["string"] }, { "Name": "OriginalRecipient", "Docs": "", "Typewords": ["string"] }, { "Name": "Action", "Docs": "", "Typewords": ["string"] }, { "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "StatusExplanation", "Docs": "", "Typewords": ["string"] }, { "Name": "DiagnosticCode", "Docs": "", "Typewords": ["string"] }, { "Name": "RemoteMTA", "Docs": "", "Typewords": ["string"] }, { "Name": "LastAttemptDate", "Docs": "", "Typewords": ["string"] }, { "Name": "WillRetryUntil", "Docs": "", "Typewords": ["string"] }] },
+		"MIMEHeader": { "Name": "MIMEHeader", "Docs": "", "Fields": [{ "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "Value", "Docs": "", "Typewords": ["string"] }, { "Name": "Decoded", "Docs": "", "Typewords": ["string"] }] },
+		"Expectations": { "Name": "Expectations", "Docs": "", "Fields": [{ "Name": "Domain", "Docs": "", "Typewords": ["string"] }, { "Name": "DMARCPolicy", "Docs": "", "Typewords": ["string"] }, { "Name": "SPFAll", "Docs": "", "Typewords": ["string"] }, { "Name": "MX", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "DANERequired", "Docs": "", "Typewords": ["bool"] }, { "Name": "MTASTSMode", "Docs": "", "Typewords": ["string"] }, { "Name": "MTASTSMinMaxAge", "Docs": "", "Typewords": ["int32"] }, { "Name": "TLSRPT", "Docs": "", "Typewords": ["bool"] }, { "Name": "MinScore", "Docs": "", "Typewords": ["int32"] }, { "Name": "DKIM", "Docs": "", "Typewords": ["[]", "ExpectedDKIM"] }] },
+		"ExpectedDKIM": { "Name": "ExpectedDKIM", "Docs": "", "Fields": [{ "Name": "Selector", "Docs": "", "Typewords": ["string"] }, { "Name": "KeyType", "Docs": "", "Typewords": ["string"] }] },
+		"ExpectationsResult": { "Name": "ExpectationsResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Checks", "Docs": "", "Typewords": ["[]", "ExpectationCheck"] }, { "Name": "Violations", "Docs": "", "Typewords": ["int32"] }, { "Name": "Result", "Docs": "", "Typewords": ["DomainResult"] }] },
+		"ExpectationCheck": { "Name": "ExpectationCheck", "Docs": "", "Fields": [{ "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "Expected", "Docs": "", "Typewords": ["string"] }, { "Name": "Actual", "Docs": "", "Typewords": ["string"] }, { "Name": "OK", "Docs": "", "Typewords": ["bool"] }] },
+		"SPFReceived": { "Name": "SPFReceived", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Mechanism", "Docs": "", "Typewords": ["string"] }] },
+		"MIMEInspection": { "Name": "MIMEInspection", "Docs": "", "Fields": [{ "Name": "Size", "Docs": "", "Typewords": ["int32"] }, { "Name": "Part", "Docs": "", "Typewords": ["nullable", "MIMEPart"] }, { "Name": "Findings", "Docs": "", "Typewords": ["[]", "Finding"] }] },
+		"MIMEPart": { "Name": "MIMEPart", "Docs": "", "Fields": [{ "Name": "Path", "Docs": "", "Typewords": ["string"] }, { "Name": "ContentType", "Docs": "", "Typewords": ["string"] }, { "Name": "Params", "Docs": "", "Typewords": ["{}", "string"] }, { "Name": "Charset", "Docs": "", "Typewords": ["string"] }, { "Name": "ContentTransferEncoding", "Docs": "", "Typewords": ["string"] }, { "Name": "Disposition", "Docs": "", "Typewords": ["string"] }, { "Name": "Filename", "Docs": "", "Typewords": ["string"] }, { "Name": "ContentID", "Docs": "", "Typewords": ["string"] }, { "Name": "Headers", "Docs": "", "Typewords": ["[]", "MIMEHeader"] }, { "Name": "Envelope", "Docs": "", "Typewords": ["nullable", "Envelope"] }, { "Name": "Size", "Docs": "", "Typewords": ["int64"] }, { "Name": "DecodedSize", "Docs": "", "Typewords": ["int64"] }, { "Name": "Lines", "Docs": "", "Typewords": ["int64"] }, { "Name": "Parts", "Docs": "", "Typewords": ["[]", "MIMEPart"] }, { "Name": "Message", "Docs": "", "Typewords": ["nullable", "MIMEPart"] }] },
+		"Envelope": { "Name": "Envelope", "Docs": "", "Fields": [{ "Name": "Date", "Docs": "", "Typewords": ["timestamp"] }, { "Name": "Subject", "Docs": "", "Typewords": ["string"] }, { "Name": "From", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "Sender", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "ReplyTo", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "To", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "CC", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "BCC", "Docs": "", "Typewords": ["[]", "Address"] }, { "Name": "InReplyTo", "Docs": "", "Typewords": ["string"] }, { "Name": "MessageID", "Docs": "", "Typewords": ["string"] }] },
+		"Address": { "Name": "Address", "Docs": "", "Fields": [{ "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "User", "Docs": "", "Typewords": ["string"] }, { "Name": "Host", "Docs": "", "Typewords": ["string"] }] },
+		"ReflectorResult": { "Name": "ReflectorResult", "Docs": "", "Fields": [{ "Name": "Address", "Docs": "", "Typewords": ["string"] }, { "Name": "Expires", "Docs": "", "Typewords": ["timestamp"] }, { "Name": "Messages", "Docs": "", "Typewords": ["[]", "ReflectorMessage"] }] },
+		"ReflectorMessage": { "Name": "ReflectorMessage", "Docs": "", "Fields": [{ "Name": "Received", "Docs": "", "Typewords": ["timestamp"] }, { "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "RemoteIP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Hello", "Docs": "", "Typewords": ["string"] }, { "Name": "EHLO", "Docs": "", "Typewords": ["bool"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "MailFrom", "Docs": "", "Typewords": ["string"] }, { "Name": "Size", "Docs": "", "Typewords": ["int32"] }, { "Name": "IPRev", "Docs": "", "Typewords": ["IPRevResult"] }, { "Name": "From", "Docs": "", "Typewords": ["string"] }, { "Name": "Subject", "Docs": "", "Typewords": ["string"] }, { "Name": "SPF", "Docs": "", "Typewords": ["ReflectorSPF"] }, { "Name": "DKIM", "Docs": "", "Typewords": ["[]", "DKIMResult"] }, { "Name": "DMARC", "Docs": "", "Typewords": ["ReflectorDMARC"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"ReflectorSPF": { "Name": "ReflectorSPF", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Mechanism", "Docs": "", "Typewords": ["string"] }, { "Name": "Identity", "Docs": "", "Typewords": ["string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }, { "Name": "Authentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"ReflectorDMARC": { "Name": "ReflectorDMARC", "Docs": "", "Fields": [{ "Name": "Status", "Docs": "", "Typewords": ["string"] }, { "Name": "Domain", "Docs": "", "Typewords": ["Domain"] }, { "Name": "Record", "Docs": "", "Typewords": ["nullable", "DMARCRecord"] }, { "Name": "RecordAuthentic", "Docs": "", "Typewords": ["bool"] }, { "Name": "AlignedSPFPass", "Docs": "", "Typewords": ["bool"] }, { "Name": "AlignedDKIMPass", "Docs": "", "Typewords": ["bool"] }, { "Name": "Reject", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"SMTPAuthResult": { "Name": "SMTPAuthResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Host", "Docs": "", "Typewords": ["Domain"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Port", "Docs": "", "Typewords": ["int32"] }, { "Name": "Security", "Docs": "", "Typewords": ["string"] }, { "Name": "TLSConnectionState", "Docs": "", "Typewords": ["nullable", "TLSConnectionState"] }, { "Name": "Mechanisms", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Mechanism", "Docs": "", "Typewords": ["string"] }, { "Name": "ChannelBinding", "Docs": "", "Typewords": ["bool"] }, { "Name": "Success", "Docs": "", "Typewords": ["bool"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }, { "Name": "Trace", "Docs": "", "Typewords": ["[]", "Proto"] }] },
+		"SMTPReply": { "Name": "SMTPReply", "Docs": "", "Fields": [{ "Name": "Code", "Docs": "", "Typewords": ["int32"] }, { "Name": "CodeExplanation", "Docs": "", "Typewords": ["string"] }, { "Name": "EnhancedCode", "Docs": "", "Typewords": ["string"] }, { "Name": "Class", "Docs": "", "Typewords": ["string"] }, { "Name": "Subject", "Docs": "", "Typewords": ["string"] }, { "Name": "Detail", "Docs": "", "Typewords": ["string"] }, { "Name": "Text", "Docs": "", "Typewords": ["string"] }, { "Name": "ClientError", "Docs": "", "Typewords": ["string"] }, { "Name": "Tags", "Docs": "", "Typewords": ["[]", "SMTPReplyTag"] }, { "Name": "Advice", "Docs": "", "Typewords": ["string"] }] },
+		"SMTPReplyTag": { "Name": "SMTPReplyTag", "Docs": "", "Fields": [{ "Name": "Provider", "Docs": "", "Typewords": ["string"] }, { "Name": "Tag", "Docs": "", "Typewords": ["string"] }, { "Name": "Explanation", "Docs": "", "Typewords": ["string"] }] },
+		"TLSScanResult": { "Name": "TLSScanResult", "Docs": "", "Fields": [{ "Name": "DurationMS", "Docs": "", "Typewords": ["int32"] }, { "Name": "Host", "Docs": "", "Typewords": ["IPDomain"] }, { "Name": "IP", "Docs": "", "Typewords": ["IP"] }, { "Name": "Versions", "Docs": "", "Typewords": ["[]", "TLSScanVersion"] }, { "Name": "CipherSuites", "Docs": "", "Typewords": ["[]", "TLSScanCipherSuite"] }, { "Name": "CertKeyTypes", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "DualCert", "Docs": "", "Typewords": ["bool"] }, { "Name": "Warnings", "Docs": "", "Typewords": ["[]", "string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"TLSScanVersion": { "Name": "TLSScanVersion", "Docs": "", "Fields": [{ "Name": "Version", "Docs": "", "Typewords": ["string"] }, { "Name": "Accepted", "Docs": "", "Typewords": ["bool"] }, { "Name": "CipherSuite", "Docs": "", "Typewords": ["string"] }, { "Name": "Error", "Docs": "", "Typewords": ["string"] }] },
+		"TLSScanCipherSuite": { "Name": "TLSScanCipherSuite", "Docs": "", "Fields": [{ "Name": "Name", "Docs": "", "Typewords": ["string"] }, { "Name": "Insecure", "Docs": "", "Typewords": ["bool"] }, { "Name": "Accepted", "Docs": "", "Typewords": ["bool"] }] },
 		"TLSAUsage": { "Name": "TLSAUsage", "Docs": "", "Values": [{ "Name": "TLSAUsagePKIXTA", "Value": 0, "Docs": "" }, { "Name": "TLSAUsagePKIXEE", "Value": 1, "Docs": "" }, { "Name": "TLSAUsageDANETA", "Value": 2, "Docs": "" }, { "Name": "TLSAUsageDANEEE", "Value": 3, "Docs": "" }] },
 		"TLSASelector": { "Name": "TLSASelector", "Docs": "", "Values": [{ "Name": "TLSASelectorCert", "Value": 0, "Docs": "" }, { "Name": "TLSASelectorSPKI", "Value": 1, "Docs": "" }] },
 		"TLSAMatchType": { "Name": "TLSAMatchType", "Docs": "", "Values": [{ "Name": "TLSAMatchTypeFull", "Value": 0, "Docs": "" }, { "Name": "TLSAMatchTypeSHA256", "Value": 1, "Docs": "" }, { "Name": "TLSAMatchTypeSHA512", "Value": 2, "Docs": "" }] },
-		"DKIMStatus": { "Name": "DKIMStatus", "Docs": "", "Values": null },
-		"Localpart": { "Name": "Localpart", "Docs": "", "Values": null },
+		"IP": { "Name": "IP", "Docs": "", "Values": [] },
 		"DMARCPolicy": { "Name": "DMARCPolicy", "Docs": "", "Values": [{ "Name": "PolicyEmpty", "Value": "", "Docs": "" }, { "Name": "PolicyNone", "Value": "none", "Docs": "" }, { "Name": "PolicyQuarantine", "Value": "quarantine", "Docs": "" }, { "Name": "PolicyReject", "Value": "reject", "Docs": "" }] },
 		"Align": { "Name": "Align", "Docs": "", "Values": [{ "Name": "AlignStrict", "Value": "s", "Docs": "" }, { "Name": "AlignRelaxed", "Value": "r", "Docs": "" }] },
 		"RUA": { "Name": "RUA", "Docs": "", "Values": null },
 		"Mode": { "Name": "Mode", "Docs": "", "Values": [{ "Name": "ModeEnforce", "Value": "enforce", "Docs": "" }, { "Name": "ModeTesting", "Value": "testing", "Docs": "" }, { "Name": "ModeNone", "Value": "none", "Docs": "" }] },
-		"IP": { "Name": "IP", "Docs": "", "Values": [] },
+		"DKIMStatus": { "Name": "DKIMStatus", "Docs": "", "Values": null },
+		"Localpart": { "Name": "Localpart", "Docs": "", "Values": null },
 	};
 	api.parser = {
-		SPFReceived: (v) => api.parse("SPFReceived", v),
+		AddressCheckResult: (v) => api.parse("AddressCheckResult", v),
 		Domain: (v) => api.parse("Domain", v),
-		Record: (v) => api.parse("Record", v),
-		DKIMResult: (v) => api.parse("DKIMResult", v),
-		Sig: (v) => api.parse("Sig", v),
-		Identity: (v) => api.parse("Identity", v),
+		Finding: (v) => api.parse("Finding", v),
+		IPDomain: (v) => api.parse("IPDomain", v),
+		AddressProbe: (v) => api.parse("AddressProbe", v),
+		Proto: (v) => api.parse("Proto", v),
+		DomainBatchResult: (v) => api.parse("DomainBatchResult", v),
+		DomainSummary: (v) => api.parse("DomainSummary", v),
 		DomainResult: (v) => api.parse("DomainResult", v),
 		DomainSPF: (v) => api.parse("DomainSPF", v),
 		SPFRecord: (v) => api.parse("SPFRecord", v),
 		Directive: (v) => api.parse("Directive", v),
 		Modifier: (v) => api.parse("Modifier", v),
+		DNSBLIP: (v) => api.parse("DNSBLIP", v),
+		DNSBLResult: (v) => api.parse("DNSBLResult", v),
+		DKIMDiscoverResult: (v) => api.parse("DKIMDiscoverResult", v),
+		DKIMDiscovered: (v) => api.parse("DKIMDiscovered", v),
+		Record: (v) => api.parse("Record", v),
 		DomainDMARC: (v) => api.parse("DomainDMARC", v),
 		DMARCRecord: (v) => api.parse("DMARCRecord", v),
 		URI: (v) => api.parse("URI", v),
@@ -347,8 +403,8 @@ var api;
 		MX: (v) => api.parse("MX", v),
 		DomainMX: (v) => api.parse("DomainMX", v),
 		DomainMXHost: (v) => api.parse("DomainMXHost", v),
-		IPDomain: (v) => api.parse("IPDomain", v),
 		DomainIP: (v) => api.parse("DomainIP", v),
+		IPRevResult: (v) => api.parse("IPRevResult", v),
 		DomainDANE: (v) => api.parse("DomainDANE", v),
 		TLSARecord: (v) => api.parse("TLSARecord", v),
 		DomainDial: (v) => api.parse("DomainDial", v),
@@ -358,17 +414,59 @@ var api;
 		TLSRPTResultPolicy: (v) => api.parse("TLSRPTResultPolicy", v),
 		TLSRPTSummary: (v) => api.parse("TLSRPTSummary", v),
 		TLSRPTFailureDetails: (v) => api.parse("TLSRPTFailureDetails", v),
-		Proto: (v) => api.parse("Proto", v),
+		SMTPEHLO: (v) => api.parse("SMTPEHLO", v),
+		SMTPExtension: (v) => api.parse("SMTPExtension", v),
+		DomainMXIP: (v) => api.parse("DomainMXIP", v),
+		DomainParity: (v) => api.parse("DomainParity", v),
+		DomainGrade: (v) => api.parse("DomainGrade", v),
+		ClientConfigResult: (v) => api.parse("ClientConfigResult", v),
+		ClientConfigSRV: (v) => api.parse("ClientConfigSRV", v),
+		SRVRecord: (v) => api.parse("SRVRecord", v),
+		ClientConfigAutoconfig: (v) => api.parse("ClientConfigAutoconfig", v),
+		ClientConfigServer: (v) => api.parse("ClientConfigServer", v),
+		ClientConfigAutodiscover: (v) => api.parse("ClientConfigAutodiscover", v),
+		ClientConfigEndpoint: (v) => api.parse("ClientConfigEndpoint", v),
+		TestDeliveryResult: (v) => api.parse("TestDeliveryResult", v),
+		DKIMBreakage: (v) => api.parse("DKIMBreakage", v),
+		DKIMResult: (v) => api.parse("DKIMResult", v),
+		Sig: (v) => api.parse("Sig", v),
+		Identity: (v) => api.parse("Identity", v),
+		DKIMHypothesis: (v) => api.parse("DKIMHypothesis", v),
+		DKIMDebug: (v) => api.parse("DKIMDebug", v),
+		DKIMDebugHeader: (v) => api.parse("DKIMDebugHeader", v),
+		DKIMDebugLine: (v) => api.parse("DKIMDebugLine", v),
+		DSN: (v) => api.parse("DSN", v),
+		DSNRecipient: (v) => api.parse("DSNRecipient", v),
+		MIMEHeader: (v) => api.parse("MIMEHeader", v),
+		Expectations: (v) => api.parse("Expectations", v),
+		ExpectedDKIM: (v) => api.parse("ExpectedDKIM", v),
+		ExpectationsResult: (v) => api.parse("ExpectationsResult", v),
+		ExpectationCheck: (v) => api.parse("ExpectationCheck", v),
+		SPFReceived: (v) => api.parse("SPFReceived", v),
+		MIMEInspection: (v) => api.parse("MIMEInspection", v),
+		MIMEPart: (v) => api.parse("MIMEPart", v),
+		Envelope: (v) => api.parse("Envelope", v),
+		Address: (v) => api.parse("Address", v),
+		ReflectorResult: (v) => api.parse("ReflectorResult", v),
+		ReflectorMessage: (v) => api.parse("ReflectorMessage", v),
+		ReflectorSPF: (v) => api.parse("ReflectorSPF", v),
+		ReflectorDMARC: (v) => api.parse("ReflectorDMARC", v),
+		SMTPAuthResult: (v) => api.parse("SMTPAuthResult", v),
+		SMTPReply: (v) => api.parse("SMTPReply", v),
+		SMTPReplyTag: (v) => api.parse("SMTPReplyTag", v),
+		TLSScanResult: (v) => api.parse("TLSScanResult", v),
+		TLSScanVersion: (v) => api.parse("TLSScanVersion", v),
+		TLSScanCipherSuite: (v) => api.parse("TLSScanCipherSuite", v),
 		TLSAUsage: (v) => api.parse("TLSAUsage", v),
 		TLSASelector: (v) => api.parse("TLSASelector", v),
 		TLSAMatchType: (v) => api.parse("TLSAMatchType", v),
-		DKIMStatus: (v) => api.parse("DKIMStatus", v),
-		Localpart: (v) => api.parse("Localpart", v),
+		IP: (v) => api.parse("IP", v),
 		DMARCPolicy: (v) => api.parse("DMARCPolicy", v),
 		Align: (v) => api.parse("Align", v),
 		RUA: (v) => api.parse("RUA", v),
 		Mode: (v) => api.parse("Mode", v),
-		IP: (v) => api.parse("IP", v),
+		DKIMStatus: (v) => api.parse("DKIMStatus", v),
+		Localpart: (v) => api.parse("Localpart", v),
 	};
 	let defaultOptions = { slicesNullable: true, mapsNullable: true, nullableOptional: true };
 	class Client {
@@ -389,6 +487,83 @@ var api;
 			c.options = { ...this.options, ...options };
 			return c;
 		}
+		async AddressCheck(address, probe) {
+			const fn = "AddressCheck";
+			const paramTypes = [["string"], ["bool"]];
+			const returnTypes = [["AddressCheckResult"]];
+			const params = [address, probe];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DomainCheckBatch(domains) {
+			const fn = "DomainCheckBatch";
+			const paramTypes = [["[]", "string"]];
+			const returnTypes = [["DomainBatchResult"]];
+			const params = [domains];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async ClientConfigCheck(domain) {
+			const fn = "ClientConfigCheck";
+			const paramTypes = [["string"]];
+			const returnTypes = [["ClientConfigResult"]];
+			const params = [domain];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async TestDelivery(address, dkimSign, requireTLS, eightbit) {
+			const fn = "TestDelivery";
+			const paramTypes = [["string"], ["bool"], ["bool"], ["bool"]];
+			const returnTypes = [["TestDeliveryResult"]];
+			const params = [address, dkimSign, requireTLS, eightbit];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DKIMBreakage(message) {
+			const fn = "DKIMBreakage";
+			const paramTypes = [["nullable", "string"]];
+			const returnTypes = [["[]", "DKIMBreakage"]];
+			const params = [message];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DKIMDebug(message) {
+			const fn = "DKIMDebug";
+			const paramTypes = [["nullable", "string"]];
+			const returnTypes = [["[]", "DKIMDebug"]];
+			const params = [message];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DKIMDiscover(domain) {
+			const fn = "DKIMDiscover";
+			const paramTypes = [["string"]];
+			const returnTypes = [["DKIMDiscoverResult"]];
+			const params = [domain];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DNSBLCheck(ipstr) {
+			const fn = "DNSBLCheck";
+			const paramTypes = [["string"]];
+			const returnTypes = [["DNSBLIP"]];
+			const params = [ipstr];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DSNParse(message) {
+			const fn = "DSNParse";
+			const paramTypes = [["nullable", "string"]];
+			const returnTypes = [["DSN"]];
+			const params = [message];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async DomainExpect(exp) {
+			const fn = "DomainExpect";
+			const paramTypes = [["Expectations"]];
+			const returnTypes = [["ExpectationsResult"]];
+			const params = [exp];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async IPRevCheck(ipstr) {
+			const fn = "IPRevCheck";
+			const paramTypes = [["string"]];
+			const returnTypes = [["IPRevResult"]];
+			const params = [ipstr];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
 		async SPFCheck(domain, ipstr) {
 			const fn = "SPFCheck";
 			const paramTypes = [["string"], ["string"]];
@@ -396,16 +571,17 @@ var api;
 			const params = [domain, ipstr];
 			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
 		}
+		// DKIMLookup looks up a DKIM record, and audits the key and fields.
 		async DKIMLookup(selector, domain) {
 			const fn = "DKIMLookup";
 			const paramTypes = [["string"], ["string"]];
-			const returnTypes = [["DKIMStatus"], ["nullable", "Record"], ["string"], ["bool"]];
+			const returnTypes = [["DKIMStatus"], ["nullable", "Record"], ["string"], ["bool"], ["[]", "Finding"]];
 			const params = [selector, domain];
 			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
 		}
 		async DKIMVerify(message) {
 			const fn = "DKIMVerify";
-			const paramTypes = [["string"]];
+			const paramTypes = [["nullable", "string"]];
 			const returnTypes = [["[]", "DKIMResult"]];
 			const params = [message];
 			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
@@ -417,6 +593,63 @@ var api;
 			const params = [domain];
 			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
 		}
+		// MIMEInspect parses a message, and shows the MIME structure and problems.
+		async MIMEInspect(message) {
+			const fn = "MIMEInspect";
+			const paramTypes = [["nullable", "string"]];
+			const returnTypes = [["MIMEInspection"]];
+			const params = [message];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		// ReflectorStart returns a new token and the address to send a message to.
+		async ReflectorStart() {
+			const fn = "ReflectorStart";
+			const paramTypes = [];
+			const returnTypes = [["string"], ["string"]];
+			const params = [];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		// ReflectorResults returns the messages received for the token.
+		async ReflectorResults(token) {
+			const fn = "ReflectorResults";
+			const paramTypes = [["string"]];
+			const returnTypes = [["ReflectorResult"]];
+			const params = [token];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		// DomainReport renders a domain check result as a self-contained document, for
+		// attaching to tickets. The result is passed in, so the domain doesn't have to be
+		// checked again.
+		async DomainReport(dr, format) {
+			const fn = "DomainReport";
+			const paramTypes = [["DomainResult"], ["string"]];
+			const returnTypes = [["string"]];
+			const params = [dr, format];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async SMTPAuthTest(host, port, security, mechanism, username, password) {
+			const fn = "SMTPAuthTest";
+			const paramTypes = [["string"], ["int32"], ["string"], ["string"], ["string"], ["string"]];
+			const returnTypes = [["SMTPAuthResult"]];
+			const params = [host, port, security, mechanism, username, password];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		// SMTPReplyExplain breaks an SMTP reply, e.g. "550 5.7.26 ..." or an error from an
+		// SMTP client, into its parts and explains them.
+		async SMTPReplyExplain(text) {
+			const fn = "SMTPReplyExplain";
+			const paramTypes = [["string"]];
+			const returnTypes = [["SMTPReply"]];
+			const params = [text];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
+		async TLSScan(domain) {
+			const fn = "TLSScan";
+			const paramTypes = [["string"]];
+			const returnTypes = [["[]", "TLSScanResult"]];
+			const params = [domain];
+			return await _sherpaCall(this.baseURL, this.authState, { ...this.options }, paramTypes, returnTypes, fn, params);
+		}
 	}
 	api.Client = Client;
 	api.defaultBaseURL = (function () {
@@ -845,11 +1078,31 @@ const formatDANERecord = (r) => {
 	return [s, dom.span(attr.title(title), s)];
 };
 const duration = (ms) => [' ', dom.span(dom._class('duration'), '' + ms + 'ms')];
+const dnsblIPs = (l) => (l || []).length === 0 ? dom.div('-') : (l || []).map(ipr => dom.div(ipr.IP, ' ', (ipr.Results || []).map(r => [
+	r.Status === 'fail' ? tag(red, 'listed: ' + domainString(r.Zone), attr.title([...(r.Codes || []), r.Reason].filter(s => s).join('\n'))) :
+		r.Status === 'pass' ? tag(green, domainString(r.Zone)) :
+			tag(orange, domainString(r.Zone), attr.title(r.Error || r.Status)),
+	' ',
+])));
+const ehloExtensions = (e) => (e.Extensions || []).map(x => dom.div(style({ paddingLeft: '1em' }), x.Explanation ? attr.title(x.Explanation) : [], verbatim(x.Keyword + (x.Params ? ' ' + x.Params : ''))));
+const iprevResult = (r) => dom.div(r.IP, ' ', authTag(r.Status), ' ', r.Name ? verbatim(r.Name) : [], !r.Name && (r.Names || []).length > 0 ? ['PTR names not resolving to IP: ', verbatim((r.Names || []).join(', '))] : [], r.EHLO ? [' ', r.EHLOMatch ? tag(green, 'matches ehlo') : tag(orange, 'ehlo mismatch', attr.title('EHLO hostname: ' + r.EHLO))] : [], errorTag(r.Error));
+const findingsList = (l) => (l || []).map(f => group(dom.div(tag(f.Severity === 'error' ? red : (f.Severity === 'warning' ? orange : grey), f.Severity), ' ', dom.span(style({ fontWeight: 'bold' }), f.Title), f.Points ? ' (-' + f.Points + ')' : []), dom.div(f.Explanation), f.Remediation ? dom.div('Remediation: ', f.Remediation) : []));
+const downloadReport = async (dr, format, ext, mimeType) => {
+	try {
+		const s = await client.DomainReport(dr, format);
+		const a = dom.a(attr.href(URL.createObjectURL(new Blob([s], { type: mimeType }))), attr.download('report-' + dr.Domain.ASCII + '.' + ext));
+		a.click();
+		setTimeout(() => URL.revokeObjectURL(a.href), 1000);
+	}
+	catch (err) {
+		window.alert('Error: ' + errmsg(err));
+	}
+};
 const domainCheckResult = (dr) => {
 	const mtastsExplain = 'MTA-STS protects MX records of domains without DNSSEC, and requires PKIX/WebPKI verification of MX host TLS certificates (the historical default "opportunistic TLS" does not verify at all). MTA-STS depends on PKIX/WebPKI	 (well-known Certificate Authorities) and trust-on-first-use ("TOFU"). DANE has similar goals and can coexist with MTA-STS.';
 	const tlsrptExplain = 'TLSRPT is a mechanism to request reports about SMTP TLS connections, both success and failures, such as invalid certificates.';
 	const daneExplain = 'DANE protects delivery to MX hosts by requiring verified TLS along with DNSSEC-protected MX records. TLS verification is most often using DANE-EE, which is based on only the public key (SPKI) of a certificate, without verification through PKIX/WebPKI (well-known Certificate Authorities).';
-	return dom.div(dom.h3('Results for receiving from ', domainString(dr.Domain)), dom.div(dom._class('row'), dom.div(dom._class('result'), dom.h4('SPF', duration(dr.SPF.DurationMS)), (() => {
+	return dom.div(dom.h3('Results for receiving from ', domainString(dr.Domain)), dom.div('Download report: ', dom.clickbutton('HTML', async function click() { await downloadReport(dr, 'html', 'html', 'text/html'); }), ' ', dom.clickbutton('Markdown', async function click() { await downloadReport(dr, 'markdown', 'md', 'text/markdown'); }), ' ', dom.clickbutton('JSON', async function click() { await downloadReport(dr, 'json', 'json', 'application/json'); })), dom.br(), dom.div(dom._class('row'), dom.div(dom._class('result'), dom.h4('Grade', attr.title('Score calculated from the findings, each finding deducts points. A is 90 and higher, F is below 60.')), dom.div(dom.span(style({ fontSize: '2em', fontWeight: 'bold', color: dr.Grade.Grade === 'A' || dr.Grade.Grade === 'B' ? green : (dr.Grade.Grade === 'F' ? red : orange) }), dr.Grade.Grade), ' ', '' + dr.Grade.Score + '/100')), dom.div(dom._class('result'), style({ maxWidth: '50em' }), dom.h4('Findings'), (dr.Grade.Findings || []).length === 0 ? dom.div('No findings.') : [], findingsList(dr.Grade.Findings))), dom.div(dom._class('row'), dom.div(dom._class('result'), dom.h4('SPF', duration(dr.SPF.DurationMS)), (() => {
 		const status = dr.SPF.Status;
 		if (status === 'none' && !dr.SPF.Error) {
 			return group(dom.div('Domain has an SPF record.', attr.title('An SPF record specifies a policy about which IP addresses are (not) allowed to send email from a domain.')));
@@ -868,7 +1121,7 @@ const domainCheckResult = (dr) => {
 				return group(tag(red, dr.SPF.Status), errorTag(dr.SPF.Error));
 			}
 		}
-	})(), group(title('DNS TXT'), dom.div(dnsTXT(dr.SPF.TXT)), dnssecTag(dr.SPF.Authentic))), dom.div(dom._class('result'), dom.h4('DKIM'), group(dom.div('Not checked.', attr.title('Not checked because DKIM records (selectors) cannot typically be enumerated. A domain can publish DKIM public keys in DNS, under a selector, and add DKIM-Signature headers to outgoing messages for verification by a receiving mail server.')))), dom.div(dom._class('result'), dom.h4('DMARC', duration(dr.DMARC.DurationMS)), (() => {
+	})(), group(title('DNS TXT'), dom.div(dnsTXT(dr.SPF.TXT)), dnssecTag(dr.SPF.Authentic)), !dr.SPF.Record ? [] : group(title('DNS lookups', attr.title('Number of DNS lookups needed to evaluate the SPF record, for include, a, mx, ptr, exists and redirect, including nested records. At most 10 are allowed, more results in a permerror.')), dom.div(tag(dr.SPF.Lookups > 10 ? red : green, '' + dr.SPF.Lookups + '/10')), errorTag(dr.SPF.LookupsErr)), (dr.SPF.DNSBL || []).length === 0 ? [] : group(title('DNSBL', attr.title('Listing in DNS blocklists of the single IPs in the SPF record.')), dnsblIPs(dr.SPF.DNSBL))), dom.div(dom._class('result'), dom.h4('DKIM', duration(dr.DKIM.DurationMS)), group(dom.div('Probed ' + dr.DKIM.Probed + ' common selectors.', attr.title('DKIM records (selectors) cannot be enumerated, so only commonly used selectors are looked up. A domain can publish DKIM public keys in DNS, under a selector, and add DKIM-Signature headers to outgoing messages for verification by a receiving mail server.')), (dr.DKIM.Selectors || []).length === 0 ? dom.div(tag(grey, 'none found')) : [], dr.DKIM.Wildcard ? dom.div(tag(orange, 'wildcard', attr.title('A random selector has a DKIM record too, found selectors may not be in use.'))) : []), (dr.DKIM.Selectors || []).map(d => group(title('Selector ', verbatim(d.Selector)), d.Error ? errorTag(d.Error) : dom.div(d.Revoked ? tag(red, 'revoked') : [tag(d.KeyType === 'rsa' && d.KeyBits < 1024 ? red : green, d.KeyType + ' ' + d.KeyBits + ' bits'), ' '], (d.Flags || []).includes('y') ? [tag(orange, 'testing', attr.title('Flag t=y, the domain is testing DKIM, verifiers must not treat messages differently based on the signature.')), ' '] : [], (d.Flags || []).includes('s') ? tag(grey, 'strict', attr.title('Flag t=s, the i= domain in signatures must be the same as d=.')) : []), dnssecTag(d.Authentic), findingsList((d.Audit || []).filter(f => f.Severity !== 'info' && f.Check !== 'dkim.syntax' && f.Check !== 'dkim.revoked'))))), dom.div(dom._class('result'), dom.h4('DMARC', duration(dr.DMARC.DurationMS)), (() => {
 		const status = dr.DMARC.Status;
 		const explain = 'A DMARC record specifies a policy about messages with From header referencing the domain. The policy can ask receiving mail servers to reject or quarantine a message that does not have an aligned DKIM and/or SPF pass (both are mechanisms to associate a message/transaction with a domain).';
 		if (status === 'none' && !dr.DMARC.Error && dr.DMARC.Record) {
@@ -911,9 +1164,9 @@ const domainCheckResult = (dr) => {
 		return dom.div(dom._class('result'), dom.h4('MX host: ' + ipdomainString(mx.Host), duration(mx.DurationMS)), group(title('MTA-STS'), errorTag(mx.MTASTSError), dom.div(!mx.MTASTSError && dr.MTASTS.Policy && dr.MTASTS.Policy.Mode === api.Mode.ModeEnforce ? tag(green, 'verified') : []), dom.div(!mx.MTASTSError && dr.MTASTS.Policy && dr.MTASTS.Policy.Mode === api.Mode.ModeTesting ? tag(red, 'unenforced') : []), dom.div(!mx.MTASTSError && (!dr.MTASTS.Policy || dr.MTASTS.Policy.Mode === api.Mode.ModeNone) ? tag(red, 'not implemented') : [])), group(title('IPs', duration(mx.IP.DurationMS)), dom.div(errorTag(mx.IP.Error), mx.IP.ExpandedHost.ASCII !== mx.Host.Domain.ASCII && mx.Host.Domain ? [
 			dom.div('Expanded host: ', verbatim(domainString(mx.IP.ExpandedHost))),
 			dnssecTag(mx.IP.ExpandedAuthentic)
-		] : [], (mx.IP.IPs || []).map(ip => dom.div(ip)), dnssecTag(mx.IP.Authentic))), group(title('DANE', duration(mx.DANE.DurationMS)), dom.div(errorTag(mx.DANE.Error), mx.DANE.Required ? tag(green, 'implemented') : tag(red, 'not implemented'), mx.DANE.Required ?
+		] : [], (mx.IP.IPs || []).map(ip => dom.div(ip)), dnssecTag(mx.IP.Authentic))), group(title('DNSBL', attr.title('Listing in DNS blocklists. Messages from listed IPs are often rejected.')), dnsblIPs(mx.DNSBL)), group(title('Reverse DNS', attr.title('PTR records of the IPs, forward-confirmed, and compared with the hostname from EHLO. Messages from IPs without matching PTR records are often penalized.')), (mx.IPRev || []).length === 0 ? dom.div('-') : (mx.IPRev || []).map(r => iprevResult(r))), group(title('DANE', duration(mx.DANE.DurationMS)), dom.div(errorTag(mx.DANE.Error), mx.DANE.Required ? tag(green, 'implemented') : tag(red, 'not implemented'), mx.DANE.Required ?
 			dom.div('Delivery to this MX host is protected with verified TLS.', attr.title(daneExplain)) :
-			dom.div('Delivery to this MX host is not protected with DANE-verified TLS.', attr.title(daneExplain)), mx.DANE.Required ? [
+			dom.div('Delivery to this MX host is not protected with DANE-verified TLS.', attr.title(daneExplain)), mx.DANE.WithoutDNSSEC ? dom.div(tag(red, 'TLSA records without DNSSEC'), ' TLSA records exist, but the MX records or IPs of the MX host are not DNSSEC-protected, so senders ignore them.') : [], mx.DANE.Required ? [
 			mx.DANE.TLSABaseDomain.ASCII !== mx.Host.Domain.ASCII ? [
 				title('TLSA base domain:'),
 				dom.div(domainString(mx.DANE.TLSABaseDomain)),
@@ -924,7 +1177,10 @@ const domainCheckResult = (dr) => {
 				const [vrs, _] = mx.DANE.VerifiedRecord ? formatDANERecord(mx.DANE.VerifiedRecord) : ['', []];
 				return dom.div(dom._class('mono'), tag(s == vrs ? green : grey, e));
 			}),
-		] : [])), group(title('Dial', duration(mx.Dial.DurationMS)), errorTag(mx.Dial.Error), dom.div('IP: ', mx.Dial.IP || '-')), group(title('SMTP', duration(mx.SMTP.DurationMS)), errorTag(mx.SMTP.Error), dom.div('Extensions: ', mx.Dial.IP && !mx.Dial.Error && !mx.SMTP.Error ? dom.div(tag(mx.SMTP.Supports8bitMIME ? green : red, '8BITMIME', attr.title('For sending messages that are not ASCII-only.')), tag(mx.SMTP.SupportsSMTPUTF8 ? green : red, 'SMTPUTF8', attr.title('For sending messages with UTF-8 in message headers, for internationalized messages.')), tag(mx.SMTP.SupportsSTARTTLS ? green : red, 'STARTTLS', attr.title('For adding TLS to a plain text SMTP session. The default is opportunistic TLS, without verification. With MTA-STS enabled, the TLS certificate must be verified with PKIX/WebPKI (common CAs). With DANE, the TLS certificate must be verified with TLSA records, typically based on public key (SPKI) of the certificate only (DANE-EE).')), tag(mx.SMTP.SupportsRequireTLS ? green : red, 'REQUIRETLS', attr.title('For sending messages where verified TLS is required along the entire delivery path, from submission to final delivery. Each SMTP server along the way must implement this extension. Also has a message header that indicates that TLS (verification) failure must be ignored.'))) : '-')), group(title('TLS'), dom.div('Version: ', mx.SMTP.TLSConnectionState ? verbatim(mx.SMTP.TLSConnectionState.Version) : '-'), dom.div('Ciphersuite: ', mx.SMTP.TLSConnectionState ? verbatim(mx.SMTP.TLSConnectionState.CipherSuite) : '-'), dom.div('PKIX verification: ', mx.SMTP.RecipientDomainResult ? (mx.SMTP.RecipientDomainResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-'), dom.div('DANE verification: ', mx.DANE.Required && mx.SMTP.HostResult ? (mx.SMTP.HostResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-')), !mx.SMTP.Trace ? [] : group(title('Transcript'), (mx.SMTP.Trace || []).map((l, index) => {
+		] : [])), group(title('Dial', duration(mx.Dial.DurationMS)), errorTag(mx.Dial.Error), dom.div('IP: ', mx.Dial.IP || '-')), group(title('SMTP', duration(mx.SMTP.DurationMS)), errorTag(mx.SMTP.Error), dom.div('Extensions: ', mx.Dial.IP && !mx.Dial.Error && !mx.SMTP.Error ? dom.div(tag(mx.SMTP.Supports8bitMIME ? green : red, '8BITMIME', attr.title('For sending messages that are not ASCII-only.')), tag(mx.SMTP.SupportsSMTPUTF8 ? green : red, 'SMTPUTF8', attr.title('For sending messages with UTF-8 in message headers, for internationalized messages.')), tag(mx.SMTP.SupportsSTARTTLS ? green : red, 'STARTTLS', attr.title('For adding TLS to a plain text SMTP session. The default is opportunistic TLS, without verification. With MTA-STS enabled, the TLS certificate must be verified with PKIX/WebPKI (common CAs). With DANE, the TLS certificate must be verified with TLSA records, typically based on public key (SPKI) of the certificate only (DANE-EE).')), tag(mx.SMTP.SupportsRequireTLS ? green : red, 'REQUIRETLS', attr.title('For sending messages where verified TLS is required along the entire delivery path, from submission to final delivery. Each SMTP server along the way must implement this extension. Also has a message header that indicates that TLS (verification) failure must be ignored.'))) : '-')), !mx.SMTP.EHLO ? [] : group(title('EHLO', attr.title('Parsed EHLO responses. Hover over an extension for an explanation.')), (mx.SMTP.Warnings || []).map(w => dom.div(tag(orange, 'warning'), ' ', w)), dom.div('Greeting hostname: ', mx.SMTP.GreetingHostname ? verbatim(mx.SMTP.GreetingHostname) : '-'), dom.div('EHLO hostname: ', verbatim(mx.SMTP.EHLO.Hostname || '-')), dom.div('Before STARTTLS:'), ehloExtensions(mx.SMTP.EHLO), mx.SMTP.EHLOTLS ? [
+			dom.div('After STARTTLS:'),
+			ehloExtensions(mx.SMTP.EHLOTLS),
+		] : []), group(title('TLS'), dom.div('Version: ', mx.SMTP.TLSConnectionState ? verbatim(mx.SMTP.TLSConnectionState.Version) : '-'), dom.div('Ciphersuite: ', mx.SMTP.TLSConnectionState ? verbatim(mx.SMTP.TLSConnectionState.CipherSuite) : '-'), dom.div('PKIX verification: ', mx.SMTP.RecipientDomainResult ? (mx.SMTP.RecipientDomainResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-'), dom.div('DANE verification: ', mx.DANE.Required && mx.SMTP.HostResult ? (mx.SMTP.HostResult.Summary.TotalSuccessfulSessionCount === 1 ? tag(green, 'yes') : tag(red, 'no')) : '-')), !mx.Parity ? [] : group(title('IPv4/IPv6 parity', attr.title('Compares SMTP sessions over IPv4 and IPv6: greeting, EHLO extensions, STARTTLS, TLS certificate and DANE verification.')), dom.div('Compared ', verbatim(mx.Parity.IPv4.IP || '-'), ' and ', verbatim(mx.Parity.IPv6.IP || '-')), (mx.Parity.Differences || []).length === 0 ? dom.div(tag(green, 'no differences')) : (mx.Parity.Differences || []).map(d => dom.div(tag(red, 'difference'), ' ', d))), !mx.SMTP.Trace ? [] : group(title('Transcript'), (mx.SMTP.Trace || []).map((l, index) => {
 			const e = dom.div(dom._class('mono'), style({ paddingLeft: '.5em', whiteSpace: 'pre-wrap', color: l.ClientWrite ? '#e48b00' : blue }), starttls ? style({ borderLeft: '2px solid ' + green }) : [], l.Text);
 			if (!starttls && !l.ClientWrite && l.Text.startsWith('2') && index > 0 && (mx.SMTP.Trace || [])[index - 1].ClientWrite && (mx.SMTP.Trace || [])[index - 1].Text === 'STARTTLS\r\n') {
 				starttls = true;
@@ -933,6 +1189,117 @@ const domainCheckResult = (dr) => {
 		})));
 	})), dom.br(), dom.div(dom.h4('Raw results as JSON'), detailsLink(dom.div(dom._class('result'), formatJSON(dr)))));
 };
+const clientConfigServers = (l) => {
+	if (!l || l.length === 0) {
+		return dom.div('-');
+	}
+	return dom.table(dom.tr(dom.th('Protocol'), dom.th('Host'), dom.th('Port'), dom.th('Security'), dom.th('Username'), dom.th('Authentication')), l.map(s => dom.tr(dom.td(s.Protocol), dom.td(s.Host), dom.td('' + s.Port), dom.td(s.Security), dom.td(s.Username), dom.td(s.Authentication))));
+};
+const clientConfigResult = (r) => {
+	return dom.div(dom.h3('Client configuration for ', domainString(r.Domain), duration(r.DurationMS)), dom.div(dom._class('row'), dom.div(dom._class('result'), dom.h4('Mismatches and problems'), (r.Mismatches || []).length === 0 ? dom.div(tag(green, 'ok'), ' No mismatches found.') : (r.Mismatches || []).map(s => dom.div(tag(red, 'problem'), ' ', s))), dom.div(dom._class('result'), dom.h4('SRV records', attr.title('SRV records for finding IMAP, POP3 and submission servers, as specified in RFC 6186 and RFC 8314.')), dom.table(dom.tr(dom.th('Service'), dom.th('Target'), dom.th('Port'), dom.th('Priority'), dom.th('Weight'), dom.th('DNSSEC')), (r.SRV || []).map(s => [
+		s.Error ? dom.tr(dom.td(s.Service), dom.td(attr.colspan('5'), errorTag(s.Error))) : [],
+		!s.Error && (s.Records || []).length === 0 ? dom.tr(dom.td(s.Service), dom.td(attr.colspan('5'), '-')) : [],
+		(s.Records || []).map(rec => dom.tr(dom.td(s.Service), dom.td(rec.Target || dom.span('.', attr.title('Service is explicitly not available.'))), dom.td('' + rec.Port), dom.td('' + rec.Priority), dom.td('' + rec.Weight), dom.td(s.Authentic ? 'yes' : 'no'))),
+	]))), dom.div(dom._class('result'), dom.h4('Autoconfig', duration(r.Autoconfig.DurationMS), attr.title('Thunderbird-style autoconfig, also used by other email clients.')), dom.div(verbatim(r.Autoconfig.URL)), errorTag(r.Autoconfig.Error), clientConfigServers(r.Autoconfig.Servers), r.Autoconfig.XML ? group(title('Raw XML'), detailsLink(verbatim(r.Autoconfig.XML))) : []), dom.div(dom._class('result'), dom.h4('Autodiscover', duration(r.Autodiscover.DurationMS), attr.title('Microsoft-style autodiscover, mostly used by Outlook.')), dom.div(verbatim(r.Autodiscover.URL)), errorTag(r.Autodiscover.Error), clientConfigServers(r.Autodiscover.Servers), r.Autodiscover.XML ? group(title('Raw XML'), detailsLink(verbatim(r.Autodiscover.XML))) : [])), dom.div(dom._class('row'), dom.div(dom._class('result'), dom.h4('Endpoints'), dom.table(dom.tr(dom.th('Protocol'), dom.th('Host'), dom.th('Port'), dom.th('Security'), dom.th('Sources'), dom.th('IP'), dom.th('TLS'), dom.th('Greeting'), dom.th('Result')), (r.Endpoints || []).map(e => dom.tr(dom.td(e.Protocol), dom.td(e.Host), dom.td('' + e.Port), dom.td(e.Security), dom.td((e.Sources || []).join(', ')), dom.td(e.IP || '-'), dom.td(e.TLSConnectionState ? e.TLSConnectionState.Version + ', ' + e.TLSConnectionState.CipherSuite : '-'), dom.td(verbatim(e.Greeting)), dom.td(e.Error ? errorTag(e.Error) : tag(green, 'ok'), duration(e.DurationMS))))))), dom.br(), dom.div(dom.h4('Raw results as JSON'), detailsLink(dom.div(dom._class('result'), formatJSON(r)))));
+};
+const authTag = (status) => tag(status === 'pass' ? green : (status === '' || status === 'none' || status === 'neutral' ? grey : red), status || 'none');
+const reflectorResult = (r, refresh) => {
+	return dom.div(dom.h3('Reflector'), dom.div('Send a message to ', verbatim(r.Address), '. Messages are shown until ', r.Expires.toLocaleString(), '. ', dom.clickbutton('Refresh', attr.title('Check for newly received messages.'), async function click() { await refresh(); })), dom.br(), (r.Messages || []).length === 0 ? dom.div('No messages received yet.') : [], dom.div(dom._class('row'), (r.Messages || []).map(m => dom.div(dom._class('result'), dom.h4('Message received at ', m.Received.toLocaleString(), duration(m.DurationMS)), errorTag(m.Error), group(title('Connection'), dom.div('Remote IP: ', verbatim(m.RemoteIP)), dom.div(m.EHLO ? 'EHLO: ' : 'HELO: ', verbatim(m.Hello)), dom.div('Reverse DNS: ', iprevResult(m.IPRev)), dom.div('TLS: ', m.TLSConnectionState ? m.TLSConnectionState.Version + ', ' + m.TLSConnectionState.CipherSuite : tag(red, 'none'))), group(title('Message'), dom.div('MAIL FROM: ', m.MailFrom ? verbatim(m.MailFrom) : '<> (null sender)'), dom.div('From: ', m.From ? verbatim(m.From) : '-'), dom.div('Subject: ', m.Subject || '-'), dom.div('Size: ', '' + m.Size, ' bytes')), group(title('SPF'), dom.div(authTag(m.SPF.Status), m.SPF.Identity ? [' for ', m.SPF.Identity, ' domain ', domainString(m.SPF.Domain)] : [], m.SPF.Mechanism ? [', mechanism ', verbatim(m.SPF.Mechanism)] : []), m.SPF.Explanation ? dom.div('Explanation: ', m.SPF.Explanation) : [], errorTag(m.SPF.Error)), group(title('DKIM'), (m.DKIM || []).length === 0 ? dom.div(authTag('none'), ' No DKIM signatures.') : [], (m.DKIM || []).map(d => dom.div(authTag(d.Status), d.Sig ? [' ', verbatim(d.Sig.Domain.ASCII), ', selector ', verbatim(d.Sig.Selector.ASCII)] : [], errorTag(d.Error)))), group(title('DMARC'), dom.div(authTag(m.DMARC.Status), m.DMARC.Domain.ASCII ? [' for ', domainString(m.DMARC.Domain)] : [], m.DMARC.Record ? [', policy ', m.DMARC.Record.Policy] : []), dom.div('Aligned SPF pass: ', m.DMARC.AlignedSPFPass ? tag(green, 'yes') : tag(red, 'no'), ', aligned DKIM pass: ', m.DMARC.AlignedDKIMPass ? tag(green, 'yes') : tag(red, 'no')), m.DMARC.Reject ? dom.div(tag(red, 'reject'), ' Message would be rejected by the DMARC policy.') : [], errorTag(m.DMARC.Error))))), dom.br(), dom.div(dom.h4('Raw results as JSON'), detailsLink(dom.div(dom._class('result'), formatJSON(r)))));
+};
+const domainBatchResult = (br) => {
+	let details;
+	const yesno = (v) => v ? tag(green, 'yes') : tag(red, 'no');
+	const gradeColor = (g) => g === 'A' || g === 'B' ? green : (g === 'F' ? red : orange);
+	return dom.div(dom.h3('Results', duration(br.DurationMS)), dom.div('Click a domain for the full results.'), dom.table(dom.tr(['Domain', 'Grade', 'SPF', 'DMARC', 'MTA-STS', 'TLSRPT', 'DNSSEC', 'DANE', 'STARTTLS', 'MX hosts', 'Findings'].map(s => dom.th(s))), (br.Summaries || []).map((s, i) => {
+		const dr = (br.Results || [])[i];
+		return dom.tr(dom.td(dr ? dom.clickbutton(s.Domain, function click() { dom._kids(details, domainCheckResult(dr)); }) : s.Domain), s.Error ? dom.td(attr.colspan('10'), errorTag(s.Error)) : [
+			dom.td(tag(gradeColor(s.Grade), s.Grade), ' ', '' + s.Score),
+			dom.td(tag(s.SPF === 'fail' || s.SPF === 'softfail' ? green : (s.SPF === 'neutral' || s.SPF === 'redirect' ? grey : red), s.SPF)),
+			dom.td(s.DMARC),
+			dom.td(s.MTASTS),
+			dom.td(yesno(s.TLSRPT)),
+			dom.td(yesno(s.DNSSEC)),
+			dom.td(yesno(s.DANE)),
+			dom.td(yesno(s.STARTTLS)),
+			dom.td('' + s.MXHosts),
+			dom.td('' + s.Findings),
+		]);
+	})), dom.br(), details = dom.div());
+};
+const expectationsResult = (r) => dom.div(dom.h3('Expectations for ', domainString(r.Domain), duration(r.DurationMS)), dom.div(r.Violations === 0 ? tag(green, 'ok') : tag(red, '' + r.Violations + ' violation' + (r.Violations === 1 ? '' : 's'))), dom.br(), dom.table(dom.tr(['Expectation', 'Expected', 'Actual', 'Result'].map(s => dom.th(s))), (r.Checks || []).map(c => dom.tr(dom.td(c.Name), dom.td(c.Expected), dom.td(c.Actual), dom.td(c.OK ? tag(green, 'ok') : tag(red, 'violation'))))), dom.br(), dom.h4('Domain check results'), detailsLink(dom.div(domainCheckResult(r.Result))));
+// binaryString returns a string with a character per byte, as used by btoa.
+const binaryString = (buf) => {
+	let s = '';
+	for (let i = 0; i < buf.length; i += 8192) {
+		s += String.fromCharCode(...buf.subarray(i, i + 8192));
+	}
+	return s;
+};
+// mboxMessages splits an mbox file into messages. A "From " line only starts a
+// message at the start or after an empty line. Lines escaped with ">" are
+// unescaped.
+const mboxMessages = (s) => {
+	const l = s.split(/(?:^|\n\r?\n)From [^\n]*\n/).slice(1);
+	return l.map((m, i) => (i < l.length - 1 ? m + '\n' : m.replace(/(\r?\n)\r?\n$/, '$1')).replace(/^>(>*From )/mg, '$1'));
+};
+const mboxSubject = (m) => (m.split(/\r?\n\r?\n/)[0].match(/^Subject:[ \t]*(.*)$/mi) || [])[1] || '(no subject)';
+// messageInput returns form fields for pasting a message or selecting a message or
+// mbox file, and a function returning the selected message, base64-encoded as
+// messages are sent as bytes, or an empty string if none.
+const messageInput = () => {
+	let text;
+	let file;
+	let mbox;
+	let messages = []; // From file, as binary strings.
+	const root = [
+		dom.div(dom.label('Message', dom.div(text = dom.textarea(attr.rows('10'))))),
+		dom.div(dom.label('Or a file, a message (.eml) or an mbox file', dom.div(file = dom.input(attr.type('file'), async function change() {
+			messages = [];
+			dom._kids(mbox);
+			mbox.style.display = 'none';
+			const f = (file.files || [])[0];
+			if (!f) {
+				return;
+			}
+			const s = binaryString(new Uint8Array(await f.arrayBuffer()));
+			if (s.startsWith('From ')) {
+				messages = mboxMessages(s);
+				dom._kids(mbox, messages.map((m, i) => dom.option(attr.value('' + i), '' + (i + 1) + ': ' + mboxSubject(m))));
+				mbox.style.display = '';
+			}
+			else {
+				messages = [s];
+			}
+		}), ' ', mbox = dom.select(style({ display: 'none', maxWidth: '30em' }))))),
+	];
+	const message = () => {
+		if (messages.length > 0) {
+			return btoa(messages[parseInt(mbox.value || '0')]);
+		}
+		else if (text.value) {
+			return btoa(binaryString(new TextEncoder().encode(text.value)));
+		}
+		return '';
+	};
+	return { root, message };
+};
+const headersTable = (l) => dom.table(dom.tr(['Header', 'Value', 'Decoded'].map(s => dom.th(s))), (l || []).map(h => dom.tr(dom.td(h.Name), dom.td(verbatim(h.Value)), dom.td(h.Decoded ? verbatim(h.Decoded) : '-'))));
+const mimePartResult = (p) => dom.div(style({ borderLeft: '2px solid ' + grey, paddingLeft: '.75em', margin: '.5em 0' }), dom.div(dom.span(style({ fontWeight: 'bold' }), p.Path ? 'Part ' + p.Path : 'Message'), ' ', verbatim(p.ContentType || 'text/plain'), p.ContentType ? [] : ' (default)', p.Charset ? [', charset ', verbatim(p.Charset)] : [], ', ', p.ContentTransferEncoding || '7bit', p.Disposition ? [', ', p.Disposition] : [], p.Filename ? [', filename ', verbatim(p.Filename)] : [], ', ', p.Size < 0 ? 'incomplete' : '' + p.Size + ' bytes' + (p.Size !== p.DecodedSize && (p.Parts || []).length === 0 ? ', ' + p.DecodedSize + ' decoded' : '') + ', ' + p.Lines + ' lines'), p.Envelope ? dom.div(p.Envelope.Subject ? dom.div('Subject: ', verbatim(p.Envelope.Subject)) : [], (p.Envelope.From || []).map(a => dom.div('From: ', verbatim((a.Name ? a.Name + ' ' : '') + '<' + a.User + '@' + a.Host + '>')))) : [], detailsLink(dom.div(headersTable(p.Headers), p.Envelope ? [dom.div('Envelope, as parsed from the headers:'), formatJSON(p.Envelope)] : [])), (p.Parts || []).map(pp => mimePartResult(pp)), p.Message ? mimePartResult(p.Message) : []);
+const dsnResult = (d) => dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Bounce'), group(title('Format'), d.Format === 'rfc3464' ? 'Delivery status notification (RFC 3464)' : 'No delivery-status part, recipients and SMTP replies were searched for in the text'), d.ReportingMTA ? group(title('Reporting MTA'), d.ReportingMTA) : [], d.ArrivalDate ? group(title('Arrival date'), d.ArrivalDate) : [], group(title('Recipients'), (d.Recipients || []).length === 0 ? dom.div('No recipients found.') : dom.table(dom.tr(['Recipient', 'Action', 'Status', 'Diagnostic', 'Remote MTA'].map(s => dom.th(s))), (d.Recipients || []).map(r => dom.tr(dom.td(r.FinalRecipient || '-', r.OriginalRecipient && r.OriginalRecipient !== r.FinalRecipient ? dom.div('Original: ', r.OriginalRecipient) : []), dom.td(r.Action ? tag(r.Action === 'failed' ? red : (r.Action === 'delayed' ? orange : green), r.Action) : '-'), dom.td(r.Status || '-', r.StatusExplanation ? dom.div(r.StatusExplanation) : []), dom.td(r.DiagnosticCode ? verbatim(r.DiagnosticCode) : '-', r.LastAttemptDate ? dom.div('Last attempt: ', r.LastAttemptDate) : [], r.WillRetryUntil ? dom.div('Will retry until: ', r.WillRetryUntil) : []), dom.td(r.RemoteMTA || '-'))))), group(title('Original message headers'), (d.OriginalHeaders || []).length === 0 ? dom.div('Not included.') : headersTable(d.OriginalHeaders)), d.Text ? group(title('Text'), dom.div(style({ maxHeight: '20em', overflow: 'auto' }), verbatim(d.Text))) : []);
+const smtpReplyResult = (r) => dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Reply'), group(title('Reply code'), dom.div(tag(r.Code >= 500 ? red : (r.Code >= 400 ? orange : green), '' + r.Code), ' ', r.CodeExplanation || 'Unknown reply code.')), r.EnhancedCode ? group(title('Enhanced status code', attr.title('Class, subject and detail, RFC 3463.')), dom.div(verbatim(r.EnhancedCode)), dom.table(dom.tr(dom.td('Class'), dom.td(r.Class || 'Unknown')), dom.tr(dom.td('Subject'), dom.td(r.Subject || 'Unknown')), dom.tr(dom.td('Detail'), dom.td(r.Detail || 'Unknown, not in the registry.')))) : [], r.ClientError ? group(title('Client error'), r.ClientError) : [], (r.Tags || []).length === 0 ? [] : group(title('Provider-specific codes'), dom.table(dom.tr(['Provider', 'Code', 'Explanation'].map(s => dom.th(s))), (r.Tags || []).map(t => dom.tr(dom.td(t.Provider), dom.td(verbatim(t.Tag)), dom.td(t.Explanation || '-'))))), r.Text ? group(title('Text'), verbatim(r.Text)) : [], r.Advice ? group(title('What to do'), r.Advice) : []);
+const addressCheckResult = (r) => dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Address'), group(title('Syntax'), r.Valid ? dom.div(tag(green, 'valid'), ' ', verbatim(r.Normalized), r.SMTPUTF8 ? [' ', tag(orange, 'requires SMTPUTF8')] : []) : dom.div(tag(red, 'invalid'), ' ', r.SyntaxError), r.Valid && r.Domain.Unicode ? dom.div('Domain: ', domainString(r.Domain)) : []), !r.Valid ? [] : group(title('Destinations', attr.title('MX targets, or the domain itself if it has no MX record (implicit MX).')), r.NullMX ? dom.div(tag(red, 'null MX'), ' the domain does not accept email') : (r.Deliverable ? dom.div(tag(green, 'deliverable'), r.HaveMX ? [] : [' ', tag(orange, 'no MX record')]) : dom.div(tag(red, 'not deliverable'))), errorTag(r.NullMX ? '' : r.DestinationsError), r.ExpandedDomain.ASCII && r.ExpandedDomain.ASCII !== r.Domain.ASCII ? dom.div('After following CNAMEs: ', domainName(r.ExpandedDomain)) : [], (r.Hosts || []).map(h => dom.div(verbatim(h.IP || domainName(h.Domain))))), !r.Probe ? [] : group(title('Probe', attr.title('MAIL FROM and RCPT TO at the first MX host that responds, without delivering a message. A random address at the same domain is tried to detect servers that accept all addresses (catch-all).')), dom.div(tag(r.Probe.Result === 'accept' ? green : (r.Probe.Result === 'reject' ? red : orange), r.Probe.Result), ' at ', domainName(r.Probe.Host.Domain), r.Probe.IP ? ' (' + r.Probe.IP + ')' : ''), errorTag(r.Probe.Error), r.Probe.Response ? dom.div('Response: ', verbatim(r.Probe.Response)) : [], r.Probe.Explanation ? dom.div(r.Probe.Explanation) : [], r.Probe.RandomAddress ? dom.div('Response for random address ', verbatim(r.Probe.RandomAddress), ': ', verbatim(r.Probe.RandomResponse || '-')) : [], r.Probe.Result === 'catchall' ? dom.div('The server accepts any address at the domain, the probe cannot tell whether the address exists.') : [], r.Probe.Result === 'unknown' ? dom.div('The server did not accept or reject the address, e.g. because of a temporary error or greylisting.') : [], detailsLink(dom.div((r.Probe.Trace || []).map(l => dom.div(dom._class('mono'), style({ paddingLeft: '.5em', whiteSpace: 'pre-wrap', color: l.ClientWrite ? '#e48b00' : blue }), l.Text))))));
+const dkimBreakageResult = (b) => group(title('Failure analysis', attr.title('Changes commonly made by intermediaries, like mailing lists, are reversed, and the signature is verified again.')), dom.div(tag(b.HeadersOK ? green : red, b.HeadersOK ? 'headers ok' : 'headers modified'), ' ', tag(b.BodyOK ? green : red, b.BodyOK ? 'body ok' : 'body modified')), errorTag(b.Error), (b.Hypotheses || []).length === 0 ? dom.div('No explanation found.') : [], (b.Hypotheses || []).map(h => dom.div(h.Confirmed ? tag(green, 'confirmed', attr.title('Reversing the change makes the signature, or the modified part, verify.')) : tag(grey, 'possible'), ' ', h.Text)));
+const dkimDebugResult = (d) => {
+	const other = d.BodyCanon === 'simple' ? 'relaxed' : 'simple';
+	return dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Signature'), errorTag(d.Result.Error), errorTag(d.Error), group(title('Status'), d.Result.Status), !d.HeaderCanon ? [] : [
+		group(title('Canonicalization', attr.title('Header and body canonicalization. With "simple", headers and body are hashed mostly as is. With "relaxed", whitespace is normalized, which survives more modifications by intermediaries.')), d.HeaderCanon + '/' + d.BodyCanon),
+		group(title('Hashed headers', attr.title('In the order they are hashed, from the bottom of the header section upwards. Headers in h= that are not present are not hashed, adding them later breaks the signature. The DKIM-Signature header itself is hashed last, without the value of b=.')), dom.table(dom.tr(['Header', 'Canonicalized, as hashed', 'Other header canonicalization'].map(s => dom.th(s))), (d.Headers || []).map(h => dom.tr(dom.td(h.Name, h.Missing ? [' ', tag(grey, 'not present', attr.title('Not hashed. Adding this header breaks the signature.'))] : []), dom.td(verbatim(h.Canonical)), dom.td(h.Canonical === h.Other ? '-' : verbatim(h.Other)))))),
+		group(title('Body hash'), dom.div('Claimed (bh=): ', verbatim(d.BodyHash)), dom.div('Computed: ', verbatim(d.BodyHashComputed), ' ', d.BodyHash === d.BodyHashComputed ? tag(green, 'match') : tag(red, 'mismatch')), dom.div('Computed with ' + other + ' body canonicalization: ', verbatim(d.BodyHashOther), d.BodyHash === d.BodyHashOther ? [' ', tag(orange, 'match', attr.title('The signer may have canonicalized the body differently than specified in the signature.'))] : [])),
+		d.Length < 0 ? [] : group(title('Length (l=)', attr.title('Only the first l= bytes of the canonicalized body are signed, content can be added after it.')), dom.div('' + d.Length + ' of ' + d.BodyLength + ' bytes of the canonicalized body signed.')),
+		group(title('Lines differing with ' + other + ' body canonicalization', attr.title('Helps find whitespace changes. At most 100 lines are shown. Values are quoted to show whitespace.')), (d.BodyLines || []).length === 0 ? dom.div('None.') : dom.table(dom.tr(['Line', d.BodyCanon, other].map(s => dom.th(s))), (d.BodyLines || []).map(l => dom.tr(dom.td('' + l.Line), dom.td(verbatim(JSON.stringify(l.Canonical))), dom.td(verbatim(JSON.stringify(l.Other))))))),
+		group(title('Canonicalized body', attr.title('As hashed, truncated to l= if present.')), dom.div(style({ maxHeight: '30em', overflow: 'auto' }), verbatim(d.Body))),
+	]);
+};
 const showTimer = (result, left) => {
 	let timer;
 	const showTimeleft = () => {
@@ -951,16 +1318,52 @@ const init = async () => {
 	let spfFieldset;
 	let spfDomain;
 	let spfIP;
+	let dnsblForm;
+	let dnsblFieldset;
+	let dnsblIP;
+	let iprevForm;
+	let iprevFieldset;
+	let iprevIP;
 	let dkimForm;
 	let dkimFieldset;
 	let dkimDomain;
 	let dkimSelector;
 	let dkimverifyFieldset;
-	let dkimverifyMessage;
+	let dkimverifyDebug;
+	const dkimverifyInput = messageInput();
+	let mimeFieldset;
+	const mimeInput = messageInput();
+	let dsnFieldset;
+	const dsnInput = messageInput();
+	let smtpreplyFieldset;
+	let smtpreplyText;
+	let addressFieldset;
+	let addressAddress;
+	let addressProbe;
 	let domainForm;
 	let domainFieldset;
 	let domainName;
+	let batchFieldset;
+	let batchDomains;
+	let expectFieldset;
+	let expectJSON;
+	let clientconfigForm;
+	let clientconfigFieldset;
+	let clientconfigDomain;
+	let reflectorFieldset;
 	let result;
+	const reflectorShow = async (token) => {
+		const r = await client.ReflectorResults(token);
+		dom._kids(result, dom.div(dom._class('results'), reflectorResult(r, async () => {
+			try {
+				await reflectorShow(token);
+			}
+			catch (err) {
+				window.alert('Error: ' + errmsg(err));
+			}
+		})));
+		result.scrollIntoView({ block: 'nearest' });
+	};
 	dom._kids(document.body, dom.div(dom.div(style({ float: 'right', color: '#888' }), dom.div(meta?.Version, ' ', meta?.GoVersion, ' ', meta?.GoOs, '/', meta?.GoArch)), dom.h1('moxtools'), dom.div('Moxtools provides a few email-related tools, mostly as a showcase for the ', dom.a(attr.href('https://pkg.go.dev/github.com/mjl-/mox#section-directories'), 'Go packages'), ' of ', dom.a(attr.href('https://github.com/mjl-/mox'), 'mox'), '.'), dom.div('The public instance at ', dom.a(attr.href('https://tools.xmox.nl'), 'tools.xmox.nl'), ' has rate limiting enabled to prevent abuse, you can easily ', dom.a(attr.href('https://github.com/mjl-/moxtools'), 'run your own moxtools instance'), ' without limits.')), dom.br(), dom.div(dom._class('row'), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Domain check'), domainForm = dom.form(async function submit(e) {
 		e.preventDefault();
 		e.stopPropagation();
@@ -982,7 +1385,92 @@ const init = async () => {
 			clearInterval(timer);
 			domainFieldset.disabled = false;
 		}
-	}, domainFieldset = dom.fieldset(dom.div(dom.label('Domain', dom.div(domainName = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Looks up MX records, and SPF, DMARC, TLSRPT, DANE and MTA-STS, with DNSSEC. Tries to connect to first 2 MX targets and negotiate TLS.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Check SPF'), spfForm = dom.form(async function submit(e) {
+	}, domainFieldset = dom.fieldset(dom.div(dom.label('Domain', dom.div(domainName = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Looks up MX records, and SPF, DMARC, TLSRPT, DANE and MTA-STS, with DNSSEC. Tries to connect to first 2 MX targets and negotiate TLS.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Batch domain check'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		const domains = batchDomains.value.split('\n').map(s => s.trim()).filter(s => s && !s.startsWith('#'));
+		const timer = showTimer(result, 30 * Math.ceil(domains.length / 5));
+		try {
+			batchFieldset.disabled = true;
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+			const br = await client.DomainCheckBatch(domains);
+			clearInterval(timer);
+			dom._kids(result, dom.div(dom._class('results'), domainBatchResult(br)));
+			result.scrollIntoView({ block: 'nearest' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			clearInterval(timer);
+			batchFieldset.disabled = false;
+		}
+	}, batchFieldset = dom.fieldset(dom.div(dom.label('Domains, one per line', dom.div(batchDomains = dom.textarea(attr.required(''), attr.rows('5'), style({ width: '100%' }))))), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Checks up to 100 domains, 5 at a time, and shows a summary table. Use the "domaincheckbatch" subcommand for more domains, with CSV output.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Domain expectations'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		let exp;
+		try {
+			exp = JSON.parse(expectJSON.value);
+		}
+		catch (err) {
+			window.alert('Error: parsing json: ' + errmsg(err));
+			return;
+		}
+		const timer = showTimer(result, 30);
+		try {
+			expectFieldset.disabled = true;
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+			const r = await client.DomainExpect(exp);
+			clearInterval(timer);
+			dom._kids(result, dom.div(dom._class('results'), expectationsResult(r)));
+			result.scrollIntoView({ block: 'nearest' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			clearInterval(timer);
+			expectFieldset.disabled = false;
+		}
+	}, expectFieldset = dom.fieldset(dom.div(dom.label('Expectations as JSON', dom.div(expectJSON = dom.textarea(attr.required(''), attr.rows('12'), style({ width: '100%', fontFamily: 'monospace' }), JSON.stringify({ Domain: 'example.org', DMARCPolicy: 'reject', SPFAll: '-all', MX: ['mx.example.org'], DANERequired: true, MTASTSMode: 'enforce', MTASTSMinMaxAge: 604800, TLSRPT: true, MinScore: 80, DKIM: [{ Selector: 's1', KeyType: 'ed25519' }] }, undefined, '\t'))))), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Checks a domain against expected state, e.g. for compliance. Fields that are absent are not checked. Use the "expect" subcommand to check files with expectations.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Client configuration'), clientconfigForm = dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		window.location.hash = ['#clientconfig', encodeURIComponent(clientconfigDomain.value)].join('/');
+		const timer = showTimer(result, 30);
+		try {
+			clientconfigFieldset.disabled = true;
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+			const r = await client.ClientConfigCheck(clientconfigDomain.value);
+			clearInterval(timer);
+			dom._kids(result, dom.div(dom._class('results'), clientConfigResult(r)));
+			result.scrollIntoView({ block: 'nearest' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			clearInterval(timer);
+			clientconfigFieldset.disabled = false;
+		}
+	}, clientconfigFieldset = dom.fieldset(dom.div(dom.label('Domain', dom.div(clientconfigDomain = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Looks up SRV records for IMAP, POP3 and submission, fetches autoconfig and autodiscover configuration, compares them, and connects to each configured endpoint to verify TLS.')), !meta?.Reflector ? [] : dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Reflector'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		try {
+			reflectorFieldset.disabled = true;
+			const [token, _] = await client.ReflectorStart();
+			window.location.hash = ['#reflector', encodeURIComponent(token)].join('/');
+			await reflectorShow(token);
+		}
+		catch (err) {
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			reflectorFieldset.disabled = false;
+		}
+	}, reflectorFieldset = dom.fieldset(dom.div(dom.submitbutton('Get address')))), dom.div(dom._class('explanation'), 'Get an address to send a message to. Shows how the message arrived: IP, EHLO, TLS, and the SPF, DKIM and DMARC results.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Check SPF'), spfForm = dom.form(async function submit(e) {
 		e.preventDefault();
 		e.stopPropagation();
 		window.location.hash = ['#spfcheck', encodeURIComponent(spfDomain.value), encodeURIComponent(spfIP.value)].join('/');
@@ -1003,7 +1491,49 @@ const init = async () => {
 			clearInterval(timer);
 			spfFieldset.disabled = false;
 		}
-	}, spfFieldset = dom.fieldset(dom.div(dom.label('Domain', dom.div(spfDomain = dom.input(attr.required(''))))), dom.div(dom.label('IP', dom.div(spfIP = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Evaluates the IP address against the SPF policy of the domain.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Lookup DKIM record'), dkimForm = dom.form(async function submit(e) {
+	}, spfFieldset = dom.fieldset(dom.div(dom.label('Domain', dom.div(spfDomain = dom.input(attr.required(''))))), dom.div(dom.label('IP', dom.div(spfIP = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Evaluates the IP address against the SPF policy of the domain.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Check DNSBL'), dnsblForm = dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		window.location.hash = ['#dnsbl', encodeURIComponent(dnsblIP.value)].join('/');
+		const timer = showTimer(result, 15);
+		try {
+			dnsblFieldset.disabled = true;
+			result.scrollIntoView({ block: 'nearest' });
+			const r = await client.DNSBLCheck(dnsblIP.value);
+			clearInterval(timer);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), group(title('DNSBL'), dnsblIPs([r]))))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			clearInterval(timer);
+			dnsblFieldset.disabled = false;
+		}
+	}, dnsblFieldset = dom.fieldset(dom.div(dom.label('IP', dom.div(dnsblIP = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Looks up the IP address in DNS blocklists. Hover over a listing for the return codes and reason.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Check reverse DNS'), iprevForm = dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		window.location.hash = ['#iprev', encodeURIComponent(iprevIP.value)].join('/');
+		const timer = showTimer(result, 15);
+		try {
+			iprevFieldset.disabled = true;
+			result.scrollIntoView({ block: 'nearest' });
+			const r = await client.IPRevCheck(iprevIP.value);
+			clearInterval(timer);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), group(title('Reverse DNS', duration(r.DurationMS)), iprevResult(r), dnssecTag(r.Authentic)), group(title('PTR names'), (r.Names || []).length === 0 ? dom.div('-') : (r.Names || []).map(s => dom.div(verbatim(s))))))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			clearInterval(timer);
+			iprevFieldset.disabled = false;
+		}
+	}, iprevFieldset = dom.fieldset(dom.div(dom.label('IP', dom.div(iprevIP = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Looks up the PTR records for the IP address, and checks if the names resolve back to the IP address (forward-confirmed reverse DNS, "iprev").')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Lookup DKIM record'), dkimForm = dom.form(async function submit(e) {
 		e.preventDefault();
 		e.stopPropagation();
 		window.location.hash = ['#dkimlookup', encodeURIComponent(dkimSelector.value), encodeURIComponent(dkimDomain.value)].join('/');
@@ -1011,9 +1541,9 @@ const init = async () => {
 		try {
 			dkimFieldset.disabled = true;
 			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
-			const [status, record, txt, authentic] = await client.DKIMLookup(dkimSelector.value, dkimDomain.value);
+			const [status, record, txt, authentic, audit] = await client.DKIMLookup(dkimSelector.value, dkimDomain.value);
 			clearInterval(timer);
-			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), group(title('Status'), dom.div(status)), group(title('DNS TXT'), dom.div(txt), dnssecTag(authentic)), group(title('Record in parsed form'), dom.div(record ? formatJSON(record) : '-'))))));
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), group(title('Status'), dom.div(status)), group(title('DNS TXT'), dom.div(txt), dnssecTag(authentic)), group(title('Record in parsed form'), dom.div(record ? formatJSON(record) : '-'))), dom.div(dom._class('result'), style({ maxWidth: '50em' }), dom.h4('Audit', attr.title('Evaluation of the key strength and the fields of the record.')), findingsList(audit)))));
 			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
 		}
 		catch (err) {
@@ -1027,13 +1557,27 @@ const init = async () => {
 	}, dkimFieldset = dom.fieldset(dom.div(dom.label('Selector', dom.div(dkimSelector = dom.input(attr.required(''))))), dom.div(dom.label('Domain', dom.div(dkimDomain = dom.input(attr.required(''))))), dom.div(dom.submitbutton('Lookup')))), dom.div(dom._class('explanation'), 'Looks up the DKIM record for the selector at the domain.')), dom.div(dom._class('inputs'), style({ flexGrow: '1', maxWidth: '80em' }), dom.h2('Verify DKIM signatures in message'), dom.form(async function submit(e) {
 		e.preventDefault();
 		e.stopPropagation();
+		const message = dkimverifyInput.message();
+		if (!message) {
+			window.alert('Paste a message or select a file.');
+			return;
+		}
 		const timer = showTimer(result, 15);
 		try {
 			dkimverifyFieldset.disabled = true;
 			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
-			const results = await client.DKIMVerify(dkimverifyMessage.value);
+			if (dkimverifyDebug.checked) {
+				const results = await client.DKIMDebug(message);
+				clearInterval(timer);
+				dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), (results || []).length === 0 ? dom.div(dom._class('result'), 'No DKIM signatures') : [], (results || []).map(d => dkimDebugResult(d)))));
+				result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+				return;
+			}
+			const results = await client.DKIMVerify(message);
+			// Try to explain failed signatures.
+			const breakage = (results || []).some(r => r.Status === 'fail') ? await client.DKIMBreakage(message) : [];
 			clearInterval(timer);
-			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), (results || []).length === 0 ? dom.div(dom._class('result'), 'No DKIM signatures') : [], (results || []).map(r => dom.div(dom._class('result'), dom.h4('Signature'), errorTag(r.Error), group(title('Status'), r.Status), group(title('Signature'), dom.div(r.Sig ? formatJSON(r.Sig) : '-')), group(title('Record'), dom.div(r.Record ? formatJSON(r.Record) : '-'), r.Record ? dnssecTag(r.RecordAuthentic) : []))))));
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), (results || []).length === 0 ? dom.div(dom._class('result'), 'No DKIM signatures') : [], (results || []).map((r, i) => dom.div(dom._class('result'), dom.h4('Signature'), errorTag(r.Error), group(title('Status'), r.Status), group(title('Signature'), dom.div(r.Sig ? formatJSON(r.Sig) : '-')), group(title('Record'), dom.div(r.Record ? formatJSON(r.Record) : '-'), r.Record ? dnssecTag(r.RecordAuthentic) : []), r.Status === 'fail' && breakage && breakage[i] ? dkimBreakageResult(breakage[i]) : [])))));
 			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
 		}
 		catch (err) {
@@ -1044,7 +1588,81 @@ const init = async () => {
 			clearInterval(timer);
 			dkimverifyFieldset.disabled = false;
 		}
-	}, dkimverifyFieldset = dom.fieldset(dom.div(dom.label('Message', dom.div(dkimverifyMessage = dom.textarea(attr.rows('10'), attr.required(''))))), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Parses the email message, finds all DKIM-Signature headers, and looks up their DKIM record and verifies their signature. Keep in mind that old messages can reference DKIM selectors that no longer exist in DNS and will not verify successfully anymore.'))), result = dom.div());
+	}, dkimverifyFieldset = dom.fieldset(dkimverifyInput.root, dom.div(dom.label(dkimverifyDebug = dom.input(attr.type('checkbox')), ' Debug: show canonicalized headers and body, and computed body hashes')), dom.div(dom.submitbutton('Verify')))), dom.div(dom._class('explanation'), 'Parses the email message, finds all DKIM-Signature headers, and looks up their DKIM record and verifies their signature. Keep in mind that old messages can reference DKIM selectors that no longer exist in DNS and will not verify successfully anymore. For failed signatures, changes commonly made by mailing lists and other intermediaries are reversed to find what broke the signature.')), dom.div(dom._class('inputs'), style({ flexGrow: '1', maxWidth: '80em' }), dom.h2('Inspect MIME structure'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		const message = mimeInput.message();
+		if (!message) {
+			window.alert('Paste a message or select a file.');
+			return;
+		}
+		try {
+			mimeFieldset.disabled = true;
+			const mi = await client.MIMEInspect(message);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Findings'), (mi.Findings || []).length === 0 ? dom.div('No problems found.') : findingsList(mi.Findings))), dom.div(dom._class('row'), dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Structure'), dom.div('' + mi.Size + ' bytes'), mi.Part ? mimePartResult(mi.Part) : dom.div('Message header could not be parsed.')))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			mimeFieldset.disabled = false;
+		}
+	}, mimeFieldset = dom.fieldset(mimeInput.root, dom.div(dom.submitbutton('Inspect')))), dom.div(dom._class('explanation'), 'Parses the message and shows its MIME tree with content types, character sets, transfer encodings, sizes and filenames, and the headers with encoded-words (RFC 2047) decoded. Problems are flagged, such as missing boundaries, non-ASCII headers that require SMTPUTF8, bare newlines and lines longer than 998 characters.')), dom.div(dom._class('inputs'), style({ flexGrow: '1', maxWidth: '80em' }), dom.h2('Parse bounce message'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		const message = dsnInput.message();
+		if (!message) {
+			window.alert('Paste a message or select a file.');
+			return;
+		}
+		try {
+			dsnFieldset.disabled = true;
+			const d = await client.DSNParse(message);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dsnResult(d))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			dsnFieldset.disabled = false;
+		}
+	}, dsnFieldset = dom.fieldset(dsnInput.root, dom.div(dom.submitbutton('Parse')))), dom.div(dom._class('explanation'), 'Parses a bounce message (delivery status notification, DSN) and shows the status per recipient, with an explanation of the enhanced status code, and the headers of the original message. Bounces without machine-readable delivery-status part (RFC 3464), e.g. from Exim, qmail and Yahoo, are parsed heuristically.')), dom.div(dom._class('inputs'), style({ flexGrow: '1', maxWidth: '80em' }), dom.h2('Explain SMTP reply'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		try {
+			smtpreplyFieldset.disabled = true;
+			const r = await client.SMTPReplyExplain(smtpreplyText.value);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), smtpReplyResult(r))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			smtpreplyFieldset.disabled = false;
+		}
+	}, smtpreplyFieldset = dom.fieldset(dom.div(dom.label('SMTP reply or error', dom.div(smtpreplyText = dom.textarea(attr.required(''), attr.rows('4'), style({ width: '100%', fontFamily: 'monospace' }), attr.placeholder('550 5.7.26 This mail has been blocked because the sender is unauthenticated.'))))), dom.div(dom.submitbutton('Explain')))), dom.div(dom._class('explanation'), 'Breaks an SMTP reply, e.g. from a bounce or a delivery log, into its basic reply code, enhanced status code (class, subject and detail) and provider-specific codes from Google, Microsoft, Yahoo and DNS blocklists, and explains what it means and what to do. Errors from the mox SMTP client that include a reply are recognized too.')), dom.div(dom._class('inputs'), style({ width: '20em' }), dom.h2('Address check'), dom.form(async function submit(e) {
+		e.preventDefault();
+		e.stopPropagation();
+		try {
+			addressFieldset.disabled = true;
+			const r = await client.AddressCheck(addressAddress.value, addressProbe.checked);
+			dom._kids(result, dom.div(dom._class('results'), dom.h3('Results'), dom.div(dom._class('row'), dom.div(dom._class('result'), style({ flexGrow: '1' }), dom.h4('Findings'), (r.Findings || []).length === 0 ? dom.div('No problems found.') : findingsList(r.Findings)), addressCheckResult(r))));
+			result.scrollIntoView({ block: 'nearest', behavior: 'smooth' });
+		}
+		catch (err) {
+			dom._kids(result);
+			window.alert('Error: ' + errmsg(err));
+		}
+		finally {
+			addressFieldset.disabled = false;
+		}
+	}, addressFieldset = dom.fieldset(dom.div(dom.label('Address', dom.div(addressAddress = dom.input(attr.required(''))))), dom.div(dom.label(addressProbe = dom.input(attr.type('checkbox')), ' Probe with RCPT TO at the mail server')), dom.div(dom.submitbutton('Check')))), dom.div(dom._class('explanation'), 'Parses the email address, including quoted localparts, internationalized domains and non-ASCII localparts (SMTPUTF8), and explains syntax problems. Looks up the mail servers for the domain, with null MX and implicit MX. Optionally connects to the mail server and checks if the address is accepted, rejected, or if the server accepts all addresses, without delivering a message. Probing must be enabled on the instance.'))), result = dom.div());
 	const h = window.location.hash.substring(1);
 	if (h) {
 		const t = h.split('/');
@@ -1060,11 +1678,26 @@ const init = async () => {
 			spfIP.value = t[2];
 			spfForm.requestSubmit();
 		}
+		else if (t[0] === 'dnsbl' && t.length === 2) {
+			dnsblIP.value = t[1];
+			dnsblForm.requestSubmit();
+		}
+		else if (t[0] === 'iprev' && t.length === 2) {
+			iprevIP.value = t[1];
+			iprevForm.requestSubmit();
+		}
 		else if (t[0] === 'dkimlookup' && t.length === 3) {
 			dkimSelector.value = t[1];
 			dkimDomain.value = t[2];
 			dkimForm.requestSubmit();
 		}
+		else if (t[0] === 'clientconfig' && t.length === 2) {
+			clientconfigDomain.value = t[1];
+			clientconfigForm.requestSubmit();
+		}
+		else if (t[0] === 'reflector' && t.length === 2) {
+			await reflectorShow(t[1]);
+		}
 		else {
 			window.location.hash = '';
 		}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/sasl"
+	"github.com/mjl-/mox/smtpclient"
+)
+
+// Testing credentials is opt-in, an open endpoint would let anyone use this
+// service for guessing passwords.
+var authTest bool
+
+// Mechanisms we can use, in order of preference.
+var smtpAuthMechanisms = []string{
+	"SCRAM-SHA-256-PLUS",
+	"SCRAM-SHA-256",
+	"SCRAM-SHA-1-PLUS",
+	"SCRAM-SHA-1",
+	"CRAM-MD5",
+	"PLAIN",
+	"LOGIN",
+}
+
+// Ports we connect to, so the test cannot be used to reach other services.
+var smtpAuthPorts = []int{25, 465, 587}
+
+type SMTPAuthResult struct {
+	DurationMS         int
+	Host               dns.Domain
+	IP                 net.IP
+	Port               int
+	Security           string // "tls" or "starttls".
+	TLSConnectionState *TLSConnectionState
+	Mechanisms         []string // Offered by server, in upper case.
+	Mechanism          string   // Used for authentication.
+	ChannelBinding     bool     // Whether a PLUS variant of SCRAM was used, binding authentication to the TLS connection.
+	Success            bool
+	Error              string
+
+	Trace []Proto // Lines with credentials are replaced with "***".
+}
+
+func (API) SMTPAuthTest(ctx context.Context, host string, port int, security, mechanism, username, password string) SMTPAuthResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	if !authTest {
+		xcheckuser(errors.New("not enabled on this instance"), "smtp authentication test")
+	}
+
+	// Never log the password, and not the username either.
+	log.Debug("smtpauthtest call", slog.String("host", host), slog.Int("port", port), slog.String("security", security), slog.String("mechanism", mechanism))
+
+	return smtpAuthTest(ctx, log, host, port, security, mechanism, username, password)
+}
+
+func smtpAuthTest(ctx context.Context, log mlog.Log, host string, port int, security, mechanism, username, password string) (r SMTPAuthResult) {
+	hostDom, err := dns.ParseDomain(host)
+	xcheckuser(err, "parsing host")
+	mechanism = strings.ToUpper(mechanism)
+	if mechanism != "" && !slices.Contains(smtpAuthMechanisms, mechanism) {
+		xcheckuser(fmt.Errorf("unknown mechanism %q", mechanism), "parsing mechanism")
+	}
+	if username == "" || password == "" {
+		xcheckuser(errors.New("username and password required"), "checking parameters")
+	}
+
+	var tlsMode smtpclient.TLSMode
+	switch security {
+	case "tls":
+		tlsMode = smtpclient.TLSImmediate
+		if port == 0 {
+			port = 465
+		}
+	case "starttls":
+		tlsMode = smtpclient.TLSRequiredStartTLS
+		if port == 0 {
+			port = 587
+		}
+	default:
+		xcheckuser(fmt.Errorf("unknown value %q, must be tls or starttls", security), "parsing security")
+	}
+	if !slices.Contains(smtpAuthPorts, port) {
+		xcheckuser(fmt.Errorf("port %d is not a submission port, must be 25, 465 or 587", port), "parsing port")
+	}
+
+	start := time.Now()
+	defer func() {
+		r.DurationMS = timeSince(start)
+	}()
+
+	r.Host = hostDom
+	r.Port = port
+	r.Security = security
+	r.Trace = []Proto{}
+
+	opctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	dialedIPs := map[string][]net.IP{}
+	_, _, _, ips, _, err := smtpclient.GatherIPs(opctx, log.Logger, resolver, "ip", dns.IPDomain{Domain: hostDom}, dialedIPs)
+	if err != nil {
+		r.Error = fmt.Sprintf("looking up ips: %v", err)
+		return
+	}
+
+	dialer := &limitDialer{Control: publicDialControl}
+	conn, ip, err := smtpclient.Dial(opctx, log.Logger, dialer, dns.IPDomain{Domain: hostDom}, ips, port, dialedIPs, nil)
+	r.IP = ip
+	if err != nil {
+		r.Error = fmt.Sprintf("dial: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Whether a PLUS mechanism was attempted.
+	var plus bool
+	auth := func(mechanisms []string, cs *tls.ConnectionState) (sasl.Client, error) {
+		r.Mechanisms = mechanisms
+		r.TLSConnectionState = tlsConnectionState(cs)
+
+		var mech string
+		if mechanism != "" {
+			if !slices.Contains(mechanisms, mechanism) {
+				return nil, fmt.Errorf("mechanism %s not offered by server", mechanism)
+			}
+			mech = mechanism
+		} else {
+			for _, m := range smtpAuthMechanisms {
+				if slices.Contains(mechanisms, m) && (cs != nil || !strings.HasSuffix(m, "-PLUS")) {
+					mech = m
+					break
+				}
+			}
+			if mech == "" {
+				return nil, nil
+			}
+		}
+		r.Mechanism = mech
+
+		// The non-PLUS SCRAM variants must know if the server supports channel binding,
+		// to prevent downgrades.
+		noServerPlus := !slices.Contains(mechanisms, mech+"-PLUS")
+		switch mech {
+		case "SCRAM-SHA-256-PLUS", "SCRAM-SHA-1-PLUS":
+			if cs == nil {
+				return nil, errors.New("channel binding requires tls connection")
+			}
+			plus = true
+			if mech == "SCRAM-SHA-256-PLUS" {
+				return sasl.NewClientSCRAMSHA256PLUS(username, password, *cs), nil
+			}
+			return sasl.NewClientSCRAMSHA1PLUS(username, password, *cs), nil
+		case "SCRAM-SHA-256":
+			return sasl.NewClientSCRAMSHA256(username, password, noServerPlus), nil
+		case "SCRAM-SHA-1":
+			return sasl.NewClientSCRAMSHA1(username, password, noServerPlus), nil
+		case "CRAM-MD5":
+			return sasl.NewClientCRAMMD5(username, password), nil
+		case "PLAIN":
+			return sasl.NewClientPlain(username, password), nil
+		case "LOGIN":
+			return sasl.NewClientLogin(username, password), nil
+		}
+		return nil, fmt.Errorf("unsupported mechanism %s", mech)
+	}
+
+	th := traceHandler{Trace: []Proto{}}
+	tracelog := slog.New(&th)
+	opts := smtpclient.Opts{Auth: auth}
+	client, err := smtpclient.New(opctx, tracelog, conn, tlsMode, true, dnsHostname, hostDom, opts)
+	r.Trace = th.Trace
+	if err != nil {
+		r.Error = err.Error()
+		return
+	}
+	client.Close()
+	r.Success = true
+	// Only after the server accepted the authentication.
+	r.ChannelBinding = plus
+	return
+}
+
+func cmdAuthtest(c *cmd) {
+	var port int
+	var security, mechanism string
+	c.flag.IntVar(&port, "port", 0, "port to connect to, default 465 for tls and 587 for starttls")
+	c.flag.StringVar(&security, "security", "tls", "tls for immediate tls, or starttls")
+	c.flag.StringVar(&mechanism, "mechanism", "", "authentication mechanism to use, instead of the most secure mechanism supported by server and moxtools")
+	args := c.Parse()
+	if len(args) != 2 {
+		c.Usage()
+	}
+
+	// Read password from stdin, so it doesn't end up in shell history or process listing.
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		xcmdcheck(err, "reading password from stdin")
+	}
+	password := strings.TrimRight(line, "\r\n")
+
+	r := smtpAuthTest(context.Background(), pkglog, args[0], port, security, mechanism, args[1], password)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(r)
+	xcmdcheck(err, "write result")
+	if !r.Success {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mjl-/mox/smtp"
+)
+
+// Classes of enhanced status codes, RFC 3463 section 3.1.
+var enhancedClasses = map[string]string{
+	"2": "Success",
+	"4": "Persistent transient failure, the message may be delivered on a later attempt",
+	"5": "Permanent failure, the message will not be delivered without changes",
+}
+
+// Subjects of enhanced status codes, RFC 3463 section 3.2.
+var enhancedSubjects = map[string]string{
+	"0": "Other or undefined status",
+	"1": "Addressing status",
+	"2": "Mailbox status",
+	"3": "Mail system status",
+	"4": "Network and routing status",
+	"5": "Mail delivery protocol status",
+	"6": "Message content or media status",
+	"7": "Security or policy status",
+}
+
+// Details of enhanced status codes, by subject and detail, from RFC 3463 and the
+// IANA registry.
+var enhancedDetails = map[string]string{
+	smtp.SeOther00: "Other undefined status.",
+
+	smtp.SeAddr1Other0:                  "Other address status.",
+	smtp.SeAddr1UnknownDestMailbox1:     "Bad destination mailbox address, the mailbox does not exist.",
+	smtp.SeAddr1UnknownSystem2:          "Bad destination system address, the domain does not exist or does not accept mail.",
+	smtp.SeAddr1MailboxSyntax3:          "Bad destination mailbox address syntax.",
+	smtp.SeAddr1MailboxAmbiguous4:       "Destination mailbox address ambiguous.",
+	smtp.SeAddr1DestValid5:              "Destination address valid.",
+	smtp.SeAddr1DestMailboxMoved6:       "Destination mailbox has moved, no forwarding address.",
+	smtp.SeAddr1SenderSyntax7:           "Bad sender's mailbox address syntax.",
+	smtp.SeAddr1BadSenderSystemAddress8: "Bad sender's system address, e.g. the domain of the sender does not exist.",
+	"1.9":                               "Message relayed to a non-compliant mailer.",
+	smtp.SeAddr1NullMX:                  "The destination domain has a null MX record, it does not accept email (RFC 7505).",
+
+	smtp.SeMailbox2Other0:             "Other or undefined mailbox status, the mailbox exists but something prevents delivery.",
+	smtp.SeMailbox2Disabled1:          "Mailbox disabled, not accepting messages.",
+	smtp.SeMailbox2Full2:              "Mailbox full, over quota.",
+	smtp.SeMailbox2MsgLimitExceeded3:  "Message length exceeds the administrative limit of the mailbox.",
+	smtp.SeMailbox2MailListExpansion4: "Mailing list expansion problem.",
+
+	smtp.SeSys3Other0:            "Other or undefined mail system status.",
+	smtp.SeSys3StorageFull1:      "Mail system full, out of storage.",
+	smtp.SeSys3NotAccepting2:     "System not accepting network messages, e.g. shutting down.",
+	smtp.SeSys3NotSupported3:     "System not capable of selected features.",
+	smtp.SeSys3MsgLimitExceeded4: "Message too big for system.",
+	smtp.SeSys3Misconfigured5:    "System incorrectly configured.",
+	"3.6":                        "Requested priority was changed.",
+
+	smtp.SeNet4Other0:           "Other or undefined network or routing status.",
+	smtp.SeNet4NoAnswer1:        "No answer from host, the connection could not be made.",
+	smtp.SeNet4BadConn2:         "Bad connection, dropped during the transaction.",
+	smtp.SeNet4Name3:            "Directory server failure, e.g. DNS lookups failed.",
+	smtp.SeNet4Routing4:         "Unable to route, e.g. no MX or address records for the destination.",
+	smtp.SeNet4Congestion5:      "Mail system congestion.",
+	smtp.SeNet4Loop6:            "Routing loop detected, the message was forwarded too many times.",
+	smtp.SeNet4DeliveryExpired7: "Delivery time expired, the message could not be delivered in time, often after repeated temporary failures.",
+
+	smtp.SeProto5Other0:              "Other or undefined protocol status.",
+	smtp.SeProto5BadCmdOrSeq1:        "Invalid command.",
+	smtp.SeProto5Syntax2:             "Syntax error.",
+	smtp.SeProto5TooManyRcpts3:       "Too many recipients.",
+	smtp.SeProto5BadParams4:          "Invalid command arguments.",
+	smtp.SeProto5ProtocolMismatch5:   "Wrong protocol version.",
+	smtp.SeProto5AuthExchangeTooLong: "Authentication exchange line is too long.",
+
+	smtp.SeMsg6Other0:                    "Other or undefined media error.",
+	smtp.SeMsg6MediaUnsupported1:         "Media not supported.",
+	smtp.SeMsg6ConversionProhibited2:     "Conversion required and prohibited.",
+	smtp.SeMsg6ConversionUnsupported3:    "Conversion required but not supported, e.g. an 8-bit message to a server without 8BITMIME.",
+	smtp.SeMsg6ConversionWithLoss4:       "Conversion with loss performed.",
+	smtp.SeMsg6ConversionFailed5:         "Conversion failed.",
+	"6.6":                                "Message content not available.",
+	smtp.SeMsg6NonASCIIAddrNotPermitted7: "Non-ASCII addresses not permitted for that sender or recipient, SMTPUTF8 is required (RFC 6531).",
+	smtp.SeMsg6UTF8ReplyRequired8:        "UTF-8 string reply is required, but not permitted by the client.",
+	smtp.SeMsg6UTF8CannotTransfer9:       "UTF-8 header message cannot be transferred to one or more recipients, SMTPUTF8 is not supported.",
+
+	smtp.SePol7Other0:                "Other or undefined security status, often used for rejections based on reputation or content (spam).",
+	smtp.SePol7DeliveryUnauth1:       "Delivery not authorized, message refused, e.g. the sender or sending IP is blocked, or relaying is denied.",
+	smtp.SePol7ExpnProhibited2:       "Mailing list expansion prohibited.",
+	smtp.SePol7ConversionImpossible3: "Security conversion required but not possible.",
+	smtp.SePol7Unsupported4:          "Security features not supported.",
+	smtp.SePol7CryptoFailure5:        "Cryptographic failure.",
+	smtp.SePol7CryptoUnsupported6:    "Cryptographic algorithm not supported.",
+	smtp.SePol7MsgIntegrity7:         "Message integrity failure.",
+	smtp.SePol7AuthBadCreds8:         "Authentication credentials invalid.",
+	smtp.SePol7AuthWeakMech9:         "Authentication mechanism is too weak.",
+	smtp.SePol7EncNeeded10:           "Encryption needed.",
+	smtp.SePol7EncReqForAuth11:       "Encryption required for requested authentication mechanism.",
+	smtp.SePol7PasswdTransitionReq12: "A password transition is needed.",
+	smtp.SePol7AccountDisabled13:     "User account disabled.",
+	smtp.SePol7TrustReq14:            "Trust relationship required.",
+	"7.15":                           "Priority level is too low.",
+	"7.16":                           "Message is too big for the specified priority.",
+	"7.17":                           "Mailbox owner has changed.",
+	"7.18":                           "Domain owner has changed.",
+	"7.19":                           "RRVS test cannot be completed.",
+	smtp.SePol7NoDKIMPass20:          "No passing DKIM signature found.",
+	smtp.SePol7NoDKIMAccept21:        "No acceptable DKIM signature found.",
+	smtp.SePol7NoDKIMAuthorMatch22:   "No valid author-matched DKIM signature found.",
+	smtp.SePol7SPFResultFail23:       "SPF validation failed.",
+	smtp.SePol7SPFError24:            "SPF validation error.",
+	smtp.SePol7RevDNSFail25:          "Reverse DNS validation failed, the sending IP has no PTR record resolving back to it.",
+	smtp.SePol7MultiAuthFails26:      "Multiple authentication checks failed. Also used by large mail providers for messages without passing SPF or DKIM, or failing DMARC.",
+	smtp.SePol7SenderHasNullMX27:     "The sender address has a null MX record, it cannot receive replies (RFC 7505).",
+	"7.28":                           "Mail flood detected.",
+	smtp.SePol7ARCFail29:             "ARC validation failure.",
+	smtp.SePol7MissingReqTLS30:       "REQUIRETLS support required, but not available on the path (RFC 8689).",
+}
+
+// enhancedCodeParts returns explanations of the class, subject and detail of an
+// enhanced status code, e.g. "5.1.1". Unknown parts are empty.
+func enhancedCodeParts(code string) (class, subject, detail string) {
+	t := strings.Split(code, ".")
+	if len(t) != 3 {
+		return
+	}
+	return enhancedClasses[t[0]], enhancedSubjects[t[1]], enhancedDetails[t[1]+"."+t[2]]
+}
+
+// enhancedCodeExplanation returns an explanation of an enhanced status code,
+// with class and detail. For unknown details, the subject is explained.
+func enhancedCodeExplanation(code string) string {
+	class, subject, detail := enhancedCodeParts(code)
+	if class == "" {
+		return ""
+	} else if detail != "" {
+		return class + ". " + detail
+	} else if subject != "" {
+		return class + ". " + subject + "."
+	}
+	return class + "."
+}
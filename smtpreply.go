@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mjl-/mox/smtp"
+	"github.com/mjl-/mox/smtpclient"
+)
+
+// SMTPReplyTag is a provider-specific code in an SMTP reply.
+type SMTPReplyTag struct {
+	Provider    string // E.g. "Google" or "DNSBL".
+	Tag         string
+	Explanation string // Empty if the tag is not known.
+}
+
+// SMTPReply is an SMTP reply, broken into its parts and explained.
+type SMTPReply struct {
+	Code            int // Basic reply code, e.g. 550.
+	CodeExplanation string
+	EnhancedCode    string // E.g. "5.7.26", empty if absent.
+	Class           string // Explanation of the first number of the enhanced code.
+	Subject         string
+	Detail          string // Empty if the detail is not known.
+	Text            string // Of all lines, without codes.
+	ClientError     string // Explanation of the error from an SMTP client, like the mox smtpclient, before the reply.
+	Tags            []SMTPReplyTag
+	Advice          string
+}
+
+// Basic reply codes, RFC 5321 section 4.2.3, and later RFCs.
+var replyCodes = map[int]string{
+	smtp.C211SystemStatus: "System status, or system help reply.",
+	smtp.C214Help:         "Help message.",
+	smtp.C220ServiceReady: "Service ready, greeting of the server.",
+	smtp.C221Closing:      "Service closing transmission channel.",
+	smtp.C235AuthSuccess:  "Authentication succeeded.",
+
+	smtp.C250Completed:               "Requested mail action okay, completed.",
+	smtp.C251UserNotLocalWillForward: "User not local, the message will be forwarded.",
+	smtp.C252WithoutVrfy:             "Cannot verify the user, but the message will be accepted and delivery attempted.",
+
+	smtp.C334ContinueAuth: "Continue authentication exchange.",
+	smtp.C354Continue:     "Start mail input, end with a line with a single dot.",
+
+	smtp.C421ServiceUnavail:         "Service not available, the server is closing the connection. Often used for rate limiting or temporary blocks.",
+	smtp.C432PasswdTransitionNeeded: "A password transition is needed.",
+	smtp.C450MailboxUnavail:         "Requested mail action not taken, mailbox unavailable, e.g. busy or temporarily blocked for policy reasons. Greylisting also uses this code.",
+	smtp.C451LocalErr:               "Requested action aborted, local error in processing. Greylisting and temporary DNS failures also use this code.",
+	smtp.C452StorageFull:            "Requested action not taken, insufficient system storage, or too many recipients.",
+	smtp.C454TempAuthFail:           "Temporary authentication failure.",
+	smtp.C455BadParams:              "Server unable to accommodate parameters.",
+
+	smtp.C500BadSyntax:              "Syntax error, command unrecognized.",
+	smtp.C501BadParamSyntax:         "Syntax error in parameters or arguments.",
+	smtp.C502CmdNotImpl:             "Command not implemented.",
+	smtp.C503BadCmdSeq:              "Bad sequence of commands.",
+	smtp.C504ParamNotImpl:           "Command parameter not implemented.",
+	smtp.C521HostNoMail:             "Host does not accept mail (RFC 7504).",
+	smtp.C530SecurityRequired:       "Must issue a STARTTLS command first, or authentication required.",
+	smtp.C534AuthMechWeak:           "Authentication mechanism is too weak.",
+	smtp.C535AuthBadCreds:           "Authentication credentials invalid.",
+	smtp.C538EncReqForAuth:          "Encryption required for requested authentication mechanism.",
+	smtp.C550MailboxUnavail:         "Requested action not taken, mailbox unavailable, e.g. not found or no access. Also used for rejections for policy reasons.",
+	smtp.C551UserNotLocal:           "User not local.",
+	smtp.C552MailboxFull:            "Requested mail action aborted, exceeded storage allocation, or the message is too large.",
+	smtp.C553BadMailbox:             "Requested action not taken, mailbox name not allowed, e.g. incorrect syntax.",
+	smtp.C554TransactionFailed:      "Transaction failed, or no SMTP service here. Often used for rejections for policy reasons, e.g. spam or blocklisting.",
+	smtp.C555UnrecognizedAddrParams: "MAIL FROM or RCPT TO parameters not recognized or not implemented.",
+	smtp.C556DomainNoMail:           "Domain does not accept mail (RFC 7504).",
+}
+
+// Errors of the mox smtpclient package that can precede a reply in error
+// messages.
+var clientErrors = []struct {
+	err         error
+	explanation string
+}{
+	{smtpclient.ErrSize, "The message is larger than the maximum size announced by the server, it was not sent."},
+	{smtpclient.ErrRequireTLSUnsupported, "The message requires TLS on the whole path (REQUIRETLS), but the server does not support it."},
+	{smtpclient.ErrStatus, "The server replied with an unexpected status, e.g. a temporary or permanent error when success was expected."},
+	{smtpclient.ErrProtocol, "The server sent a malformed reply, or an inconsistent multi-line reply."},
+	{smtpclient.ErrTLS, "The TLS handshake failed, or verifying the TLS certificate of the server failed."},
+	{smtpclient.ErrBotched, "The connection is unusable after an earlier error."},
+	{smtpclient.ErrClosed, "The connection was already closed."},
+}
+
+var (
+	replyRegexp        = regexp.MustCompile(`(?:^|[\s:(\[])([2-5][0-9][0-9])(?:[ -]|$)(?:\s*#?([2-5]\.[0-9]{1,3}\.[0-9]{1,3})\b)?`)
+	replyLineRegexp    = regexp.MustCompile(`^\s*([2-5][0-9][0-9])[ -]?(?:\s*#?[2-5]\.[0-9]{1,3}\.[0-9]{1,3}\b)?\s*`)
+	googleTagRegexp    = regexp.MustCompile(`support\.google\.com/mail/\?p=([A-Za-z0-9]+)`)
+	microsoftTagRegexp = regexp.MustCompile(`\b(S[0-9]{3,4}|AS\([0-9]+\))`)
+	yahooTagRegexp     = regexp.MustCompile(`\b(TSS[0-9]{2}|PH01)\b`)
+	dnsblRegexp        = regexp.MustCompile(`(?i)\b(?:blocked using|listed (?:at|in|on|by))\s+([a-z0-9.-]+\.[a-z]{2,})`)
+)
+
+// Google tags in the support URL of replies.
+var googleTags = map[string]string{
+	"NoSuchUser":                "The recipient address does not exist.",
+	"OverQuotaTemp":             "The mailbox of the recipient is full, temporarily.",
+	"OverQuotaPerm":             "The mailbox of the recipient is full.",
+	"UnsolicitedMessageError":   "The message looks like spam, or the sending IP or domain has a bad reputation.",
+	"UnsolicitedRateLimitError": "Rate limited because of unusual traffic from the sending IP or domain, or bad reputation.",
+	"BlockedMessage":            "The message was blocked, e.g. because of its content or attachments.",
+	"DisabledUser":              "The account of the recipient is disabled.",
+}
+
+// Microsoft-specific enhanced status codes, details beyond the registry.
+var microsoftDetails = map[string]string{
+	"7.509": "The message was rejected because of the DMARC policy of the sender's domain.",
+	"7.606": "The sending IP is banned.",
+	"7.708": "Traffic from the sending IP is not accepted, often because the IP has no sending history (reputation).",
+	"7.750": "The sending domain is not registered with the service, often for a new or suspended tenant.",
+}
+
+var yahooTags = map[string]string{
+	"TSS04": "Temporarily deferred because of unexpected volume or user complaints.",
+	"TSS09": "All messages from the IP will be permanently deferred, because of reputation.",
+	"TSS11": "Temporarily deferred because of excessive user complaints.",
+	"PH01":  "Not accepted for policy reasons.",
+}
+
+// Advice by subject and detail of the enhanced code, or by subject.
+var replyAdvice = map[string]string{
+	"1.1":  "Check the recipient address for typos. Remove the address from mailing lists if it keeps failing.",
+	"1.2":  "Check the domain of the recipient address. It may not exist, or have no MX records.",
+	"1.10": "The domain of the recipient does not accept email, the address cannot be used.",
+	"2.1":  "The mailbox is disabled, contact the recipient through other means.",
+	"2.2":  "The mailbox of the recipient is full. Try again later, or contact the recipient through other means.",
+	"3.4":  "Send a smaller message, e.g. with a link instead of large attachments.",
+	"4.7":  "Check the earlier delivery attempts for the underlying cause, and whether the destination is reachable.",
+	"7.1":  "Check whether the sending IP is listed in blocklists, and the sender domain has valid SPF, DKIM and DMARC records. If relaying was denied, authenticate before sending.",
+	"7.23": "Add the sending IP to the SPF record of the sender domain.",
+	"7.25": "Configure reverse DNS (a PTR record) for the sending IP, with a hostname that resolves back to the IP.",
+	"7.26": "Sign messages with DKIM and ensure SPF passes, aligned with the domain in the From header for DMARC.",
+	"7.27": "Fix the sender address, it has a domain that cannot receive mail.",
+	"7":    "Check the reputation of the sending IP and domain, blocklists, and the SPF, DKIM and DMARC configuration of the sender domain.",
+	"6":    "Check the encoding of the message, e.g. 8-bit content or non-ASCII addresses to servers without support.",
+	"4":    "Check DNS and connectivity of the destination.",
+	"1":    "Check the recipient address.",
+}
+
+// SMTPReplyExplain breaks an SMTP reply, e.g. "550 5.7.26 ..." or an error from an
+// SMTP client, into its parts and explains them.
+func (API) SMTPReplyExplain(ctx context.Context, text string) SMTPReply {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+
+	log.Debug("smtpreplyexplain call", slog.String("text", text))
+
+	r, err := smtpReplyExplain(text)
+	xcheckuser(err, "parsing smtp reply")
+	return r
+}
+
+func smtpReplyExplain(text string) (SMTPReply, error) {
+	r := SMTPReply{Tags: []SMTPReplyTag{}}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	loc := replyRegexp.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return r, errors.New("no smtp reply code found, e.g. 550")
+	}
+	r.Code, _ = strconv.Atoi(text[loc[2]:loc[3]])
+	if loc[4] >= 0 {
+		r.EnhancedCode = text[loc[4]:loc[5]]
+	}
+
+	// Explain a client error before the reply, e.g. "remote smtp server sent
+	// unexpected response status code, permanent: 550 ...".
+	prefix := strings.ToLower(text[:loc[2]])
+	for _, ce := range clientErrors {
+		if strings.Contains(prefix, ce.err.Error()) {
+			r.ClientError = ce.explanation
+			break
+		}
+	}
+
+	// Multi-line replies repeat the code on each line.
+	var lines []string
+	for _, line := range strings.Split(text[loc[2]:], "\n") {
+		if m := replyLineRegexp.FindStringSubmatch(line); m != nil && m[1] == strconv.Itoa(r.Code) {
+			line = line[len(m[0]):]
+		}
+		if s := strings.TrimSpace(line); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	r.Text = strings.Join(lines, " ")
+
+	r.CodeExplanation = replyCodes[r.Code]
+	if r.CodeExplanation == "" {
+		switch r.Code / 100 {
+		case 2:
+			r.CodeExplanation = "Success."
+		case 3:
+			r.CodeExplanation = "Intermediate reply, more information is needed."
+		case 4:
+			r.CodeExplanation = "Transient failure."
+		case 5:
+			r.CodeExplanation = "Permanent failure."
+		}
+	}
+	if r.EnhancedCode != "" {
+		if r.EnhancedCode[0] != text[loc[2]] {
+			r.CodeExplanation += fmt.Sprintf(" Note: the class of the enhanced code (%c) does not match the reply code.", r.EnhancedCode[0])
+		}
+		r.Class, r.Subject, r.Detail = enhancedCodeParts(r.EnhancedCode)
+	}
+
+	lower := strings.ToLower(text)
+	if m := googleTagRegexp.FindStringSubmatch(text); m != nil {
+		r.Tags = append(r.Tags, SMTPReplyTag{"Google", m[1], googleTags[m[1]]})
+	} else if strings.HasSuffix(lower, "gsmtp") {
+		r.Tags = append(r.Tags, SMTPReplyTag{"Google", "gsmtp", "Reply from a Google mail server."})
+	}
+	microsoft := strings.Contains(lower, "outlook.com") || strings.Contains(lower, "microsoft") || strings.Contains(lower, "hotmail")
+	for _, m := range microsoftTagRegexp.FindAllStringSubmatch(text, -1) {
+		var expl string
+		if strings.HasPrefix(m[1], "AS(") {
+			expl = "Microsoft 365 rejection reason code, e.g. AS(201806281) for a recipient that does not exist."
+		} else {
+			expl = "Outlook.com block code, typically for a sending IP with bad reputation or on an internal blocklist. Request delisting through the Microsoft delisting portal."
+		}
+		if strings.HasPrefix(m[1], "AS(") || microsoft {
+			r.Tags = append(r.Tags, SMTPReplyTag{"Microsoft", m[1], expl})
+		}
+	}
+	if r.EnhancedCode != "" && r.Detail == "" {
+		_, sd, _ := strings.Cut(r.EnhancedCode, ".")
+		if s, ok := microsoftDetails[sd]; ok {
+			r.Tags = append(r.Tags, SMTPReplyTag{"Microsoft", r.EnhancedCode, s})
+		} else if t := strings.Split(sd, "."); t[0] == "7" {
+			if n, _ := strconv.Atoi(t[1]); n >= 606 && n <= 649 {
+				r.Tags = append(r.Tags, SMTPReplyTag{"Microsoft", r.EnhancedCode, microsoftDetails["7.606"]})
+			}
+		}
+	}
+	for _, m := range yahooTagRegexp.FindAllStringSubmatch(text, -1) {
+		r.Tags = append(r.Tags, SMTPReplyTag{"Yahoo", m[1], yahooTags[m[1]]})
+	}
+	for _, m := range dnsblRegexp.FindAllStringSubmatch(text, -1) {
+		r.Tags = append(r.Tags, SMTPReplyTag{"DNSBL", m[1], "The sending IP is listed in this DNS blocklist. Check the IP with the DNSBL check, and request delisting after fixing the cause."})
+	}
+
+	if r.EnhancedCode != "" {
+		_, sd, _ := strings.Cut(r.EnhancedCode, ".")
+		subj, _, _ := strings.Cut(sd, ".")
+		r.Advice = replyAdvice[sd]
+		if r.Advice == "" {
+			r.Advice = replyAdvice[subj]
+		}
+	}
+	if r.Advice == "" && r.Code/100 == 5 {
+		r.Advice = "The message will not be delivered as is. The text of the reply often has the reason, or a link with more information."
+	}
+	if r.Code/100 == 4 {
+		r.Advice = strings.TrimSpace("The sending server retries delivery for a while, typically days. " + r.Advice)
+	}
+	return r, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/spf"
+)
+
+// RFC 7208 section 4.6.4: at most 10 mechanisms/modifiers causing DNS lookups.
+const spfLookupLimit = 10
+
+// spfLookups counts the DNS lookups needed to evaluate record, following include
+// and redirect. Like evaluators, a domain included multiple times is counted
+// each time. Domains with macros cannot be followed without a message, they are
+// counted but not followed. Counting stops when the limit is exceeded. An error
+// is returned if a nested record cannot be used, or for an include loop.
+func spfLookups(ctx context.Context, log mlog.Log, record *spf.Record) (int, error) {
+	var n int
+	records := map[string]*spf.Record{} // Lookup cache.
+	path := map[string]bool{}           // Domains being evaluated, for loop detection.
+	var walk func(r *spf.Record) error
+	follow := func(name string) error {
+		if strings.Contains(name, "%") || n > spfLookupLimit {
+			return nil
+		}
+		key := strings.ToLower(strings.TrimSuffix(name, "."))
+		if path[key] {
+			return fmt.Errorf("loop: %s includes itself", name)
+		}
+		r, ok := records[key]
+		if !ok {
+			d, err := dns.ParseDomainLax(name)
+			if err != nil {
+				return fmt.Errorf("parsing domain %q: %v", name, err)
+			}
+			_, _, r, _, err = spf.Lookup(ctx, log.Logger, resolver, d)
+			if err != nil {
+				return fmt.Errorf("record for %s: %v", name, err)
+			}
+			records[key] = r
+		}
+		path[key] = true
+		defer delete(path, key)
+		return walk(r)
+	}
+	walk = func(r *spf.Record) error {
+		var all bool
+		for _, d := range r.Directives {
+			switch d.Mechanism {
+			case "include":
+				n++
+				if err := follow(d.DomainSpec); err != nil {
+					return err
+				}
+			case "a", "mx", "ptr", "exists":
+				n++
+			case "all":
+				all = true
+			}
+		}
+		// Evaluators ignore redirect if the record has an "all" mechanism.
+		if r.Redirect != "" && !all {
+			n++
+			return follow(r.Redirect)
+		}
+		return nil
+	}
+	err := walk(record)
+	return n, err
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjl-/mox/dns"
+	"github.com/mjl-/mox/mlog"
+	"github.com/mjl-/mox/smtpclient"
+)
+
+// Scanning makes many connections to each MX host, so it is opt-in.
+var tlsScan bool
+
+type TLSScanResult struct {
+	DurationMS   int
+	Host         dns.IPDomain
+	IP           net.IP
+	Versions     []TLSScanVersion
+	CipherSuites []TLSScanCipherSuite // For TLS 1.2. Go cannot restrict the TLS 1.3 cipher suites.
+	CertKeyTypes []string             // Of certificates seen with ECDSA and RSA cipher suites, e.g. "ecdsa" and "rsa".
+	DualCert     bool                 // Whether different certificates are served for ECDSA and RSA cipher suites.
+	Warnings     []string             // About legacy protocols and insecure cipher suites.
+	Error        string
+}
+
+type TLSScanVersion struct {
+	Version     string
+	Accepted    bool
+	CipherSuite string // Negotiated.
+	Error       string
+}
+
+type TLSScanCipherSuite struct {
+	Name     string
+	Insecure bool // As marked by Go, e.g. RC4, 3DES or CBC with SHA-256.
+	Accepted bool
+}
+
+var tlsScanVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+func (API) TLSScan(ctx context.Context, domain string) []TLSScanResult {
+	log := newLog()
+
+	xlimit(ctx, &apiLimiter)
+	xlimit(ctx, &apiDomainLimiter)
+
+	if !tlsScan {
+		xcheckuser(errors.New("not enabled on this instance"), "tls scan")
+	}
+
+	log.Debug("tlsscan call", slog.String("domain", domain))
+
+	dom, err := dns.ParseDomain(domain)
+	xcheckuser(err, "parsing domain")
+
+	return tlsScanDomain(ctx, log, dom)
+}
+
+// tlsScanDomain scans the first IP of each MX host of the domain, in parallel.
+func tlsScanDomain(ctx context.Context, log mlog.Log, dom dns.Domain) []TLSScanResult {
+	opctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+
+	_, _, _, _, hosts, _, err := smtpclient.GatherDestinations(opctx, log.Logger, resolver, dns.IPDomain{Domain: dom})
+	if err != nil {
+		xcheckuser(err, "looking up mx hosts")
+	}
+
+	results := make([]TLSScanResult, len(hosts))
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func() {
+			defer logPanic(log)
+			defer wg.Done()
+
+			results[i] = tlsScanHost(opctx, log, h)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// tlsScanHost does a TLS handshake after STARTTLS for each TLS version, and for
+// each TLS 1.2 cipher suite, each with a new connection. The connections are made
+// one after the other, so as not to overload the server. The connections don't
+// go through the per-IP rate limiter, the API charges the domain rate limiter
+// once for the whole scan.
+func tlsScanHost(ctx context.Context, log mlog.Log, host dns.IPDomain) (r TLSScanResult) {
+	start := time.Now()
+	defer func() {
+		r.DurationMS = timeSince(start)
+	}()
+
+	r.Host = host
+	r.Versions = []TLSScanVersion{}
+	r.CipherSuites = []TLSScanCipherSuite{}
+	r.Warnings = []string{}
+
+	_, _, _, ips, _, err := smtpclient.GatherIPs(ctx, log.Logger, resolver, "ip", host, map[string][]net.IP{})
+	if err != nil {
+		r.Error = fmt.Sprintf("looking up ips: %v", err)
+		return
+	} else if len(ips) == 0 {
+		r.Error = "no ips"
+		return
+	}
+	r.IP = ips[0]
+	addr := net.JoinHostPort(r.IP.String(), "25")
+
+	dialer := &net.Dialer{Control: publicDialControl}
+	handshake := func(config *tls.Config) (*tls.ConnectionState, error) {
+		return tlsScanHandshake(ctx, dialer, addr, host.Domain, config)
+	}
+
+	for _, v := range tlsScanVersions {
+		sv := TLSScanVersion{Version: tlsVersionName(v)}
+		cs, err := handshake(&tls.Config{MinVersion: v, MaxVersion: v})
+		if err != nil {
+			sv.Error = err.Error()
+		} else {
+			sv.Accepted = true
+			sv.CipherSuite = tls.CipherSuiteName(cs.CipherSuite)
+			if v < tls.VersionTLS12 {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("Legacy protocol %s accepted, deprecated by RFC 8996.", sv.Version))
+			}
+		}
+		r.Versions = append(r.Versions, sv)
+		if errors.Is(err, errTLSScanSMTP) {
+			// No point in continuing if STARTTLS isn't working.
+			if r.Error == "" {
+				r.Error = err.Error()
+			}
+			return
+		}
+	}
+
+	var ecdsaSuites, rsaSuites []uint16
+	tls12 := func(l []*tls.CipherSuite, insecure bool) {
+		for _, s := range l {
+			if !slices.Contains(s.SupportedVersions, tls.VersionTLS12) {
+				continue
+			}
+			_, err := handshake(&tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{s.ID}})
+			r.CipherSuites = append(r.CipherSuites, TLSScanCipherSuite{s.Name, insecure, err == nil})
+			if err == nil && insecure {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("Insecure cipher suite %s accepted.", s.Name))
+			}
+			if err == nil && strings.HasPrefix(s.Name, "TLS_ECDHE_ECDSA_") {
+				ecdsaSuites = append(ecdsaSuites, s.ID)
+			} else if err == nil && strings.HasPrefix(s.Name, "TLS_ECDHE_RSA_") {
+				rsaSuites = append(rsaSuites, s.ID)
+			}
+		}
+	}
+	tls12(tls.CipherSuites(), false)
+	tls12(tls.InsecureCipherSuites(), true)
+
+	// Find the certificates for ECDSA and RSA cipher suites, to detect dual certificates.
+	var fingerprints []string
+	for _, suites := range [][]uint16{ecdsaSuites, rsaSuites} {
+		if len(suites) == 0 {
+			continue
+		}
+		cs, err := handshake(&tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12, CipherSuites: suites})
+		if err != nil || len(cs.PeerCertificates) == 0 {
+			continue
+		}
+		var keyType string
+		switch cs.PeerCertificates[0].PublicKey.(type) {
+		case *ecdsa.PublicKey:
+			keyType = "ecdsa"
+		case *rsa.PublicKey:
+			keyType = "rsa"
+		case ed25519.PublicKey:
+			keyType = "ed25519"
+		default:
+			keyType = "unknown"
+		}
+		if !slices.Contains(r.CertKeyTypes, keyType) {
+			r.CertKeyTypes = append(r.CertKeyTypes, keyType)
+		}
+		fingerprints = append(fingerprints, tlsConnectionState(cs).CertFingerprint)
+	}
+	r.DualCert = len(fingerprints) == 2 && fingerprints[0] != fingerprints[1]
+	return
+}
+
+var errTLSScanSMTP = errors.New("smtp")
+
+// tlsScanHandshake connects to addr, does EHLO and STARTTLS, and a TLS handshake
+// with config. Certificates are not verified.
+func tlsScanHandshake(ctx context.Context, dialer smtpclient.Dialer, addr string, host dns.Domain, config *tls.Config) (*tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: dial: %w", errTLSScanSMTP, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	br := bufio.NewReader(conn)
+	response := func(code string) error {
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(line, code) {
+				return fmt.Errorf("unexpected response %q", strings.TrimSpace(line))
+			}
+			if len(line) < 4 || line[3] != '-' {
+				return nil
+			}
+		}
+	}
+	command := func(name, cmd, code string) error {
+		if cmd != "" {
+			if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+				return fmt.Errorf("%w: %s: %v", errTLSScanSMTP, name, err)
+			}
+		}
+		if err := response(code); err != nil {
+			return fmt.Errorf("%w: %s: %v", errTLSScanSMTP, name, err)
+		}
+		return nil
+	}
+	if err := command("greeting", "", "220"); err != nil {
+		return nil, err
+	}
+	if err := command("ehlo", "EHLO "+dnsHostname.ASCII, "250"); err != nil {
+		return nil, err
+	}
+	if err := command("starttls", "STARTTLS", "220"); err != nil {
+		return nil, err
+	}
+
+	config.ServerName = host.ASCII
+	config.InsecureSkipVerify = true // We only look at the handshake.
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(tlsConn, "QUIT\r\n")
+	cs := tlsConn.ConnectionState()
+	return &cs, nil
+}
+
+func cmdTLSScan(c *cmd) {
+	args := c.Parse()
+	if len(args) != 1 {
+		c.Usage()
+	}
+
+	dom, err := dns.ParseDomain(args[0])
+	xcmdcheck(err, "parsing domain")
+
+	results := tlsScanDomain(context.Background(), pkglog, dom)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(results)
+	xcmdcheck(err, "write result")
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mjl-/adns"
+
+	"github.com/mjl-/mox/dns"
+)
+
+// zoneResolver returns a resolver that answers from zone files, in the RFC 1035
+// master file format, for checking DNS changes before they are published. Only
+// the record types used by the checks are kept: A, AAAA, MX, TXT, CNAME and TLSA.
+// Names are relative to origin unless the zone file has an $ORIGIN.
+func zoneResolver(files []string, origin dns.Domain, authentic bool) (dns.MockResolver, error) {
+	r := dns.MockResolver{
+		A:            map[string][]string{},
+		AAAA:         map[string][]string{},
+		TXT:          map[string][]string{},
+		MX:           map[string][]*net.MX{},
+		TLSA:         map[string][]adns.TLSA{},
+		CNAME:        map[string]string{},
+		AllAuthentic: authentic,
+	}
+	for _, f := range files {
+		buf, err := os.ReadFile(f)
+		if err != nil {
+			return r, err
+		}
+		if err := parseZone(r, string(buf), origin.ASCII+"."); err != nil {
+			return r, fmt.Errorf("%s:%v", f, err)
+		}
+	}
+	return r, nil
+}
+
+// zoneToken is a word from a zone file, quoted strings are kept together.
+type zoneToken struct {
+	s      string
+	quoted bool
+}
+
+func parseZone(r dns.MockResolver, data, origin string) error {
+	var owner string
+	var pending [][]zoneToken // Tokens of current record, with continuation lines within parentheses.
+	var lineno, startLine int
+	var parens int
+	var ownerBlank bool
+
+	// fqdn makes name absolute and lower case.
+	fqdn := func(name string) string {
+		name = strings.ToLower(name)
+		if name == "@" {
+			return origin
+		} else if strings.HasSuffix(name, ".") {
+			return name
+		}
+		return name + "." + origin
+	}
+
+	record := func(l []zoneToken) error {
+		if len(l) == 0 {
+			return nil
+		}
+		if !l[0].quoted && strings.HasPrefix(l[0].s, "$") {
+			switch strings.ToUpper(l[0].s) {
+			case "$ORIGIN":
+				if len(l) != 2 {
+					return fmt.Errorf("$ORIGIN needs one parameter")
+				}
+				origin = fqdn(l[1].s)
+				return nil
+			case "$TTL":
+				return nil
+			}
+			return fmt.Errorf("unsupported directive %s", l[0].s)
+		}
+		if !ownerBlank {
+			owner = fqdn(l[0].s)
+			l = l[1:]
+		} else if owner == "" {
+			return fmt.Errorf("record without owner name")
+		}
+		// Optional TTL and class, in any order.
+		for len(l) > 0 && !l[0].quoted {
+			if c := l[0].s[0]; c >= '0' && c <= '9' || strings.EqualFold(l[0].s, "IN") {
+				l = l[1:]
+			} else {
+				break
+			}
+		}
+		if len(l) == 0 {
+			return fmt.Errorf("missing record type")
+		}
+		typ := strings.ToUpper(l[0].s)
+		l = l[1:]
+		need := func(n int) error {
+			if len(l) < n {
+				return fmt.Errorf("%s record needs %d parameters, got %d", typ, n, len(l))
+			}
+			return nil
+		}
+		switch typ {
+		case "A", "AAAA":
+			if err := need(1); err != nil {
+				return err
+			}
+			ip := net.ParseIP(l[0].s)
+			if ip == nil || (typ == "A") != (ip.To4() != nil) {
+				return fmt.Errorf("invalid ip %q for %s record", l[0].s, typ)
+			}
+			if typ == "A" {
+				r.A[owner] = append(r.A[owner], ip.String())
+			} else {
+				r.AAAA[owner] = append(r.AAAA[owner], ip.String())
+			}
+		case "MX":
+			if err := need(2); err != nil {
+				return err
+			}
+			pref, err := strconv.ParseUint(l[0].s, 10, 16)
+			if err != nil {
+				return fmt.Errorf("invalid mx preference %q", l[0].s)
+			}
+			r.MX[owner] = append(r.MX[owner], &net.MX{Host: fqdn(l[1].s), Pref: uint16(pref)})
+		case "TXT":
+			if err := need(1); err != nil {
+				return err
+			}
+			var txt string
+			for _, t := range l {
+				txt += t.s
+			}
+			r.TXT[owner] = append(r.TXT[owner], txt)
+		case "CNAME":
+			if err := need(1); err != nil {
+				return err
+			}
+			r.CNAME[owner] = fqdn(l[0].s)
+		case "TLSA":
+			if err := need(4); err != nil {
+				return err
+			}
+			var v [3]uint8
+			for i := range v {
+				x, err := strconv.ParseUint(l[i].s, 10, 8)
+				if err != nil {
+					return fmt.Errorf("invalid tlsa field %q", l[i].s)
+				}
+				v[i] = uint8(x)
+			}
+			var data string
+			for _, t := range l[3:] {
+				data += t.s
+			}
+			buf, err := hex.DecodeString(data)
+			if err != nil {
+				return fmt.Errorf("invalid tlsa data: %v", err)
+			}
+			r.TLSA[owner] = append(r.TLSA[owner], adns.TLSA{Usage: adns.TLSAUsage(v[0]), Selector: adns.TLSASelector(v[1]), MatchType: adns.TLSAMatchType(v[2]), CertAssoc: buf})
+		}
+		// Other record types, like SOA and NS, are not used by the checks.
+		return nil
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		lineno++
+		line = strings.TrimRight(line, "\r")
+		if parens == 0 {
+			startLine = lineno
+			ownerBlank = line != "" && (line[0] == ' ' || line[0] == '\t')
+			pending = nil
+		}
+
+		var l []zoneToken
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case c == ';':
+				i = len(line)
+			case c == ' ' || c == '\t':
+				i++
+			case c == '(':
+				parens++
+				i++
+			case c == ')':
+				parens--
+				if parens < 0 {
+					return fmt.Errorf("%d: unbalanced parentheses", lineno)
+				}
+				i++
+			case c == '"':
+				var b strings.Builder
+				i++
+				for ; i < len(line) && line[i] != '"'; i++ {
+					if line[i] == '\\' && i+1 < len(line) {
+						i++
+					}
+					b.WriteByte(line[i])
+				}
+				if i >= len(line) {
+					return fmt.Errorf("%d: unterminated quoted string", lineno)
+				}
+				i++
+				l = append(l, zoneToken{b.String(), true})
+			default:
+				j := i
+				for j < len(line) && !strings.ContainsRune(" \t;()\"", rune(line[j])) {
+					j++
+				}
+				l = append(l, zoneToken{line[i:j], false})
+				i = j
+			}
+		}
+		pending = append(pending, l)
+		if parens > 0 {
+			continue
+		}
+		var all []zoneToken
+		for _, l := range pending {
+			all = append(all, l...)
+		}
+		if err := record(all); err != nil {
+			return fmt.Errorf("%d: %v", startLine, err)
+		}
+	}
+	if parens != 0 {
+		return fmt.Errorf("%d: unbalanced parentheses", startLine)
+	}
+	return nil
+}